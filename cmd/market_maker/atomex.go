@@ -6,6 +6,7 @@ import (
 	"crypto/sha256"
 	"encoding/binary"
 	"encoding/hex"
+	"sync/atomic"
 	"time"
 
 	"github.com/atomex-protocol/watch_tower/cmd/market_maker/strategy"
@@ -13,6 +14,7 @@ import (
 	"github.com/atomex-protocol/watch_tower/internal/atomex"
 	"github.com/atomex-protocol/watch_tower/internal/atomex/signers"
 	"github.com/atomex-protocol/watch_tower/internal/chain"
+	"github.com/atomex-protocol/watch_tower/internal/chain/tools"
 	"github.com/atomex-protocol/watch_tower/internal/exchange"
 	"github.com/atomex-protocol/watch_tower/internal/types"
 	"github.com/pkg/errors"
@@ -76,10 +78,8 @@ func (mm *MarketMaker) sendOneByOneLimits() error {
 			continue
 		}
 
-		for i := range quotes {
-			if err := mm.sendOrder(quotes[i], true); err != nil {
-				return err
-			}
+		if err := mm.sendQuotes(quotes, true); err != nil {
+			return err
 		}
 	}
 	return nil
@@ -106,16 +106,14 @@ func (mm *MarketMaker) processTicker(tick exchange.Ticker, symbol string) error
 
 		mm.tickers[ticker.Symbol] = ticker
 
-		args := strategy.NewArgs().Ask(ticker.Ask).Bid(ticker.Bid).AskVolume(ticker.AskVolume).BidVolume(ticker.BidVolume).Symbol(synthSymbol)
+		args := mm.strategyArgs(ticker, synthSymbol)
 		for i := range mm.strategies {
 			quotes, err := mm.strategies[i].Quotes(args)
 			if err != nil {
 				return nil
 			}
-			for j := range quotes {
-				if err := mm.sendOrder(quotes[j], false); err != nil {
-					return errors.Wrap(err, "sendOrder")
-				}
+			if err := mm.sendQuotes(quotes, false); err != nil {
+				return errors.Wrap(err, "sendQuotes")
 			}
 		}
 	}
@@ -123,6 +121,42 @@ func (mm *MarketMaker) processTicker(tick exchange.Ticker, symbol string) error
 	return nil
 }
 
+func (mm *MarketMaker) strategyArgs(ticker exchange.Ticker, symbol string) *strategy.Args {
+	args := strategy.NewArgs().Ask(ticker.Ask).Bid(ticker.Bid).AskVolume(ticker.AskVolume).BidVolume(ticker.BidVolume).Symbol(symbol)
+	if info, ok := mm.symbols[symbol]; ok {
+		args = args.BaseBalance(mm.inventory.Balance(info.Base)).QuoteBalance(mm.inventory.Balance(info.Quote))
+	}
+	return args
+}
+
+// sendQuotes - sends orders of quotes. Each ladder level is replaced independently. Orders of levels which are deeper than the quoted ones are cancelled.
+func (mm *MarketMaker) sendQuotes(quotes []strategy.Quote, force bool) error {
+	depth := make(map[clientOrderID]int)
+	for i := range quotes {
+		if err := mm.sendOrder(quotes[i], force); err != nil {
+			return errors.Wrap(err, "sendOrder")
+		}
+
+		key := clientOrderID{kind: quotes[i].Strategy, symbol: quotes[i].Symbol, side: quotes[i].Side}
+		if quotes[i].Level >= depth[key] {
+			depth[key] = quotes[i].Level + 1
+		}
+	}
+
+	stale := make([]clientOrderID, 0)
+	mm.orders.Range(func(cid clientOrderID, order *Order) bool {
+		key := clientOrderID{kind: cid.kind, symbol: cid.symbol, side: cid.side}
+		if count, ok := depth[key]; ok && cid.level >= count {
+			stale = append(stale, cid)
+		}
+		return true
+	})
+	for i := range stale {
+		mm.cancelOrder(stale[i])
+	}
+	return nil
+}
+
 func (mm *MarketMaker) sendOrder(quote strategy.Quote, force bool) error {
 	symbol, ok := mm.atomexMeta.ToSymbols[quote.Symbol]
 	if !ok {
@@ -135,16 +169,17 @@ func (mm *MarketMaker) sendOrder(quote strategy.Quote, force bool) error {
 	}
 
 	price, _ := quote.Price.Float64()
-	qty, _ := quote.Volume.Float64()
 	side := mustAtomexSide(quote.Side)
 
 	clientID := clientOrderID{
 		kind:   quote.Strategy,
 		symbol: quote.Symbol,
 		side:   quote.Side,
-		index:  time.Now().UnixNano(),
+		level:  quote.Level,
+		index:  mm.nextOrderIndex(),
 	}
 
+	var qty float64
 	var cancelErr error
 	notChanged := true
 	var found bool
@@ -160,6 +195,7 @@ func (mm *MarketMaker) sendOrder(quote strategy.Quote, force bool) error {
 					cancelErr = err
 				}
 				mm.log.Info().Int64("id", order.ID).Msg("order cancelling...")
+				mm.inventory.Release(order.ClientID)
 				notChanged = false
 			}
 			return false
@@ -174,6 +210,16 @@ func (mm *MarketMaker) sendOrder(quote strategy.Quote, force bool) error {
 		return nil
 	}
 
+	volume := mm.inventory.Clip(symbolInfo, quote)
+	if volume.IsZero() {
+		mm.log.Warn().Str("symbol", quote.Symbol).Str("volume", quote.Volume.String()).Msg("insufficient inventory. quote is suppressed.")
+		return nil
+	}
+	if !volume.Equal(quote.Volume) {
+		mm.log.Info().Str("symbol", quote.Symbol).Str("volume", quote.Volume.String()).Str("clipped", volume.String()).Msg("quote is clipped by inventory")
+	}
+	qty, _ = volume.Float64()
+
 	receiver, err := mm.getReceiverWallet(symbolInfo, quote.Side)
 	if err != nil {
 		return errors.Wrap(err, "getReceiverWallet")
@@ -234,9 +280,24 @@ func (mm *MarketMaker) sendOrder(quote strategy.Quote, force bool) error {
 
 	order := requestToOrder(request, scrt)
 	mm.orders.Store(clientID, &order)
+	mm.inventory.Reserve(request.ClientOrderID, symbolInfo, quote.Side, quote.Price, volume)
 	return nil
 }
 
+// nextOrderIndex - returns current unix time in nanoseconds which is greater than all previous indices, so orders of ladder levels sent at once get unique secrets
+func (mm *MarketMaker) nextOrderIndex() int64 {
+	for {
+		last := atomic.LoadInt64(&mm.lastOrderIndex)
+		index := time.Now().UnixNano()
+		if index <= last {
+			index = last + 1
+		}
+		if atomic.CompareAndSwapInt64(&mm.lastOrderIndex, last, index) {
+			return index
+		}
+	}
+}
+
 type secret struct {
 	Value string
 	Hash  string
@@ -282,6 +343,7 @@ func (mm *MarketMaker) cancelAll(ctx context.Context) (cancelErr error) {
 
 		if response.Result {
 			mm.log.Debug().Int64("order_id", order.ID).Str("symbol", order.Symbol).Msg("cancelled")
+			mm.inventory.Release(order.ClientID)
 
 			var cid clientOrderID
 			if err := cid.parse(order.ClientID); err != nil {
@@ -302,6 +364,8 @@ func (mm *MarketMaker) cancelAll(ctx context.Context) (cancelErr error) {
 
 func (mm *MarketMaker) handleAtomexSwapUpdate(swap atomex.Swap) error {
 	if swap.User.Status != atomex.SwapStatusInvolved || swap.CounterParty.Status != atomex.SwapStatusInvolved {
+		// Atomex doesn't send involved swap after initiation, so it's never reserved again
+		delete(mm.reservedSwaps, chain.Hex(swap.SecretHash))
 		return nil
 	}
 
@@ -316,8 +380,84 @@ func (mm *MarketMaker) handleAtomexSwapUpdate(swap atomex.Swap) error {
 		return errors.Wrap(err, "atomexSwapToInternal")
 	}
 
-	mm.swaps.Store(chain.Hex(swap.SecretHash), s)
-	return nil
+	side := strategy.Bid
+	if swap.Side == atomex.SideSell {
+		side = strategy.Ask
+	}
+
+	// swap may be stored already by chain event, so Atomex data is merged into it
+	current := mm.swaps.LoadOrStore(chain.Hex(swap.SecretHash), s)
+	if current != s {
+		mergeAtomexSwap(current, s)
+	}
+
+	// swap's amounts are reserved until our leg is initiated. Atomex sends the swap until both legs are initiated,
+	// so it's reserved only when it's received first time. Otherwise a released reserve would be restored.
+	if _, ok := mm.reservedSwaps[chain.Hex(swap.SecretHash)]; !ok {
+		mm.reservedSwaps[chain.Hex(swap.SecretHash)] = struct{}{}
+		mm.inventory.Reserve(swap.SecretHash, s.Symbol, side, swap.Price, swap.Qty)
+	}
+
+	received := s.Symbol.Base
+	if side == strategy.Ask {
+		received = s.Symbol.Quote
+	}
+	mm.ledger.Swap(chain.Hex(swap.SecretHash), s.Symbol.Name, received.Name)
+
+	return mm.watchCounterPartyLeg(swap)
+}
+
+// mergeAtomexSwap - fills swap which is created by chain event with Atomex data. Statuses of chain events are newer, so they are kept.
+func mergeAtomexSwap(swap, atomexSwap *tools.Swap) {
+	if swap.Symbol.Name == "" {
+		swap.Symbol = atomexSwap.Symbol
+	}
+	if swap.RefundTime.IsZero() {
+		swap.RefundTime = atomexSwap.RefundTime
+	}
+	if swap.Secret.IsEmpty() {
+		swap.Secret = atomexSwap.Secret
+	}
+	if swap.Status == tools.StatusEmpty {
+		swap.Status = atomexSwap.Status
+	}
+	if swap.Initiator.ChainType == chain.ChainTypeUnknown {
+		swap.Initiator = atomexSwap.Initiator
+	} else {
+		swap.Initiator.Merge(atomexSwap.Initiator)
+	}
+	if swap.Acceptor.ChainType == chain.ChainTypeUnknown {
+		swap.Acceptor = atomexSwap.Acceptor
+	} else {
+		swap.Acceptor.Merge(atomexSwap.Acceptor)
+	}
+}
+
+// watchCounterPartyLeg - registers counterparty's HTLC in chains which can't find it without swap parameters
+func (mm *MarketMaker) watchCounterPartyLeg(swap atomex.Swap) error {
+	symbol, ok := mm.atomexMeta.FromSymbols[swap.Symbol]
+	if !ok {
+		return errors.Errorf("unknown symbol: %s", swap.Symbol)
+	}
+	info, ok := mm.symbols[symbol]
+	if !ok {
+		return errors.Errorf("unknown symbol: %s", symbol)
+	}
+
+	var asset types.Asset
+	switch swap.Side {
+	case atomex.SideBuy:
+		asset = info.Base
+	case atomex.SideSell:
+		asset = info.Quote
+	}
+
+	return mm.tracker.Watch(asset.ChainType(), chain.WatchArgs{
+		HashedSecret: chain.Hex(swap.SecretHash),
+		Initiator:    swap.CounterParty.Requisites.RefundAddress,
+		Participant:  swap.User.Requisites.ReceivingAddress,
+		RefundTime:   swap.TimeStamp.Add(time.Duration(swap.CounterParty.Requisites.LockTime) * time.Second),
+	})
 }
 
 func (mm *MarketMaker) initiateInvolvedSwap(ctx context.Context, swap atomex.Swap) error {
@@ -350,6 +490,7 @@ func (mm *MarketMaker) initiateInvolvedSwap(ctx context.Context, swap atomex.Swa
 		Participant:  swap.CounterParty.Requisites.ReceivingAddress,
 		Contract:     asset.AtomexContract,
 		TokenAddress: asset.Contract,
+		TokenID:      asset.TokenID,
 		Amount:       amountToInt(swap.Qty, asset.Decimals),
 		PayOff:       payOff,
 		RefundTime:   refundTime,
@@ -371,27 +512,55 @@ func (mm *MarketMaker) handleAtomexOrderUpdate(order atomex.OrderWebsocket) erro
 
 	switch order.Status {
 	case atomex.OrderStatusCanceled, atomex.OrderStatusRejected:
+		mm.inventory.Release(order.ClientOrderID)
 		mm.orders.Delete(cid)
 		if internalOrder != nil {
 			mm.swaps.Delete(chain.Hex(internalOrder.Secret.Hash))
 		}
 
 	case atomex.OrderStatusPartiallyFilled, atomex.OrderStatusFilled:
+		qty := mm.fills.Add(order)
+		if qty.IsPositive() {
+			side := strategy.Bid
+			if order.Side == atomex.SideSell {
+				side = strategy.Ask
+			}
+			mm.ledger.Fill(cid.symbol, side, order.Price, qty)
+		}
+
+		if mm.hedger != nil {
+			placed, err := mm.hedger.Hedge(order, cid.symbol, qty)
+			if err != nil {
+				mm.log.Err(err).Int64("order_id", order.ID).Msg("hedge")
+			}
+			mm.recordHedges(placed)
+		}
+
+		if order.Status == atomex.OrderStatusFilled {
+			// the swap of filled order reserves its amounts
+			mm.inventory.Release(order.ClientOrderID)
+		} else if symbol, ok := mm.symbols[cid.symbol]; ok && qty.IsPositive() {
+			// the filled part is reserved by its swap
+			side := strategy.Bid
+			if order.Side == atomex.SideSell {
+				side = strategy.Ask
+			}
+			mm.inventory.Shrink(order.ClientOrderID, symbol, side, order.Price, qty)
+		}
+
 		ticker, ok := mm.tickers[cid.symbol]
 		if !ok {
 			return nil
 		}
 
-		args := strategy.NewArgs().Ask(ticker.Ask).Bid(ticker.Bid).AskVolume(ticker.AskVolume).BidVolume(ticker.BidVolume).Symbol(cid.symbol)
+		args := mm.strategyArgs(ticker, cid.symbol)
 		for i := range mm.strategies {
 			quotes, err := mm.strategies[i].Quotes(args)
 			if err != nil {
 				return errors.Wrap(err, "Quotes")
 			}
-			for j := range quotes {
-				if err := mm.sendOrder(quotes[j], true); err != nil {
-					return errors.Wrap(err, "sendOrder")
-				}
+			if err := mm.sendQuotes(quotes, true); err != nil {
+				return errors.Wrap(err, "sendQuotes")
 			}
 		}
 	case atomex.OrderStatusPending: // do not handle. it's internal atomex status.
@@ -414,6 +583,7 @@ func (mm *MarketMaker) cancelOrder(clientID clientOrderID) bool {
 			} else {
 				mm.log.Info().Int64("id", order.ID).Msg("order cancelling...")
 				mm.secrets.Delete(chain.Hex(order.Secret.Hash))
+				mm.inventory.Release(order.ClientID)
 			}
 			mm.orders.Delete(cid)
 			found = true
@@ -0,0 +1,587 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/atomex-protocol/watch_tower/cmd/market_maker/strategy"
+	"github.com/atomex-protocol/watch_tower/cmd/market_maker/synthetic"
+	"github.com/atomex-protocol/watch_tower/internal/atomex"
+	"github.com/atomex-protocol/watch_tower/internal/exchange"
+	"github.com/atomex-protocol/watch_tower/internal/exchange/binance"
+	"github.com/atomex-protocol/watch_tower/internal/types"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+)
+
+// BacktestConfig - `File` is a file of recorded tickers for `tickers` source or a directory of recorder for `record` source. `Interval` is a Binance klines interval for `klines` source.
+// `Balances` are initial balances by asset names in asset units. Report is written to `Report` file if it's set.
+type BacktestConfig struct {
+	Source   BacktestSource             `yaml:"source" validate:"omitempty,oneof=tickers klines record"`
+	File     string                     `yaml:"file"`
+	Interval binance.Interval           `yaml:"interval"`
+	Start    time.Time                  `yaml:"start"`
+	End      time.Time                  `yaml:"end"`
+	Fill     FillConfig                 `yaml:"fill"`
+	Balances map[string]decimal.Decimal `yaml:"balances"`
+	Report   string                     `yaml:"report"`
+}
+
+// BacktestSource -
+type BacktestSource string
+
+// backtest sources
+const (
+	BacktestSourceTickers BacktestSource = "tickers"
+	BacktestSourceKlines  BacktestSource = "klines"
+	BacktestSourceRecord  BacktestSource = "record"
+)
+
+// FillConfig - `File` is a file of Atomex top of book snapshots for `book` model.
+// `Intensity` and `Decay` are parameters of `probabilistic` model: quote is filled with intensity `Intensity * exp(-Decay * distance)` per second,
+// where distance is relative distance of quote's price from mid price.
+type FillConfig struct {
+	Model     FillModel `yaml:"model" validate:"omitempty,oneof=book probabilistic"`
+	File      string    `yaml:"file"`
+	Intensity float64   `yaml:"intensity" validate:"omitempty,gte=0"`
+	Decay     float64   `yaml:"decay" validate:"omitempty,gte=0"`
+	Seed      int64     `yaml:"seed"`
+}
+
+// FillModel -
+type FillModel string
+
+// fill models
+const (
+	FillModelBook          FillModel = "book"
+	FillModelProbabilistic FillModel = "probabilistic"
+)
+
+// BacktestReport -
+type BacktestReport struct {
+	Start      time.Time        `json:"start"`
+	End        time.Time        `json:"end"`
+	Events     int              `json:"events"`
+	Strategies []StrategyReport `json:"strategies"`
+}
+
+// StrategyReport - `PnL` and drawdown are changes of portfolio value in quote asset. `Realized` and `Unrealized` are PnL of ledger.
+// Uptimes are shares of time when the side is quoted.
+type StrategyReport struct {
+	Strategy         strategy.Kind    `json:"strategy"`
+	Symbol           string           `json:"symbol"`
+	Fills            []BacktestFill   `json:"fills"`
+	Volume           decimal.Decimal  `json:"volume"`
+	PnL              decimal.Decimal  `json:"pnl"`
+	Realized         decimal.Decimal  `json:"realized"`
+	Unrealized       decimal.Decimal  `json:"unrealized"`
+	MaxDrawdown      decimal.Decimal  `json:"max_drawdown"`
+	MaxDrawdownRatio decimal.Decimal  `json:"max_drawdown_ratio"`
+	BidUptime        float64          `json:"bid_uptime"`
+	AskUptime        float64          `json:"ask_uptime"`
+	Inventory        []InventoryPoint `json:"inventory"`
+}
+
+// BacktestFill -
+type BacktestFill struct {
+	Time   time.Time       `json:"time"`
+	Side   string          `json:"side"`
+	Price  decimal.Decimal `json:"price"`
+	Volume decimal.Decimal `json:"volume"`
+}
+
+// InventoryPoint - balances after fill
+type InventoryPoint struct {
+	Time  time.Time       `json:"time"`
+	Base  decimal.Decimal `json:"base"`
+	Quote decimal.Decimal `json:"quote"`
+}
+
+// Backtest - replays tickers of quote provider through strategies and fills their quotes by fill model. Each strategy has its own balances.
+type Backtest struct {
+	cfg    BacktestConfig
+	log    zerolog.Logger
+	klines klinesSource
+	rnd    *rand.Rand
+
+	synthetics    map[string]synthetic.Synthetic
+	synthSymbols  []string
+	toSymbols     map[string]string
+	fromSymbols   map[string]synthetic.Config
+	atomexSymbols map[string]string
+	runs          []*backtestRun
+
+	tickers map[string]exchange.Ticker
+	tops    map[string]atomex.TopOfBook
+}
+
+// NewBacktest -
+func NewBacktest(cfg Config, log zerolog.Logger) (*Backtest, error) {
+	switch cfg.Backtest.Source {
+	case BacktestSourceTickers:
+		if cfg.Backtest.File == "" {
+			return nil, errors.New("empty file of tickers")
+		}
+	case BacktestSourceRecord:
+		if cfg.Backtest.File == "" {
+			return nil, errors.New("empty directory of records")
+		}
+	case BacktestSourceKlines:
+		if cfg.Backtest.Interval.Duration() == 0 {
+			return nil, errors.Errorf("unknown klines interval: %s", cfg.Backtest.Interval)
+		}
+		if cfg.Backtest.Start.IsZero() {
+			return nil, errors.New("empty start of klines")
+		}
+	default:
+		return nil, errors.Errorf("unknown backtest source: %s", cfg.Backtest.Source)
+	}
+
+	switch cfg.Backtest.Fill.Model {
+	case FillModelBook:
+		if cfg.Backtest.Fill.File == "" && cfg.Backtest.Source != BacktestSourceRecord {
+			return nil, errors.New("empty file of Atomex top of book")
+		}
+	case FillModelProbabilistic:
+		if cfg.Backtest.Fill.Intensity <= 0 {
+			return nil, errors.New("intensity of probabilistic fill model should be positive")
+		}
+	default:
+		return nil, errors.Errorf("unknown fill model: %s", cfg.Backtest.Fill.Model)
+	}
+
+	synthetics := make(map[string]synthetic.Synthetic)
+	synthSymbols := make([]string, 0, len(cfg.QuoteProviderMeta.FromSymbols))
+	for symbol, synthCfg := range cfg.QuoteProviderMeta.FromSymbols {
+		synth, err := synthetic.New(symbol, synthCfg)
+		if err != nil {
+			return nil, err
+		}
+		synthetics[symbol] = synth
+		synthSymbols = append(synthSymbols, symbol)
+	}
+	// fixed order of synthetics makes results reproducible by seed. Direct synthetics go first, so divided ones use legs of the same tick.
+	sort.Slice(synthSymbols, func(i, j int) bool {
+		ti, tj := synthetics[synthSymbols[i]].Type(), synthetics[synthSymbols[j]].Type()
+		if ti != tj {
+			return ti == synthetic.DirectType
+		}
+		return synthSymbols[i] < synthSymbols[j]
+	})
+
+	runs := make([]*backtestRun, 0, len(cfg.Strategies))
+	for _, s := range cfg.Strategies {
+		if s.Kind == strategy.KindAvellanedaStoikov && s.Horizon == 0 {
+			s.Horizon = cfg.General.Atomex.Settings.LockTime
+		}
+		strat, err := strategy.New(s)
+		if err != nil {
+			return nil, err
+		}
+
+		var symbol types.Symbol
+		for i := range cfg.General.Symbols {
+			if cfg.General.Symbols[i].Name == s.SymbolName {
+				symbol = cfg.General.Symbols[i]
+				break
+			}
+		}
+		if symbol.Name == "" {
+			return nil, errors.Errorf("unknown symbol: %s", s.SymbolName)
+		}
+
+		runs = append(runs, newBacktestRun(strat, s.Kind, symbol, cfg.Backtest.Balances, cfg.Ledger))
+	}
+
+	seed := cfg.Backtest.Fill.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	return &Backtest{
+		cfg:           cfg.Backtest,
+		log:           log,
+		klines:        binance.NewBinance(binance.WithRestURL(binance.BaseURLServer2)),
+		rnd:           rand.New(rand.NewSource(seed)),
+		synthetics:    synthetics,
+		synthSymbols:  synthSymbols,
+		toSymbols:     cfg.QuoteProviderMeta.ToSymbols,
+		fromSymbols:   cfg.QuoteProviderMeta.FromSymbols,
+		atomexSymbols: cfg.General.Atomex.FromSymbols,
+		runs:          runs,
+		tickers:       make(map[string]exchange.Ticker),
+		tops:          make(map[string]atomex.TopOfBook),
+	}, nil
+}
+
+// Run - loads events and replays them. It returns report of each strategy.
+func (b *Backtest) Run() (BacktestReport, error) {
+	events, err := b.load()
+	if err != nil {
+		return BacktestReport{}, err
+	}
+	b.log.Info().Int("events", len(events)).Msg("backtest is started")
+
+	for i := range events {
+		switch {
+		case events[i].ticker != nil:
+			b.onTicker(events[i].time, *events[i].ticker)
+		case events[i].book != nil:
+			b.onTopOfBook(events[i].time, *events[i].book)
+		}
+	}
+
+	report := BacktestReport{
+		Events:     len(events),
+		Strategies: make([]StrategyReport, len(b.runs)),
+	}
+	if len(events) > 0 {
+		report.Start = events[0].time
+		report.End = events[len(events)-1].time
+	}
+	for i := range b.runs {
+		report.Strategies[i] = b.runs[i].report(b.tickers)
+	}
+	return report, nil
+}
+
+func (b *Backtest) load() ([]backtestEvent, error) {
+	var events []backtestEvent
+	switch b.cfg.Source {
+	case BacktestSourceTickers:
+		tickers, err := loadTickers(b.cfg.File, b.cfg.Start, b.cfg.End)
+		if err != nil {
+			return nil, errors.Wrap(err, "loadTickers")
+		}
+		events = tickers
+	case BacktestSourceKlines:
+		symbols := make([]string, 0)
+		unique := make(map[string]struct{})
+		for _, run := range b.runs {
+			for _, symbol := range b.fromSymbols[run.symbol.Name].Symbols {
+				if _, ok := unique[symbol]; !ok {
+					unique[symbol] = struct{}{}
+					symbols = append(symbols, symbol)
+				}
+			}
+		}
+		klines, err := loadKlines(b.klines, symbols, b.cfg.Interval, b.cfg.Start, b.cfg.End)
+		if err != nil {
+			return nil, errors.Wrap(err, "loadKlines")
+		}
+		events = klines
+	case BacktestSourceRecord:
+		records, err := loadRecords(b.cfg.File, b.cfg.Start, b.cfg.End, b.cfg.Fill.Model == FillModelBook && b.cfg.Fill.File == "")
+		if err != nil {
+			return nil, errors.Wrap(err, "loadRecords")
+		}
+		events = records
+	}
+
+	if b.cfg.Fill.Model == FillModelBook && b.cfg.Fill.File != "" {
+		book, err := loadTopOfBook(b.cfg.Fill.File, b.cfg.Start, b.cfg.End)
+		if err != nil {
+			return nil, errors.Wrap(err, "loadTopOfBook")
+		}
+		events = append(events, book...)
+	}
+
+	sortEvents(events)
+	return events, nil
+}
+
+func (b *Backtest) onTicker(ts time.Time, tick exchange.Ticker) {
+	for _, synthSymbol := range b.synthSymbols {
+		ticker, err := b.synthetics[synthSymbol].Ticker(tick, b.tickers, b.toSymbols)
+		if err != nil {
+			continue
+		}
+		b.tickers[ticker.Symbol] = ticker
+
+		for _, run := range b.runs {
+			if run.symbol.Name != synthSymbol {
+				continue
+			}
+
+			dt := run.advance(ts)
+			if b.cfg.Fill.Model == FillModelProbabilistic {
+				run.fillByProbability(ts, ticker, dt, b.cfg.Fill, b.rnd)
+			}
+			run.mark(ticker)
+			run.requote(ts, ticker)
+			if top, ok := b.tops[synthSymbol]; ok {
+				run.fillByBook(ts, top)
+			}
+		}
+	}
+}
+
+func (b *Backtest) onTopOfBook(ts time.Time, top atomex.TopOfBook) {
+	symbol, ok := b.atomexSymbols[top.Symbol]
+	if !ok {
+		return
+	}
+	b.tops[symbol] = top
+
+	for _, run := range b.runs {
+		if run.symbol.Name != symbol {
+			continue
+		}
+		run.advance(ts)
+		run.fillByBook(ts, top)
+	}
+}
+
+// backtestRun - state of strategy in backtest
+type backtestRun struct {
+	strategy strategy.Strategy
+	kind     strategy.Kind
+	symbol   types.Symbol
+	ledger   *Ledger
+
+	base   decimal.Decimal
+	quote  decimal.Decimal
+	quotes []strategy.Quote
+
+	last      time.Time
+	duration  time.Duration
+	bidUptime time.Duration
+	askUptime time.Duration
+
+	started     bool
+	startValue  decimal.Decimal
+	value       decimal.Decimal
+	peak        decimal.Decimal
+	maxDrawdown decimal.Decimal
+	maxRatio    decimal.Decimal
+
+	volume    decimal.Decimal
+	fills     []BacktestFill
+	inventory []InventoryPoint
+}
+
+func newBacktestRun(strat strategy.Strategy, kind strategy.Kind, symbol types.Symbol, balances map[string]decimal.Decimal, ledger LedgerConfig) *backtestRun {
+	return &backtestRun{
+		strategy:  strat,
+		kind:      kind,
+		symbol:    symbol,
+		ledger:    NewLedger(ledger),
+		base:      balances[symbol.Base.Name],
+		quote:     balances[symbol.Quote.Name],
+		quotes:    make([]strategy.Quote, 0),
+		fills:     make([]BacktestFill, 0),
+		inventory: make([]InventoryPoint, 0),
+	}
+}
+
+// advance - moves time of run to `ts` and accounts uptime of current quotes. It returns elapsed time.
+func (run *backtestRun) advance(ts time.Time) time.Duration {
+	if run.last.IsZero() {
+		run.last = ts
+		return 0
+	}
+
+	dt := ts.Sub(run.last)
+	run.last = ts
+	run.duration += dt
+
+	var bid, ask bool
+	for i := range run.quotes {
+		switch run.quotes[i].Side {
+		case strategy.Bid:
+			bid = true
+		case strategy.Ask:
+			ask = true
+		}
+	}
+	if bid {
+		run.bidUptime += dt
+	}
+	if ask {
+		run.askUptime += dt
+	}
+	return dt
+}
+
+// mark - marks portfolio value to mid price and updates drawdown
+func (run *backtestRun) mark(ticker exchange.Ticker) {
+	if !ticker.Ask.IsPositive() || !ticker.Bid.IsPositive() {
+		return
+	}
+	run.value = run.base.Mul(decimal.Avg(ticker.Ask, ticker.Bid)).Add(run.quote)
+
+	if !run.started {
+		run.started = true
+		run.startValue = run.value
+		run.peak = run.value
+	}
+	if run.value.GreaterThan(run.peak) {
+		run.peak = run.value
+	}
+	if drawdown := run.peak.Sub(run.value); drawdown.GreaterThan(run.maxDrawdown) {
+		run.maxDrawdown = drawdown
+		if run.peak.IsPositive() {
+			run.maxRatio = drawdown.Div(run.peak)
+		}
+	}
+}
+
+// requote - replaces quotes by the strategy's ones. Volumes are clipped by balances.
+func (run *backtestRun) requote(ts time.Time, ticker exchange.Ticker) {
+	args := strategy.NewArgs().
+		Ask(ticker.Ask).Bid(ticker.Bid).AskVolume(ticker.AskVolume).BidVolume(ticker.BidVolume).
+		Symbol(run.symbol.Name).BaseBalance(run.base).QuoteBalance(run.quote).Time(ts)
+
+	run.quotes = run.quotes[:0]
+
+	quotes, err := run.strategy.Quotes(args)
+	if err != nil {
+		return
+	}
+
+	base, quote := run.base, run.quote
+	for _, q := range quotes {
+		if !q.Price.IsPositive() {
+			continue
+		}
+
+		switch q.Side {
+		case strategy.Ask:
+			q.Volume = decimal.Min(q.Volume, base)
+			base = base.Sub(q.Volume)
+		case strategy.Bid:
+			q.Volume = decimal.Min(q.Volume, quote.Div(q.Price))
+			quote = quote.Sub(q.Volume.Mul(q.Price))
+		}
+		if q.Volume.IsPositive() {
+			run.quotes = append(run.quotes, q)
+		}
+	}
+}
+
+// fillByBook - fills quotes which cross Atomex top of book
+func (run *backtestRun) fillByBook(ts time.Time, top atomex.TopOfBook) {
+	run.fillIf(ts, func(q strategy.Quote) bool {
+		switch q.Side {
+		case strategy.Bid:
+			return top.Ask.IsPositive() && q.Price.GreaterThanOrEqual(top.Ask)
+		case strategy.Ask:
+			return top.Bid.IsPositive() && q.Price.LessThanOrEqual(top.Bid)
+		}
+		return false
+	})
+}
+
+// fillByProbability - fills quotes with probability `1 - exp(-intensity * exp(-decay * distance) * dt)`. Quotes which cross mid price are filled anyway.
+func (run *backtestRun) fillByProbability(ts time.Time, ticker exchange.Ticker, dt time.Duration, cfg FillConfig, rnd *rand.Rand) {
+	if !ticker.Ask.IsPositive() || !ticker.Bid.IsPositive() {
+		return
+	}
+	mid := decimal.Avg(ticker.Ask, ticker.Bid)
+
+	run.fillIf(ts, func(q strategy.Quote) bool {
+		distance := q.Price.Sub(mid).Div(mid)
+		if q.Side == strategy.Bid {
+			distance = distance.Neg()
+		}
+		if !distance.IsPositive() {
+			return true
+		}
+
+		relative, _ := distance.Float64()
+		probability := 1 - math.Exp(-cfg.Intensity*math.Exp(-cfg.Decay*relative)*dt.Seconds())
+		return rnd.Float64() < probability
+	})
+}
+
+// fillIf - fills entirely quotes which satisfy `filled`
+func (run *backtestRun) fillIf(ts time.Time, filled func(q strategy.Quote) bool) {
+	rest := run.quotes[:0]
+	for _, q := range run.quotes {
+		if !filled(q) {
+			rest = append(rest, q)
+			continue
+		}
+
+		side := "bid"
+		if q.Side == strategy.Bid {
+			run.base = run.base.Add(q.Volume)
+			run.quote = run.quote.Sub(q.Volume.Mul(q.Price))
+		} else {
+			side = "ask"
+			run.base = run.base.Sub(q.Volume)
+			run.quote = run.quote.Add(q.Volume.Mul(q.Price))
+		}
+		run.ledger.Fill(run.symbol.Name, q.Side, q.Price, q.Volume)
+		run.volume = run.volume.Add(q.Volume)
+
+		run.fills = append(run.fills, BacktestFill{
+			Time:   ts,
+			Side:   side,
+			Price:  q.Price,
+			Volume: q.Volume,
+		})
+		run.inventory = append(run.inventory, InventoryPoint{
+			Time:  ts,
+			Base:  run.base,
+			Quote: run.quote,
+		})
+	}
+	run.quotes = rest
+}
+
+func (run *backtestRun) report(tickers map[string]exchange.Ticker) StrategyReport {
+	report := StrategyReport{
+		Strategy:         run.kind,
+		Symbol:           run.symbol.Name,
+		Fills:            run.fills,
+		Volume:           run.volume,
+		PnL:              run.value.Sub(run.startValue),
+		MaxDrawdown:      run.maxDrawdown,
+		MaxDrawdownRatio: run.maxRatio,
+		Inventory:        run.inventory,
+	}
+	if run.duration > 0 {
+		report.BidUptime = run.bidUptime.Seconds() / run.duration.Seconds()
+		report.AskUptime = run.askUptime.Seconds() / run.duration.Seconds()
+	}
+
+	for _, pnl := range run.ledger.Report(tickers).Symbols {
+		if pnl.Symbol == run.symbol.Name {
+			report.Realized = pnl.Realized
+			report.Unrealized = pnl.Unrealized
+		}
+	}
+	return report
+}
+
+// Log -
+func (report StrategyReport) Log(log zerolog.Logger) {
+	log.Info().
+		Str("strategy", string(report.Strategy)).
+		Str("symbol", report.Symbol).
+		Int("fills", len(report.Fills)).
+		Str("volume", report.Volume.String()).
+		Str("pnl", report.PnL.String()).
+		Str("realized", report.Realized.String()).
+		Str("unrealized", report.Unrealized.String()).
+		Str("max_drawdown", report.MaxDrawdown.String()).
+		Str("max_drawdown_ratio", report.MaxDrawdownRatio.StringFixed(4)).
+		Float64("bid_uptime", report.BidUptime).
+		Float64("ask_uptime", report.AskUptime).
+		Msg("backtest report")
+}
+
+// Save - writes report to JSON file
+func (report BacktestReport) Save(name string) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, data, 0644)
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/atomex-protocol/watch_tower/internal/atomex"
+	"github.com/atomex-protocol/watch_tower/internal/exchange"
+	"github.com/atomex-protocol/watch_tower/internal/exchange/binance"
+	"github.com/atomex-protocol/watch_tower/internal/recorder"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// TickerRecord - line of recorded tickers file
+type TickerRecord struct {
+	Time      time.Time       `json:"time"`
+	Symbol    string          `json:"symbol"`
+	Ask       decimal.Decimal `json:"ask"`
+	AskVolume decimal.Decimal `json:"ask_volume"`
+	Bid       decimal.Decimal `json:"bid"`
+	BidVolume decimal.Decimal `json:"bid_volume"`
+}
+
+// backtestEvent - ticker of quote provider or top of Atomex book
+type backtestEvent struct {
+	time   time.Time
+	ticker *exchange.Ticker
+	book   *atomex.TopOfBook
+}
+
+func sortEvents(events []backtestEvent) {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].time.Before(events[j].time)
+	})
+}
+
+// readJSONLines - decodes each line of JSONL file which may be compressed by gzip. `.gz` extension means compressed file.
+func readJSONLines(name string, decode func(data []byte) error) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var reader io.Reader = f
+	if strings.HasSuffix(name, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return errors.Wrap(err, "gzip.NewReader")
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		data := scanner.Bytes()
+		if len(data) == 0 {
+			continue
+		}
+		if err := decode(data); err != nil {
+			return errors.Wrapf(err, "%s:%d", name, line)
+		}
+	}
+	return scanner.Err()
+}
+
+// loadTickers - loads recorded tickers of quote provider between `start` and `end`. Zero bound isn't checked.
+func loadTickers(name string, start, end time.Time) ([]backtestEvent, error) {
+	events := make([]backtestEvent, 0)
+	err := readJSONLines(name, func(data []byte) error {
+		var record TickerRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+		if !inRange(record.Time, start, end) {
+			return nil
+		}
+		events = append(events, backtestEvent{
+			time: record.Time,
+			ticker: &exchange.Ticker{
+				Symbol:    record.Symbol,
+				Ask:       record.Ask,
+				AskVolume: record.AskVolume,
+				Bid:       record.Bid,
+				BidVolume: record.BidVolume,
+			},
+		})
+		return nil
+	})
+	return events, err
+}
+
+// loadTopOfBook - loads snapshots of Atomex top of book which are returned by `MarketData/quotes` with millisecond timestamps
+func loadTopOfBook(name string, start, end time.Time) ([]backtestEvent, error) {
+	events := make([]backtestEvent, 0)
+	err := readJSONLines(name, func(data []byte) error {
+		var top atomex.TopOfBook
+		if err := json.Unmarshal(data, &top); err != nil {
+			return err
+		}
+		ts := time.UnixMilli(top.Timestamp).UTC()
+		if !inRange(ts, start, end) {
+			return nil
+		}
+		events = append(events, backtestEvent{
+			time: ts,
+			book: &top,
+		})
+		return nil
+	})
+	return events, err
+}
+
+// loadRecords - loads tickers and book tickers of quote provider from files of recorder in `dir`. Atomex top of book is loaded if `book` is set.
+func loadRecords(dir string, start, end time.Time, book bool) ([]backtestEvent, error) {
+	files, err := recorder.Files(dir, recorderFilePrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]backtestEvent, 0)
+	err = recorder.Read(files, func(record recorder.Record) error {
+		if !inRange(record.Time, start, end) {
+			return nil
+		}
+		if record.Source == recorder.SourceAtomex && !book {
+			return nil
+		}
+
+		value, err := record.Value()
+		if err != nil {
+			return err
+		}
+
+		switch typ := value.(type) {
+		case binance.WebsocketTicker:
+			events = append(events, backtestEvent{
+				time: record.Time,
+				ticker: &exchange.Ticker{
+					Symbol:    typ.Symbol,
+					Ask:       typ.Ask,
+					AskVolume: typ.AskQuantity,
+					Bid:       typ.Bid,
+					BidVolume: typ.BidQuantity,
+				},
+			})
+		case binance.BookTicker:
+			events = append(events, backtestEvent{
+				time: record.Time,
+				ticker: &exchange.Ticker{
+					Symbol:    typ.Symbol,
+					Ask:       typ.Ask,
+					AskVolume: typ.AskVolume,
+					Bid:       typ.Bid,
+					BidVolume: typ.BidVolume,
+				},
+			})
+		case []atomex.TopOfBook:
+			for i := range typ {
+				events = append(events, backtestEvent{
+					time: record.Time,
+					book: &typ[i],
+				})
+			}
+		}
+		return nil
+	})
+	return events, err
+}
+
+type klinesSource interface {
+	Klines(symbol string, interval binance.Interval, start, end time.Time) ([]exchange.OHLC, error)
+}
+
+// loadKlines - converts candles of provider symbols to tickers. Each candle gives 4 tickers in the interval: open, low, high and close for a growing candle
+// and open, high, low and close for a falling one. Ask and bid are equal to the price.
+func loadKlines(source klinesSource, symbols []string, interval binance.Interval, start, end time.Time) ([]backtestEvent, error) {
+	duration := interval.Duration()
+	if duration == 0 {
+		return nil, errors.Errorf("unknown klines interval: %s", interval)
+	}
+
+	events := make([]backtestEvent, 0)
+	for _, symbol := range symbols {
+		klines, err := source.Klines(symbol, interval, start, end)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Klines %s", symbol)
+		}
+
+		for _, kline := range klines {
+			prices := []decimal.Decimal{kline.Open, kline.Low, kline.High, kline.Close}
+			if kline.Close.LessThan(kline.Open) {
+				prices[1], prices[2] = kline.High, kline.Low
+			}
+			volume := kline.Volume.Div(decimal.NewFromInt(int64(len(prices))))
+			for i, price := range prices {
+				events = append(events, backtestEvent{
+					time: kline.Time.Add(duration * time.Duration(i) / time.Duration(len(prices))),
+					ticker: &exchange.Ticker{
+						Symbol:    symbol,
+						Ask:       price,
+						AskVolume: volume,
+						Bid:       price,
+						BidVolume: volume,
+					},
+				})
+			}
+		}
+	}
+	return events, nil
+}
+
+func inRange(ts, start, end time.Time) bool {
+	if !start.IsZero() && ts.Before(start) {
+		return false
+	}
+	if !end.IsZero() && ts.After(end) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"math/rand"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/atomex-protocol/watch_tower/cmd/market_maker/strategy"
+	"github.com/atomex-protocol/watch_tower/cmd/market_maker/synthetic"
+	"github.com/atomex-protocol/watch_tower/internal/atomex"
+	"github.com/atomex-protocol/watch_tower/internal/config"
+	"github.com/atomex-protocol/watch_tower/internal/exchange"
+	"github.com/atomex-protocol/watch_tower/internal/types"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeJSONLines(t *testing.T, name string, values ...interface{}) {
+	f, err := os.Create(name)
+	require.NoError(t, err)
+	defer f.Close()
+
+	encoder := json.NewEncoder(f)
+	for i := range values {
+		require.NoError(t, encoder.Encode(values[i]))
+	}
+}
+
+// fixedVolume - sets volume of quotes of `follow` strategy which doesn't quote volume
+type fixedVolume struct {
+	strategy.Strategy
+	volume decimal.Decimal
+}
+
+func (s fixedVolume) Quotes(args *strategy.Args) ([]strategy.Quote, error) {
+	quotes, err := s.Strategy.Quotes(args)
+	for i := range quotes {
+		quotes[i].Volume = s.volume
+	}
+	return quotes, err
+}
+
+func TestBacktest_Run(t *testing.T) {
+	d := decimal.RequireFromString
+	dir := t.TempDir()
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tickers := path.Join(dir, "tickers.jsonl")
+	writeJSONLines(t, tickers,
+		TickerRecord{Time: start, Symbol: "XTZETH", Bid: d("0.0010"), Ask: d("0.0010")},
+		TickerRecord{Time: start.Add(time.Minute), Symbol: "XTZETH", Bid: d("0.0011"), Ask: d("0.0011")},
+		TickerRecord{Time: start.Add(2 * time.Minute), Symbol: "XTZETH", Bid: d("0.0012"), Ask: d("0.0012")},
+	)
+	book := path.Join(dir, "book.jsonl")
+	writeJSONLines(t, book,
+		atomex.TopOfBook{Symbol: "XTZ/ETH", Timestamp: start.Add(30 * time.Second).UnixMilli(), Bid: d("0.0008"), Ask: d("0.0009")},
+		atomex.TopOfBook{Symbol: "XTZ/ETH", Timestamp: start.Add(45 * time.Second).UnixMilli(), Bid: d("0.0005"), Ask: d("0.005")},
+		atomex.TopOfBook{Symbol: "XTZ/ETH", Timestamp: start.Add(90 * time.Second).UnixMilli(), Bid: d("0.00115"), Ask: d("0.0013")},
+	)
+
+	xtz := types.Asset{Name: "XTZ", Chain: "tezos", Decimals: 6}
+	eth := types.Asset{Name: "ETH", Chain: "ethereum", Decimals: 18}
+	cfg := Config{
+		Strategies: []strategy.Config{
+			{SymbolName: "XTZ_ETH", Kind: strategy.KindFollow},
+		},
+		Backtest: BacktestConfig{
+			Source:   BacktestSourceTickers,
+			File:     tickers,
+			Fill:     FillConfig{Model: FillModelBook, File: book},
+			Balances: map[string]decimal.Decimal{"XTZ": d("100"), "ETH": d("1")},
+		},
+		General: config.General{
+			Symbols: []types.Symbol{{Name: "XTZ_ETH", Base: xtz, Quote: eth}},
+			Atomex:  config.Atomex{FromSymbols: map[string]string{"XTZ/ETH": "XTZ_ETH"}},
+		},
+		QuoteProviderMeta: QuoteProviderMeta{
+			FromSymbols: map[string]synthetic.Config{
+				"XTZ_ETH": {Symbols: []string{"XTZETH"}, Type: synthetic.DirectType},
+			},
+			ToSymbols: map[string]string{"XTZETH": "XTZ_ETH"},
+		},
+	}
+
+	bt, err := NewBacktest(cfg, zerolog.Nop())
+	require.NoError(t, err)
+	require.Len(t, bt.runs, 1)
+	bt.runs[0].strategy = fixedVolume{bt.runs[0].strategy, d("10")}
+
+	report, err := bt.Run()
+	require.NoError(t, err)
+	assert.Equal(t, 6, report.Events)
+	require.Len(t, report.Strategies, 1)
+
+	result := report.Strategies[0]
+	require.Len(t, result.Fills, 2)
+	assert.Equal(t, "bid", result.Fills[0].Side)
+	assert.Equal(t, "0.001", result.Fills[0].Price.String())
+	assert.Equal(t, "ask", result.Fills[1].Side)
+	assert.Equal(t, "0.0011", result.Fills[1].Price.String())
+	assert.Equal(t, "20", result.Volume.String())
+	assert.Equal(t, "0.001", result.Realized.String())
+	assert.Equal(t, "0.021", result.PnL.String())
+
+	require.Len(t, result.Inventory, 2)
+	assert.Equal(t, "110", result.Inventory[0].Base.String())
+	assert.Equal(t, "100", result.Inventory[1].Base.String())
+	assert.Equal(t, "1.001", result.Inventory[1].Quote.String())
+}
+
+func TestBacktestRun_fillByProbability(t *testing.T) {
+	d := decimal.RequireFromString
+	symbol := types.Symbol{Name: "XTZ_ETH", Base: types.Asset{Name: "XTZ"}, Quote: types.Asset{Name: "ETH"}}
+	strat, err := strategy.New(strategy.Config{SymbolName: "XTZ_ETH", Kind: strategy.KindFollow})
+	require.NoError(t, err)
+
+	run := newBacktestRun(fixedVolume{strat, d("10")}, strategy.KindFollow, symbol, map[string]decimal.Decimal{"XTZ": d("5"), "ETH": d("1")}, LedgerConfig{})
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	ticker := exchange.Ticker{Symbol: "XTZ_ETH", Bid: d("0.001"), Ask: d("0.002")}
+
+	run.advance(start)
+	run.requote(start, ticker)
+	require.Len(t, run.quotes, 2)
+	assert.Equal(t, "5", run.quotes[1].Volume.String(), "ask is clipped by base balance")
+
+	cfg := FillConfig{Intensity: 1000, Decay: 0}
+	dt := run.advance(start.Add(time.Second))
+	run.fillByProbability(start.Add(time.Second), ticker, dt, cfg, rand.New(rand.NewSource(1)))
+	assert.Empty(t, run.quotes)
+	assert.Len(t, run.fills, 2)
+
+	report := run.report(nil)
+	assert.Equal(t, 1.0, report.BidUptime)
+	assert.Equal(t, 1.0, report.AskUptime)
+}
+
+func TestBacktest_Seed(t *testing.T) {
+	d := decimal.RequireFromString
+	dir := t.TempDir()
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tickers := path.Join(dir, "tickers.jsonl")
+	records := make([]interface{}, 0)
+	for i := 0; i < 50; i++ {
+		ts := start.Add(time.Duration(i) * time.Second)
+		records = append(records,
+			TickerRecord{Time: ts, Symbol: "XTZUSDT", Bid: d("1.5"), Ask: d("1.6")},
+			TickerRecord{Time: ts, Symbol: "ETHUSDT", Bid: d("3000"), Ask: d("3010")},
+		)
+	}
+	writeJSONLines(t, tickers, records...)
+
+	xtz := types.Asset{Name: "XTZ"}
+	eth := types.Asset{Name: "ETH"}
+	usdt := types.Asset{Name: "USDT"}
+	// ticker of XTZUSDT updates both symbols, so order of symbols changes draws of fills
+	cfg := Config{
+		Strategies: []strategy.Config{
+			{SymbolName: "XTZ_USDT", Kind: strategy.KindFollow},
+			{SymbolName: "XTZ_ETH", Kind: strategy.KindFollow},
+		},
+		Backtest: BacktestConfig{
+			Source:   BacktestSourceTickers,
+			File:     tickers,
+			Fill:     FillConfig{Model: FillModelProbabilistic, Intensity: 0.2, Decay: 10, Seed: 42},
+			Balances: map[string]decimal.Decimal{"XTZ": d("1000"), "ETH": d("10"), "USDT": d("100000")},
+		},
+		General: config.General{
+			Symbols: []types.Symbol{{Name: "XTZ_USDT", Base: xtz, Quote: usdt}, {Name: "XTZ_ETH", Base: xtz, Quote: eth}},
+		},
+		QuoteProviderMeta: QuoteProviderMeta{
+			FromSymbols: map[string]synthetic.Config{
+				"XTZ_USDT": {Symbols: []string{"XTZUSDT"}, Type: synthetic.DirectType},
+				"ETH_USDT": {Symbols: []string{"ETHUSDT"}, Type: synthetic.DirectType},
+				"XTZ_ETH":  {Symbols: []string{"XTZUSDT", "ETHUSDT"}, Type: synthetic.DividedType},
+			},
+			ToSymbols: map[string]string{"XTZUSDT": "XTZ_USDT", "ETHUSDT": "ETH_USDT"},
+		},
+	}
+
+	run := func() BacktestReport {
+		bt, err := NewBacktest(cfg, zerolog.Nop())
+		require.NoError(t, err)
+		for i := range bt.runs {
+			bt.runs[i].strategy = fixedVolume{bt.runs[i].strategy, d("1")}
+		}
+		report, err := bt.Run()
+		require.NoError(t, err)
+		return report
+	}
+
+	first := run()
+	require.NotEmpty(t, first.Strategies[0].Fills)
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, first, run())
+	}
+}
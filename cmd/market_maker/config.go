@@ -9,15 +9,25 @@ import (
 	"github.com/atomex-protocol/watch_tower/cmd/market_maker/synthetic"
 	"github.com/atomex-protocol/watch_tower/internal/config"
 	"github.com/atomex-protocol/watch_tower/internal/keys"
+	"github.com/atomex-protocol/watch_tower/internal/storage"
 )
 
 // Config -
 type Config struct {
+	Mode          Mode              `yaml:"mode" validate:"omitempty,oneof=live paper"`
 	QuoteProvider QuoteProvider     `yaml:"quote_provider" validate:"required"`
 	Strategies    []strategy.Config `yaml:"strategies" validate:"required"`
 	Keys          Keys              `yaml:"keys" validate:"required"`
 	LogLevel      string            `yaml:"log_level"`
 	Restore       bool              `yaml:"restore"`
+	Inventory     InventoryConfig   `yaml:"inventory"`
+	Hedging       HedgingConfig     `yaml:"hedging"`
+	Ledger        LedgerConfig      `yaml:"ledger"`
+	Paper         PaperConfig       `yaml:"paper"`
+	Backtest      BacktestConfig    `yaml:"backtest"`
+	Recorder      RecorderConfig    `yaml:"recorder"`
+	Warmup        WarmupConfig      `yaml:"warmup"`
+	Storage       storage.Config    `yaml:"storage"`
 
 	General           config.General    `yaml:"-" validate:"-"`
 	QuoteProviderMeta QuoteProviderMeta `yaml:"-" validate:"-"`
@@ -34,6 +44,15 @@ func (cfg *Config) loadQuoteProviderMeta(ctx context.Context) error {
 	return nil
 }
 
+// Mode - `paper` mode runs strategies on the real quote provider with simulated Atomex exchange and chains
+type Mode string
+
+// modes
+const (
+	ModeLive  Mode = "live"
+	ModePaper Mode = "paper"
+)
+
 // QuoteProvider -
 type QuoteProvider struct {
 	Kind QuoteProviderKind `yaml:"kind" validate:"required,oneof=binance"`
@@ -11,6 +11,7 @@ import (
 	"github.com/atomex-protocol/watch_tower/internal/chain/tools"
 	"github.com/atomex-protocol/watch_tower/internal/types"
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 )
 
 // Order -
@@ -144,15 +145,18 @@ func (swaps *SwapsMap) Range(handler func(hashedSecret chain.Hex, swap *tools.Sw
 	swaps.mx.RUnlock()
 }
 
+// clientOrderID - is formatted as 1 digit of strategy kind, 1 digit of side, 2 digits of ladder level, 19 digits of index and symbol.
+// IDs of orders placed before ladder levels have no level digits, they are parsed as level 0.
 type clientOrderID struct {
 	kind   strategy.Kind
 	symbol string
 	side   strategy.Side
+	level  int
 	index  int64
 }
 
 func (c clientOrderID) String() string {
-	return fmt.Sprintf("%d%d%d%s", strategyKindToInt(c.kind), c.side, c.index, c.symbol)
+	return fmt.Sprintf("%d%d%02d%d%s", strategyKindToInt(c.kind), c.side, c.level, c.index, c.symbol)
 }
 
 func (c *clientOrderID) parse(str string) error {
@@ -172,20 +176,34 @@ func (c *clientOrderID) parse(str string) error {
 	}
 	c.side = strategy.Side(side)
 
-	index, err := strconv.ParseInt(str[2:21], 10, 64)
+	// symbol starts right after index in IDs without level
+	offset := 2
+	if str[21] >= '0' && str[21] <= '9' {
+		if len(str) < 24 {
+			return errors.Errorf("invalid client order id '%s'", str)
+		}
+		level, err := strconv.ParseInt(str[2:4], 10, 32)
+		if err != nil {
+			return errors.Wrapf(err, "invalid client order id '%s'", str)
+		}
+		c.level = int(level)
+		offset = 4
+	}
+
+	index, err := strconv.ParseInt(str[offset:offset+19], 10, 64)
 	if err != nil {
 		return errors.Wrapf(err, "invalid client order id '%s'", str)
 	}
 	c.index = index
 
-	c.symbol = str[21:]
+	c.symbol = str[offset+19:]
 
 	return nil
 }
 
 // Equals -
 func (c clientOrderID) Equals(clientID clientOrderID) bool {
-	return c.kind == clientID.kind && c.side == clientID.side && c.symbol == clientID.symbol
+	return c.kind == clientID.kind && c.side == clientID.side && c.symbol == clientID.symbol && c.level == clientID.level
 }
 
 func strategyKindToInt(kind strategy.Kind) int {
@@ -196,6 +214,10 @@ func strategyKindToInt(kind strategy.Kind) int {
 		return 2
 	case strategy.KindVolatility:
 		return 3
+	case strategy.KindInventory:
+		return 4
+	case strategy.KindAvellanedaStoikov:
+		return 5
 	}
 	return 0
 }
@@ -208,6 +230,10 @@ func intToStrategyKind(kind int64) strategy.Kind {
 		return strategy.KindOneByOne
 	case 3:
 		return strategy.KindVolatility
+	case 4:
+		return strategy.KindInventory
+	case 5:
+		return strategy.KindAvellanedaStoikov
 	}
 	return strategy.KindUnknown
 }
@@ -256,3 +282,32 @@ func (s *Secrets) Delete(hash chain.Hex) {
 	delete(s.m, hash)
 	s.mx.Unlock()
 }
+
+// Fills - filled quantities of atomex orders by order id
+type Fills struct {
+	m  map[int64]decimal.Decimal
+	mx sync.Mutex
+}
+
+// NewFills -
+func NewFills() *Fills {
+	return &Fills{
+		m: make(map[int64]decimal.Decimal),
+	}
+}
+
+// Add - stores filled quantity of `order` and returns quantity which is filled since the previous update of the order
+func (f *Fills) Add(order atomex.OrderWebsocket) decimal.Decimal {
+	filled := order.Qty.Sub(order.LeaveQty)
+
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	qty := filled.Sub(f.m[order.ID])
+	if order.Status == atomex.OrderStatusFilled {
+		delete(f.m, order.ID)
+	} else {
+		f.m[order.ID] = filled
+	}
+	return qty
+}
@@ -16,31 +16,50 @@ func Test_clientOrderID_parse(t *testing.T) {
 	}{
 		{
 			name: "test 1",
-			str:  "201637245985956790529XTZ_ETH",
+			str:  "20001637245985956790529XTZ_ETH",
 			want: clientOrderID{
 				symbol: "XTZ_ETH",
 				index:  1637245985956790529,
 				side:   strategy.Bid,
 				kind:   strategy.KindOneByOne,
 			},
+		}, {
+			name: "ladder level",
+			str:  "31121637245985956790529XTZ_ETH",
+			want: clientOrderID{
+				symbol: "XTZ_ETH",
+				index:  1637245985956790529,
+				side:   strategy.Ask,
+				level:  12,
+				kind:   strategy.KindVolatility,
+			},
+		}, {
+			name: "without ladder level",
+			str:  "311637245985956790529XTZ_ETH",
+			want: clientOrderID{
+				symbol: "XTZ_ETH",
+				index:  1637245985956790529,
+				side:   strategy.Ask,
+				kind:   strategy.KindVolatility,
+			},
 		}, {
 			name:    "test 2",
 			str:     "201637245",
 			wantErr: true,
 		}, {
 			name:    "test 3",
-			str:     "a01637245985956790529XTZ_ETH",
+			str:     "a0001637245985956790529XTZ_ETH",
 			wantErr: true,
 		}, {
 			name: "test 4",
-			str:  "2a1637245985956790529XTZ_ETH",
+			str:  "2a001637245985956790529XTZ_ETH",
 			want: clientOrderID{
 				kind: strategy.KindOneByOne,
 			},
 			wantErr: true,
 		}, {
 			name: "test 5",
-			str:  "20163724d956790529XTZ_ETH",
+			str:  "20000163724d956790529XTZ_ETH",
 			want: clientOrderID{
 				kind: strategy.KindOneByOne,
 				side: strategy.Bid,
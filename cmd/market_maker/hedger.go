@@ -0,0 +1,145 @@
+package main
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/atomex-protocol/watch_tower/cmd/market_maker/synthetic"
+	"github.com/atomex-protocol/watch_tower/internal/atomex"
+	"github.com/atomex-protocol/watch_tower/internal/exchange"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+)
+
+// HedgingConfig -
+type HedgingConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// Hedger - hedges fills of atomex orders by market orders on the quote provider across the legs of symbol's synthetic.
+// Quantity which isn't hedged, e.g. below lot size or of failed order, is carried by provider symbol and is hedged with the next fill.
+type Hedger struct {
+	trader     exchange.Trader
+	synthetics map[string]synthetic.Synthetic
+	log        zerolog.Logger
+
+	unhedged map[string]decimal.Decimal // signed quantity by provider symbol: positive is bought, negative is sold
+	mx       sync.Mutex
+}
+
+// NewHedger -
+func NewHedger(trader exchange.Trader, synthetics map[string]synthetic.Synthetic, log zerolog.Logger) *Hedger {
+	return &Hedger{
+		trader:     trader,
+		synthetics: synthetics,
+		log:        log,
+		unhedged:   make(map[string]decimal.Decimal),
+	}
+}
+
+// Hedge - places orders which hedge fill of atomex `order` with quantity `qty` and quantities left unhedged by previous fills. `symbol` is the symbol ID from symbols config.
+// It returns placed orders. If some order isn't placed, its quantity is carried and the first error is returned.
+func (h *Hedger) Hedge(order atomex.OrderWebsocket, symbol string, qty decimal.Decimal) ([]exchange.Order, error) {
+	if !qty.IsPositive() {
+		return nil, nil
+	}
+
+	synth, ok := h.synthetics[symbol]
+	if !ok {
+		return nil, errors.Errorf("unknown synthetic of symbol %s", symbol)
+	}
+
+	// market maker bought base asset at atomex, so it has to be sold on the quote provider and vice versa
+	side := exchange.OrderSideSell
+	if order.Side == atomex.SideSell {
+		side = exchange.OrderSideBuy
+	}
+
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	requests := synth.Hedge(side, qty, order.Price)
+	symbols := make([]string, 0, len(requests)+len(h.unhedged))
+	for _, request := range requests {
+		if !contains(symbols, request.Symbol) {
+			symbols = append(symbols, request.Symbol)
+		}
+		h.unhedged[request.Symbol] = h.unhedged[request.Symbol].Add(signedQty(request.Side, request.Qty))
+	}
+	carried := make([]string, 0, len(h.unhedged))
+	for providerSymbol := range h.unhedged {
+		if !contains(symbols, providerSymbol) {
+			carried = append(carried, providerSymbol)
+		}
+	}
+	sort.Strings(carried)
+	symbols = append(symbols, carried...)
+
+	orders := make([]exchange.Order, 0, len(symbols))
+	var hedgeErr error
+	for _, providerSymbol := range symbols {
+		unhedged := h.unhedged[providerSymbol]
+		if unhedged.IsZero() {
+			delete(h.unhedged, providerSymbol)
+			continue
+		}
+
+		request := exchange.OrderRequest{
+			Symbol: providerSymbol,
+			Side:   exchange.OrderSideBuy,
+			Type:   exchange.OrderTypeMarket,
+			Qty:    unhedged.Abs(),
+		}
+		if unhedged.IsNegative() {
+			request.Side = exchange.OrderSideSell
+		}
+
+		placed, err := h.trader.PlaceOrder(request)
+		if err != nil {
+			if errors.Is(err, exchange.ErrInvalidQty) {
+				h.log.Debug().Int64("atomex_order_id", order.ID).Str("symbol", providerSymbol).Str("qty", unhedged.String()).Msg("quantity is too small to hedge. it's carried to the next fill")
+				continue
+			}
+			h.log.Err(err).Int64("atomex_order_id", order.ID).Str("symbol", providerSymbol).Str("qty", unhedged.String()).Msg("hedge is failed. it's carried to the next fill")
+			if hedgeErr == nil {
+				hedgeErr = errors.Wrapf(err, "PlaceOrder %s %s %s", request.Symbol, request.Side, request.Qty)
+			}
+			continue
+		}
+		orders = append(orders, placed)
+
+		if left := unhedged.Sub(signedQty(placed.Side, placed.ExecutedQty)); left.IsZero() {
+			delete(h.unhedged, providerSymbol)
+		} else {
+			h.unhedged[providerSymbol] = left
+		}
+
+		h.log.Info().
+			Int64("atomex_order_id", order.ID).
+			Int64("order_id", placed.ID).
+			Str("symbol", placed.Symbol).
+			Str("side", string(placed.Side)).
+			Str("qty", placed.ExecutedQty.String()).
+			Str("quote_qty", placed.QuoteQty.String()).
+			Str("status", string(placed.Status)).
+			Msg("fill is hedged")
+	}
+	return orders, hedgeErr
+}
+
+func signedQty(side exchange.OrderSide, qty decimal.Decimal) decimal.Decimal {
+	if side == exchange.OrderSideSell {
+		return qty.Neg()
+	}
+	return qty
+}
+
+func contains(values []string, value string) bool {
+	for i := range values {
+		if values[i] == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/atomex-protocol/watch_tower/cmd/market_maker/synthetic"
+	"github.com/atomex-protocol/watch_tower/internal/atomex"
+	"github.com/atomex-protocol/watch_tower/internal/exchange"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testTrader struct {
+	requests []exchange.OrderRequest
+	minQty   map[string]decimal.Decimal
+	errs     map[string]error // error of the next order of symbol
+}
+
+func (t *testTrader) PlaceOrder(request exchange.OrderRequest) (exchange.Order, error) {
+	if request.Qty.LessThan(t.minQty[request.Symbol]) {
+		return exchange.Order{}, exchange.ErrInvalidQty
+	}
+	if err, ok := t.errs[request.Symbol]; ok {
+		delete(t.errs, request.Symbol)
+		return exchange.Order{}, err
+	}
+	t.requests = append(t.requests, request)
+	return exchange.Order{Symbol: request.Symbol, Side: request.Side, ExecutedQty: request.Qty, Status: exchange.OrderStatusFilled}, nil
+}
+
+func (t *testTrader) CancelOrder(symbol string, id int64) error { return nil }
+
+func (t *testTrader) Order(symbol string, id int64) (exchange.Order, error) {
+	return exchange.Order{}, nil
+}
+
+func (t *testTrader) OpenOrders(symbol string) ([]exchange.Order, error) { return nil, nil }
+
+func (t *testTrader) Balances() ([]exchange.Balance, error) { return nil, nil }
+
+func TestHedger_Hedge(t *testing.T) {
+	divided, err := synthetic.NewDivided("XTZ_ETH", "XTZUSDT", "ETHUSDT")
+	require.NoError(t, err)
+
+	trader := new(testTrader)
+	hedger := NewHedger(trader, map[string]synthetic.Synthetic{"XTZ_ETH": divided}, zerolog.Nop())
+
+	order := atomex.OrderWebsocket{
+		ID:       1,
+		Side:     atomex.SideBuy,
+		Price:    decimal.RequireFromString("0.001"),
+		Qty:      decimal.RequireFromString("100"),
+		LeaveQty: decimal.RequireFromString("60"),
+		Status:   atomex.OrderStatusPartiallyFilled,
+	}
+	fills := NewFills()
+	placed, err := hedger.Hedge(order, "XTZ_ETH", fills.Add(order))
+	require.NoError(t, err)
+	require.Len(t, placed, 2)
+
+	// repeated update doesn't hedge twice
+	placed, err = hedger.Hedge(order, "XTZ_ETH", fills.Add(order))
+	require.NoError(t, err)
+	require.Empty(t, placed)
+
+	order.LeaveQty = decimal.Zero
+	order.Status = atomex.OrderStatusFilled
+	_, err = hedger.Hedge(order, "XTZ_ETH", fills.Add(order))
+	require.NoError(t, err)
+
+	want := []exchange.OrderRequest{
+		{Symbol: "XTZUSDT", Side: exchange.OrderSideSell, Qty: decimal.RequireFromString("40")},
+		{Symbol: "ETHUSDT", Side: exchange.OrderSideBuy, Qty: decimal.RequireFromString("0.04")},
+		{Symbol: "XTZUSDT", Side: exchange.OrderSideSell, Qty: decimal.RequireFromString("60")},
+		{Symbol: "ETHUSDT", Side: exchange.OrderSideBuy, Qty: decimal.RequireFromString("0.06")},
+	}
+	require.Len(t, trader.requests, len(want))
+	for i := range want {
+		assert.Equal(t, want[i].Symbol, trader.requests[i].Symbol)
+		assert.Equal(t, want[i].Side, trader.requests[i].Side)
+		assert.Equal(t, exchange.OrderTypeMarket, trader.requests[i].Type)
+		assert.Equal(t, want[i].Qty.String(), trader.requests[i].Qty.String())
+	}
+
+	_, err = hedger.Hedge(atomex.OrderWebsocket{ID: 2}, "unknown", decimal.NewFromInt(1))
+	assert.Error(t, err)
+}
+
+func TestHedger_HedgeUnhedged(t *testing.T) {
+	divided, err := synthetic.NewDivided("XTZ_ETH", "XTZUSDT", "ETHUSDT")
+	require.NoError(t, err)
+
+	trader := &testTrader{
+		minQty: map[string]decimal.Decimal{"XTZUSDT": decimal.NewFromInt(10)},
+		errs:   map[string]error{"ETHUSDT": errors.New("connection reset")},
+	}
+	hedger := NewHedger(trader, map[string]synthetic.Synthetic{"XTZ_ETH": divided}, zerolog.Nop())
+
+	order := atomex.OrderWebsocket{
+		ID:    1,
+		Side:  atomex.SideBuy,
+		Price: decimal.RequireFromString("0.001"),
+	}
+
+	// XTZUSDT is below lot size and ETHUSDT is failed, so both are carried
+	placed, err := hedger.Hedge(order, "XTZ_ETH", decimal.NewFromInt(4))
+	require.Error(t, err)
+	require.Empty(t, placed)
+
+	// the next fill hedges carried quantities too
+	placed, err = hedger.Hedge(order, "XTZ_ETH", decimal.NewFromInt(8))
+	require.NoError(t, err)
+	require.Len(t, placed, 2)
+	assert.Empty(t, hedger.unhedged)
+
+	want := []exchange.OrderRequest{
+		{Symbol: "XTZUSDT", Side: exchange.OrderSideSell, Qty: decimal.RequireFromString("12")},
+		{Symbol: "ETHUSDT", Side: exchange.OrderSideBuy, Qty: decimal.RequireFromString("0.012")},
+	}
+	require.Len(t, trader.requests, len(want))
+	for i := range want {
+		assert.Equal(t, want[i].Symbol, trader.requests[i].Symbol)
+		assert.Equal(t, want[i].Side, trader.requests[i].Side)
+		assert.Equal(t, want[i].Qty.String(), trader.requests[i].Qty.String())
+	}
+}
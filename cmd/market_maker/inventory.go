@@ -0,0 +1,277 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/atomex-protocol/watch_tower/cmd/market_maker/strategy"
+	"github.com/atomex-protocol/watch_tower/internal/chain"
+	"github.com/atomex-protocol/watch_tower/internal/types"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+)
+
+// default interval of balances update in seconds
+const defaultInventoryUpdateInterval = 30
+
+// InventoryConfig - `Fees` is an amount of chain's native currency which is reserved for fees of each swap by chain names
+type InventoryConfig struct {
+	UpdateInterval int64                      `yaml:"update_interval" validate:"omitempty,gt=0"`
+	Fees           map[string]decimal.Decimal `yaml:"fees"`
+}
+
+type balanceSource interface {
+	Balance(ctx context.Context, typ chain.ChainType, args chain.BalanceArgs) (decimal.Decimal, error)
+}
+
+type assetKey struct {
+	chain    chain.ChainType
+	contract string
+	tokenID  uint64
+}
+
+func newAssetKey(asset types.Asset) assetKey {
+	return assetKey{
+		chain:    asset.ChainType(),
+		contract: asset.Contract,
+		tokenID:  asset.TokenID,
+	}
+}
+
+// Inventory - balances of market maker's wallets and amounts which are reserved by open orders and active swaps. Amounts are in asset units, not in minimal ones.
+type Inventory struct {
+	source  balanceSource
+	log     zerolog.Logger
+	assets  map[assetKey]types.Asset
+	natives map[chain.ChainType]types.Asset
+	fees    map[chain.ChainType]decimal.Decimal
+
+	mx       sync.RWMutex
+	balances map[assetKey]decimal.Decimal
+	reserved map[string]map[assetKey]decimal.Decimal
+}
+
+// NewInventory - tracks balances of assets of `symbols`. Chain's native asset is an asset without contract.
+func NewInventory(source balanceSource, symbols map[string]types.Symbol, assets map[string]types.Asset, cfg InventoryConfig, log zerolog.Logger) (*Inventory, error) {
+	natives := make(map[chain.ChainType]types.Asset)
+	for _, asset := range assets {
+		if asset.Contract == "" {
+			natives[asset.ChainType()] = asset
+		}
+	}
+
+	inventory := &Inventory{
+		source:   source,
+		log:      log,
+		assets:   make(map[assetKey]types.Asset),
+		natives:  make(map[chain.ChainType]types.Asset),
+		fees:     make(map[chain.ChainType]decimal.Decimal),
+		balances: make(map[assetKey]decimal.Decimal),
+		reserved: make(map[string]map[assetKey]decimal.Decimal),
+	}
+
+	for _, symbol := range symbols {
+		for _, asset := range []types.Asset{symbol.Base, symbol.Quote} {
+			inventory.assets[newAssetKey(asset)] = asset
+
+			native, ok := natives[asset.ChainType()]
+			if !ok {
+				return nil, errors.Errorf("unknown native asset of chain %s", asset.Chain)
+			}
+			inventory.natives[asset.ChainType()] = native
+			inventory.assets[newAssetKey(native)] = native
+		}
+	}
+
+	for name, fee := range cfg.Fees {
+		typ := chain.ChainTypeFromString(name)
+		if typ == chain.ChainTypeUnknown {
+			return nil, errors.Errorf("unknown chain in inventory fees: %s", name)
+		}
+		inventory.fees[typ] = fee
+	}
+
+	return inventory, nil
+}
+
+// Update - receives balances of all tracked assets. Asset which balance can't be received is considered empty.
+func (inv *Inventory) Update(ctx context.Context) {
+	balances := make(map[assetKey]decimal.Decimal, len(inv.assets))
+	for key, asset := range inv.assets {
+		balance, err := inv.source.Balance(ctx, key.chain, chain.BalanceArgs{
+			Contract: asset.Contract,
+			TokenID:  asset.TokenID,
+		})
+		if err != nil {
+			inv.log.Err(err).Str("asset", asset.Name).Str("chain", asset.Chain).Msg("can't receive balance")
+			continue
+		}
+		balances[key] = balance.Shift(-int32(asset.Decimals))
+	}
+
+	inv.mx.Lock()
+	inv.balances = balances
+	inv.mx.Unlock()
+}
+
+// Run - updates balances every `interval` until context is done
+func (inv *Inventory) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			inv.Update(ctx)
+		}
+	}
+}
+
+// Native - returns native asset of chain
+func (inv *Inventory) Native(typ chain.ChainType) (types.Asset, bool) {
+	asset, ok := inv.natives[typ]
+	return asset, ok
+}
+
+// Balance - returns balance of asset
+func (inv *Inventory) Balance(asset types.Asset) decimal.Decimal {
+	inv.mx.RLock()
+	defer inv.mx.RUnlock()
+	return inv.balances[newAssetKey(asset)]
+}
+
+// Available - returns balance of asset without reserved amounts
+func (inv *Inventory) Available(asset types.Asset) decimal.Decimal {
+	inv.mx.RLock()
+	defer inv.mx.RUnlock()
+	return inv.available(newAssetKey(asset))
+}
+
+func (inv *Inventory) available(key assetKey) decimal.Decimal {
+	value := inv.balances[key]
+	for _, amounts := range inv.reserved {
+		value = value.Sub(amounts[key])
+	}
+	return value
+}
+
+// requirements - returns amounts which are spent by swap of `volume` of `symbol` base asset: the sent asset and fees of initiation and redeem in native assets of both chains
+func (inv *Inventory) requirements(symbol types.Symbol, side strategy.Side, price, volume decimal.Decimal) map[assetKey]decimal.Decimal {
+	sent, received := symbol.Base, symbol.Quote
+	amount := volume
+	if side == strategy.Bid {
+		sent, received = symbol.Quote, symbol.Base
+		amount = volume.Mul(price)
+	}
+
+	result := map[assetKey]decimal.Decimal{
+		newAssetKey(sent): amount,
+	}
+	for _, asset := range []types.Asset{sent, received} {
+		fee, ok := inv.fees[asset.ChainType()]
+		if !ok {
+			continue
+		}
+		native := newAssetKey(inv.natives[asset.ChainType()])
+		result[native] = result[native].Add(fee)
+	}
+	return result
+}
+
+// Clip - returns volume of quote which can be covered by available balances. Zero volume means the quote has to be suppressed.
+func (inv *Inventory) Clip(symbol types.Symbol, quote strategy.Quote) decimal.Decimal {
+	if !quote.Volume.IsPositive() || !quote.Price.IsPositive() {
+		return decimal.Zero
+	}
+
+	required := inv.requirements(symbol, quote.Side, quote.Price, quote.Volume)
+
+	sent := symbol.Base
+	if quote.Side == strategy.Bid {
+		sent = symbol.Quote
+	}
+	sentKey := newAssetKey(sent)
+
+	inv.mx.RLock()
+	defer inv.mx.RUnlock()
+
+	for key, amount := range required {
+		if key == sentKey {
+			continue
+		}
+		if inv.available(key).LessThan(amount) {
+			return decimal.Zero
+		}
+	}
+
+	// if the sent asset is native, fees are paid from the same balance
+	amount := quote.Volume
+	if quote.Side == strategy.Bid {
+		amount = quote.Volume.Mul(quote.Price)
+	}
+	fees := required[sentKey].Sub(amount)
+	maxAmount := inv.available(sentKey).Sub(fees)
+	if !maxAmount.IsPositive() {
+		return decimal.Zero
+	}
+
+	maxVolume := maxAmount
+	if quote.Side == strategy.Bid {
+		maxVolume = maxAmount.Div(quote.Price)
+	}
+	maxVolume = maxVolume.Truncate(int32(symbol.Base.Decimals))
+
+	if maxVolume.LessThan(quote.Volume) {
+		if !maxVolume.IsPositive() {
+			return decimal.Zero
+		}
+		return maxVolume
+	}
+	return quote.Volume
+}
+
+// Reserve - reserves amounts which are required by order or swap with `id`
+func (inv *Inventory) Reserve(id string, symbol types.Symbol, side strategy.Side, price, volume decimal.Decimal) {
+	required := inv.requirements(symbol, side, price, volume)
+
+	inv.mx.Lock()
+	inv.reserved[id] = required
+	inv.mx.Unlock()
+}
+
+// Shrink - decreases amounts reserved by `id` by requirements of `volume`, e.g. by filled part of order. Amounts don't become negative.
+func (inv *Inventory) Shrink(id string, symbol types.Symbol, side strategy.Side, price, volume decimal.Decimal) {
+	required := inv.requirements(symbol, side, price, volume)
+
+	inv.mx.Lock()
+	defer inv.mx.Unlock()
+
+	reserved, ok := inv.reserved[id]
+	if !ok {
+		return
+	}
+	for key, amount := range required {
+		if value, ok := reserved[key]; ok {
+			reserved[key] = decimal.Max(value.Sub(amount), decimal.Zero)
+		}
+	}
+}
+
+// Release - releases amounts reserved by `id`
+func (inv *Inventory) Release(id string) {
+	inv.mx.Lock()
+	delete(inv.reserved, id)
+	inv.mx.Unlock()
+}
+
+// IsReserved -
+func (inv *Inventory) IsReserved(id string) bool {
+	inv.mx.RLock()
+	_, ok := inv.reserved[id]
+	inv.mx.RUnlock()
+	return ok
+}
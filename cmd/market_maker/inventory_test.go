@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/atomex-protocol/watch_tower/cmd/market_maker/strategy"
+	"github.com/atomex-protocol/watch_tower/internal/chain"
+	"github.com/atomex-protocol/watch_tower/internal/types"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testBalances map[string]decimal.Decimal
+
+func (b testBalances) Balance(ctx context.Context, typ chain.ChainType, args chain.BalanceArgs) (decimal.Decimal, error) {
+	return b[typ.String()+args.Contract], nil
+}
+
+func TestInventory_Clip(t *testing.T) {
+	xtz := types.Asset{Name: "XTZ", Chain: "tezos", Decimals: 6}
+	eth := types.Asset{Name: "ETH", Chain: "ethereum", Decimals: 18}
+	tzBTC := types.Asset{Name: "tzBTC", Chain: "tezos", Contract: "KT1PWx2mnDueood7fEmfbBDKx1D9BAnnXitn", Decimals: 8}
+
+	symbols := map[string]types.Symbol{
+		"XTZ_ETH":   {Name: "XTZ_ETH", Base: xtz, Quote: eth},
+		"tzBTC_XTZ": {Name: "tzBTC_XTZ", Base: tzBTC, Quote: xtz},
+	}
+	assets := map[string]types.Asset{"XTZ": xtz, "ETH": eth, "tzBTC": tzBTC}
+
+	tests := []struct {
+		name     string
+		balances testBalances
+		reserved decimal.Decimal
+		filled   decimal.Decimal
+		symbol   string
+		quote    strategy.Quote
+		want     string
+	}{
+		{
+			name:     "enough balance",
+			balances: testBalances{"tezos": decimal.RequireFromString("101000000"), "ethereum": decimal.RequireFromString("1000000000000000000")},
+			symbol:   "XTZ_ETH",
+			quote:    strategy.Quote{Side: strategy.Ask, Price: decimal.RequireFromString("0.001"), Volume: decimal.RequireFromString("100")},
+			want:     "100",
+		}, {
+			name:     "ask is clipped by balance without fees",
+			balances: testBalances{"tezos": decimal.RequireFromString("51000000"), "ethereum": decimal.RequireFromString("1000000000000000000")},
+			symbol:   "XTZ_ETH",
+			quote:    strategy.Quote{Side: strategy.Ask, Price: decimal.RequireFromString("0.001"), Volume: decimal.RequireFromString("100")},
+			want:     "50.5",
+		}, {
+			name:     "bid is clipped by quote balance",
+			balances: testBalances{"tezos": decimal.RequireFromString("1000000"), "ethereum": decimal.RequireFromString("60000000000000000")},
+			symbol:   "XTZ_ETH",
+			quote:    strategy.Quote{Side: strategy.Bid, Price: decimal.RequireFromString("0.001"), Volume: decimal.RequireFromString("100")},
+			want:     "50",
+		}, {
+			name:     "no fees for redeem",
+			balances: testBalances{"tezos": decimal.RequireFromString("200000000")},
+			symbol:   "XTZ_ETH",
+			quote:    strategy.Quote{Side: strategy.Ask, Price: decimal.RequireFromString("0.001"), Volume: decimal.RequireFromString("100")},
+			want:     "0",
+		}, {
+			name:     "token ask requires native fees",
+			balances: testBalances{"tezosKT1PWx2mnDueood7fEmfbBDKx1D9BAnnXitn": decimal.RequireFromString("100000000"), "tezos": decimal.RequireFromString("900000")},
+			symbol:   "tzBTC_XTZ",
+			quote:    strategy.Quote{Side: strategy.Ask, Price: decimal.RequireFromString("100"), Volume: decimal.RequireFromString("1")},
+			want:     "0",
+		}, {
+			name:     "reserved amounts are not available",
+			balances: testBalances{"tezos": decimal.RequireFromString("101000000"), "ethereum": decimal.RequireFromString("1000000000000000000")},
+			reserved: decimal.RequireFromString("30"),
+			symbol:   "XTZ_ETH",
+			quote:    strategy.Quote{Side: strategy.Ask, Price: decimal.RequireFromString("0.001"), Volume: decimal.RequireFromString("100")},
+			want:     "70",
+		}, {
+			name:     "filled part of order is not reserved",
+			balances: testBalances{"tezos": decimal.RequireFromString("101000000"), "ethereum": decimal.RequireFromString("1000000000000000000")},
+			reserved: decimal.RequireFromString("30"),
+			filled:   decimal.RequireFromString("10"),
+			symbol:   "XTZ_ETH",
+			quote:    strategy.Quote{Side: strategy.Ask, Price: decimal.RequireFromString("0.001"), Volume: decimal.RequireFromString("100")},
+			want:     "80.5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inventory, err := NewInventory(tt.balances, symbols, assets, InventoryConfig{
+				Fees: map[string]decimal.Decimal{
+					"tezos":    decimal.RequireFromString("0.5"),
+					"ethereum": decimal.RequireFromString("0.01"),
+				},
+			}, zerolog.Nop())
+			require.NoError(t, err)
+
+			inventory.Update(context.Background())
+			if tt.reserved.IsPositive() {
+				inventory.Reserve("order", symbols["XTZ_ETH"], strategy.Ask, decimal.RequireFromString("0.001"), tt.reserved)
+			}
+			if tt.filled.IsPositive() {
+				inventory.Shrink("order", symbols["XTZ_ETH"], strategy.Ask, decimal.RequireFromString("0.001"), tt.filled)
+			}
+
+			got := inventory.Clip(symbols[tt.symbol], tt.quote)
+			assert.Equal(t, tt.want, got.String())
+
+			inventory.Release("order")
+			assert.False(t, inventory.IsReserved("order"))
+		})
+	}
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/atomex-protocol/watch_tower/cmd/market_maker/strategy"
+	"github.com/atomex-protocol/watch_tower/internal/chain"
+	"github.com/atomex-protocol/watch_tower/internal/exchange"
+	"github.com/shopspring/decimal"
+)
+
+// default interval of PnL report in seconds
+const defaultLedgerReportInterval = 300
+
+// LedgerConfig - `Currency` is an asset which PnL is reported in, e.g. USDT
+type LedgerConfig struct {
+	Currency       string `yaml:"currency"`
+	ReportInterval int64  `yaml:"report_interval" validate:"omitempty,gt=0"`
+}
+
+// position - position in base asset of symbol with average price in quote asset
+type position struct {
+	quote    string
+	qty      decimal.Decimal
+	avgPrice decimal.Decimal
+	realized decimal.Decimal
+	volume   decimal.Decimal
+	fills    int64
+}
+
+// add - applies fill to position by average cost method and returns realized PnL in quote asset
+func (p *position) add(side strategy.Side, price, qty decimal.Decimal) decimal.Decimal {
+	p.fills++
+	p.volume = p.volume.Add(qty)
+
+	signed := qty
+	if side == strategy.Ask {
+		signed = qty.Neg()
+	}
+
+	if p.qty.IsZero() || p.qty.Sign() == signed.Sign() {
+		total := p.qty.Add(signed)
+		p.avgPrice = p.avgPrice.Mul(p.qty.Abs()).Add(price.Mul(qty)).Div(total.Abs())
+		p.qty = total
+		return decimal.Zero
+	}
+
+	closed := decimal.Min(qty, p.qty.Abs())
+	pnl := price.Sub(p.avgPrice).Mul(closed)
+	if p.qty.IsNegative() {
+		pnl = pnl.Neg()
+	}
+	p.realized = p.realized.Add(pnl)
+
+	p.qty = p.qty.Add(signed)
+	switch {
+	case p.qty.IsZero():
+		p.avgPrice = decimal.Zero
+	case p.qty.Sign() == signed.Sign():
+		// position is flipped, so the rest is opened by fill's price
+		p.avgPrice = price
+	}
+	return pnl
+}
+
+// SymbolPnL - PnL of symbol. `Realized` and `Unrealized` are in quote asset, `Fees` and `Payoffs` are by assets. Values are in reporting currency, `FeesValue` includes payoffs.
+// `Valued` is false if some of prices in reporting currency are unknown.
+type SymbolPnL struct {
+	Symbol     string
+	Position   decimal.Decimal
+	AvgPrice   decimal.Decimal
+	Volume     decimal.Decimal
+	Fills      int64
+	Realized   decimal.Decimal
+	Unrealized decimal.Decimal
+	Fees       map[string]decimal.Decimal
+	Payoffs    map[string]decimal.Decimal
+
+	RealizedValue   decimal.Decimal
+	UnrealizedValue decimal.Decimal
+	FeesValue       decimal.Decimal
+	TotalValue      decimal.Decimal
+	Valued          bool
+}
+
+// PnLReport -
+type PnLReport struct {
+	Currency string
+	Symbols  []SymbolPnL
+	Total    decimal.Decimal
+	Valued   bool
+}
+
+// ledgerSwap - symbol of swap and asset which is received by market maker
+type ledgerSwap struct {
+	symbol   string
+	received string
+}
+
+// Ledger - records fills of atomex orders and hedges, fees and payoffs of swaps and computes realized and mark-to-market PnL.
+// Symbols are named as `BASE_QUOTE` like in symbols config and in `to_symbols` of the quote provider.
+type Ledger struct {
+	currency string
+
+	mx        sync.RWMutex
+	positions map[string]*position
+	fees      map[string]map[string]decimal.Decimal
+	payoffs   map[string]map[string]decimal.Decimal
+	swaps     map[chain.Hex]ledgerSwap
+}
+
+// NewLedger -
+func NewLedger(cfg LedgerConfig) *Ledger {
+	return &Ledger{
+		currency:  cfg.Currency,
+		positions: make(map[string]*position),
+		fees:      make(map[string]map[string]decimal.Decimal),
+		payoffs:   make(map[string]map[string]decimal.Decimal),
+		swaps:     make(map[chain.Hex]ledgerSwap),
+	}
+}
+
+// Fill - records fill of `qty` of `symbol` base asset by `price` in quote asset. It returns realized PnL of the fill in quote asset.
+func (l *Ledger) Fill(symbol string, side strategy.Side, price, qty decimal.Decimal) decimal.Decimal {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	return l.position(symbol).add(side, price, qty)
+}
+
+// Fee - records fee which is paid in `asset` units for symbol's swap or hedge
+func (l *Ledger) Fee(symbol, asset string, amount decimal.Decimal) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	l.position(symbol)
+	l.fees[symbol] = addAmount(l.fees[symbol], asset, amount)
+}
+
+// Payoff - records reward for redeem which is paid in `asset` units for symbol's swap
+func (l *Ledger) Payoff(symbol, asset string, amount decimal.Decimal) {
+	l.mx.Lock()
+	defer l.mx.Unlock()
+
+	l.position(symbol)
+	l.payoffs[symbol] = addAmount(l.payoffs[symbol], asset, amount)
+}
+
+// Swap - registers swap, so its fees and payoffs are attributed to `symbol`. `received` is an asset which is received by market maker.
+func (l *Ledger) Swap(hashedSecret chain.Hex, symbol, received string) {
+	l.mx.Lock()
+	l.swaps[hashedSecret] = ledgerSwap{symbol, received}
+	l.mx.Unlock()
+}
+
+// ForgetSwap - removes swap which has no more fees and payoffs
+func (l *Ledger) ForgetSwap(hashedSecret chain.Hex) {
+	l.mx.Lock()
+	delete(l.swaps, hashedSecret)
+	l.mx.Unlock()
+}
+
+// SwapFee - records fee which is paid in `asset` units by operation of swap. Fees of unknown swaps are recorded without symbol.
+func (l *Ledger) SwapFee(hashedSecret chain.Hex, asset string, amount decimal.Decimal) {
+	l.mx.RLock()
+	swap := l.swaps[hashedSecret]
+	l.mx.RUnlock()
+
+	l.Fee(swap.symbol, asset, amount)
+}
+
+// SwapPayoff - records reward for redeem of swap's leg which is received by market maker. It returns false if swap is unknown.
+func (l *Ledger) SwapPayoff(hashedSecret chain.Hex, amount decimal.Decimal) bool {
+	l.mx.RLock()
+	swap, ok := l.swaps[hashedSecret]
+	l.mx.RUnlock()
+
+	if ok {
+		l.Payoff(swap.symbol, swap.received, amount)
+	}
+	return ok
+}
+
+func (l *Ledger) position(symbol string) *position {
+	p, ok := l.positions[symbol]
+	if !ok {
+		_, quote := splitSymbol(symbol)
+		p = &position{quote: quote}
+		l.positions[symbol] = p
+	}
+	return p
+}
+
+func addAmount(amounts map[string]decimal.Decimal, asset string, amount decimal.Decimal) map[string]decimal.Decimal {
+	if amounts == nil {
+		amounts = make(map[string]decimal.Decimal)
+	}
+	amounts[asset] = amounts[asset].Add(amount)
+	return amounts
+}
+
+// Report - computes PnL of each symbol. Open positions are marked to mid prices of `tickers` which are keyed by symbols.
+func (l *Ledger) Report(tickers map[string]exchange.Ticker) PnLReport {
+	l.mx.RLock()
+	defer l.mx.RUnlock()
+
+	report := PnLReport{
+		Currency: l.currency,
+		Symbols:  make([]SymbolPnL, 0, len(l.positions)),
+		Valued:   true,
+	}
+
+	for symbol, p := range l.positions {
+		pnl := SymbolPnL{
+			Symbol:   symbol,
+			Position: p.qty,
+			AvgPrice: p.avgPrice,
+			Volume:   p.volume,
+			Fills:    p.fills,
+			Realized: p.realized,
+			Fees:     copyAmounts(l.fees[symbol]),
+			Payoffs:  copyAmounts(l.payoffs[symbol]),
+			Valued:   true,
+		}
+
+		if !p.qty.IsZero() {
+			if mid, ok := midPrice(tickers, symbol); ok {
+				pnl.Unrealized = mid.Sub(p.avgPrice).Mul(p.qty)
+			} else {
+				pnl.Valued = false
+			}
+		}
+
+		if !pnl.Realized.IsZero() || !pnl.Unrealized.IsZero() {
+			if price, ok := l.price(tickers, p.quote); ok {
+				pnl.RealizedValue = pnl.Realized.Mul(price)
+				pnl.UnrealizedValue = pnl.Unrealized.Mul(price)
+			} else {
+				pnl.Valued = false
+			}
+		}
+
+		for _, amounts := range []map[string]decimal.Decimal{pnl.Fees, pnl.Payoffs} {
+			for asset, amount := range amounts {
+				price, ok := l.price(tickers, asset)
+				if !ok {
+					pnl.Valued = false
+					continue
+				}
+				pnl.FeesValue = pnl.FeesValue.Add(amount.Mul(price))
+			}
+		}
+
+		pnl.TotalValue = pnl.RealizedValue.Add(pnl.UnrealizedValue).Sub(pnl.FeesValue)
+		report.Total = report.Total.Add(pnl.TotalValue)
+		report.Valued = report.Valued && pnl.Valued
+		report.Symbols = append(report.Symbols, pnl)
+	}
+
+	sort.Slice(report.Symbols, func(i, j int) bool {
+		return report.Symbols[i].Symbol < report.Symbols[j].Symbol
+	})
+	return report
+}
+
+// price - returns price of asset in reporting currency by ticker of `ASSET_CURRENCY` or `CURRENCY_ASSET` symbol
+func (l *Ledger) price(tickers map[string]exchange.Ticker, asset string) (decimal.Decimal, bool) {
+	if asset == l.currency {
+		return decimal.NewFromInt(1), true
+	}
+	if mid, ok := midPrice(tickers, asset+"_"+l.currency); ok {
+		return mid, true
+	}
+	if mid, ok := midPrice(tickers, l.currency+"_"+asset); ok {
+		return decimal.NewFromInt(1).Div(mid), true
+	}
+	return decimal.Zero, false
+}
+
+func copyAmounts(amounts map[string]decimal.Decimal) map[string]decimal.Decimal {
+	result := make(map[string]decimal.Decimal, len(amounts))
+	for asset, amount := range amounts {
+		result[asset] = amount
+	}
+	return result
+}
+
+func midPrice(tickers map[string]exchange.Ticker, symbol string) (decimal.Decimal, bool) {
+	ticker, ok := tickers[symbol]
+	if !ok || !ticker.Ask.IsPositive() || !ticker.Bid.IsPositive() {
+		return decimal.Zero, false
+	}
+	return decimal.Avg(ticker.Ask, ticker.Bid), true
+}
+
+func splitSymbol(symbol string) (string, string) {
+	parts := strings.SplitN(symbol, "_", 2)
+	if len(parts) != 2 {
+		return symbol, ""
+	}
+	return parts[0], parts[1]
+}
+
+// recordHedges - records fills of hedge orders by symbols of `to_symbols` of the quote provider
+func (mm *MarketMaker) recordHedges(orders []exchange.Order) {
+	for _, order := range orders {
+		if !order.ExecutedQty.IsPositive() {
+			continue
+		}
+
+		symbol, ok := mm.quoteProviderMeta.ToSymbols[order.Symbol]
+		if !ok {
+			symbol = order.Symbol
+		}
+
+		side := strategy.Bid
+		if order.Side == exchange.OrderSideSell {
+			side = strategy.Ask
+		}
+		mm.ledger.Fill(symbol, side, order.QuoteQty.Div(order.ExecutedQty), order.ExecutedQty)
+
+		if order.Commission.IsPositive() {
+			mm.ledger.Fee(symbol, order.CommissionAsset, order.Commission)
+		}
+	}
+}
+
+// recordOperationFee - records fee of finished operation which was sent by market maker. Fee is paid in chain's native asset.
+func (mm *MarketMaker) recordOperationFee(hashedSecret chain.Hex, operation chain.Operation) {
+	if !operation.Fee.IsPositive() {
+		return
+	}
+	native, ok := mm.inventory.Native(operation.ChainType)
+	if !ok {
+		mm.log.Warn().Str("chain", operation.ChainType.String()).Str("hash", operation.Hash).Msg("unknown native asset. fee isn't recorded.")
+		return
+	}
+	mm.ledger.SwapFee(hashedSecret, native.Name, operation.Fee.Shift(-int32(native.Decimals)))
+}
+
+func (mm *MarketMaker) reportPnL() {
+	report := mm.ledger.Report(mm.tickers)
+	for _, pnl := range report.Symbols {
+		mm.log.Info().
+			Str("symbol", pnl.Symbol).
+			Str("position", pnl.Position.String()).
+			Str("avg_price", pnl.AvgPrice.String()).
+			Str("volume", pnl.Volume.String()).
+			Int64("fills", pnl.Fills).
+			Str("realized", pnl.Realized.String()).
+			Str("unrealized", pnl.Unrealized.String()).
+			Interface("fees", pnl.Fees).
+			Interface("payoffs", pnl.Payoffs).
+			Str("total", pnl.TotalValue.StringFixed(2)).
+			Bool("valued", pnl.Valued).
+			Msg("symbol's PnL")
+	}
+	mm.log.Info().
+		Str("currency", report.Currency).
+		Str("total", report.Total.StringFixed(2)).
+		Bool("valued", report.Valued).
+		Msg("PnL")
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/atomex-protocol/watch_tower/cmd/market_maker/strategy"
+	"github.com/atomex-protocol/watch_tower/internal/chain"
+	"github.com/atomex-protocol/watch_tower/internal/exchange"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPosition_add(t *testing.T) {
+	d := decimal.RequireFromString
+
+	var p position
+	assert.True(t, p.add(strategy.Bid, d("10"), d("2")).IsZero())
+	assert.True(t, p.add(strategy.Bid, d("13"), d("1")).IsZero())
+	assert.Equal(t, "3", p.qty.String())
+	assert.Equal(t, "11", p.avgPrice.String())
+
+	assert.Equal(t, "2", p.add(strategy.Ask, d("12"), d("2")).String())
+	assert.Equal(t, "1", p.qty.String())
+	assert.Equal(t, "11", p.avgPrice.String())
+
+	// position is flipped to short
+	assert.Equal(t, "-1", p.add(strategy.Ask, d("10"), d("3")).String())
+	assert.Equal(t, "-2", p.qty.String())
+	assert.Equal(t, "10", p.avgPrice.String())
+
+	assert.Equal(t, "4", p.add(strategy.Bid, d("8"), d("2")).String())
+	assert.True(t, p.qty.IsZero())
+	assert.True(t, p.avgPrice.IsZero())
+
+	assert.Equal(t, "5", p.realized.String())
+	assert.Equal(t, "10", p.volume.String())
+	assert.EqualValues(t, 5, p.fills)
+}
+
+func TestLedger_Report(t *testing.T) {
+	d := decimal.RequireFromString
+
+	ledger := NewLedger(LedgerConfig{Currency: "USDT"})
+	ledger.Fill("XTZ_ETH", strategy.Bid, d("0.001"), d("100"))
+	ledger.Fill("XTZ_USDT", strategy.Ask, d("3"), d("100"))
+
+	hashedSecret := chain.Hex("abcd")
+	ledger.Swap(hashedSecret, "XTZ_ETH", "XTZ")
+	ledger.SwapFee(hashedSecret, "ETH", d("0.001"))
+	require.True(t, ledger.SwapPayoff(hashedSecret, d("1")))
+	ledger.ForgetSwap(hashedSecret)
+	require.False(t, ledger.SwapPayoff(hashedSecret, d("1")))
+
+	tickers := map[string]exchange.Ticker{
+		"XTZ_ETH":  {Bid: d("0.0011"), Ask: d("0.0013")},
+		"XTZ_USDT": {Bid: d("3.5"), Ask: d("3.5")},
+		"ETH_USDT": {Bid: d("2000"), Ask: d("2000")},
+	}
+
+	report := ledger.Report(tickers)
+	require.Len(t, report.Symbols, 2)
+	assert.Equal(t, "USDT", report.Currency)
+	assert.True(t, report.Valued)
+
+	xtzEth := report.Symbols[0]
+	assert.Equal(t, "XTZ_ETH", xtzEth.Symbol)
+	assert.Equal(t, "0.02", xtzEth.Unrealized.String())
+	assert.Equal(t, "40", xtzEth.UnrealizedValue.String())
+	assert.Equal(t, "5.5", xtzEth.FeesValue.String())
+	assert.Equal(t, "34.5", xtzEth.TotalValue.String())
+
+	xtzUsdt := report.Symbols[1]
+	assert.Equal(t, "XTZ_USDT", xtzUsdt.Symbol)
+	assert.Equal(t, "-50", xtzUsdt.Unrealized.String())
+	assert.Equal(t, "-50", xtzUsdt.TotalValue.String())
+
+	assert.Equal(t, "-15.5", report.Total.String())
+
+	delete(tickers, "ETH_USDT")
+	assert.False(t, ledger.Report(tickers).Valued)
+}
@@ -10,10 +10,17 @@ import (
 	"time"
 
 	"github.com/atomex-protocol/watch_tower/internal/config"
+	"github.com/atomex-protocol/watch_tower/internal/logger"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
+// subcommands
+const (
+	commandBacktest = "backtest"
+	commandRecord   = "record"
+)
+
 func main() {
 	zerolog.SetGlobalLevel(zerolog.InfoLevel)
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stdout, TimeFormat: time.RFC3339})
@@ -21,6 +28,12 @@ func main() {
 	var configDir string
 	flag.StringVar(&configDir, "c", "configs", "path to directory containing configs")
 
+	var command string
+	if len(os.Args) > 1 && (os.Args[1] == commandBacktest || os.Args[1] == commandRecord) {
+		command = os.Args[1]
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
+
 	flag.Parse()
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -43,6 +56,16 @@ func main() {
 		log.Panic().Err(err).Msg("config.loadQuoteProviderMeta")
 	}
 
+	switch command {
+	case commandBacktest:
+		runBacktest(cfg)
+		return
+	case commandRecord:
+		runRecorder(ctx, cfg)
+		cancel()
+		return
+	}
+
 	marketMaker, err := NewMarketMaker(cfg)
 	if err != nil {
 		log.Panic().Err(err).Msg("NewMarketMaker")
@@ -72,3 +95,59 @@ func main() {
 
 	log.Info().Msg("stopped")
 }
+
+func runBacktest(cfg Config) {
+	logLevel, err := zerolog.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		log.Panic().Err(err).Msg("zerolog.ParseLevel")
+	}
+	backtestLog := logger.New(logger.WithLogLevel(logLevel), logger.WithModuleName("backtest"))
+
+	bt, err := NewBacktest(cfg, backtestLog)
+	if err != nil {
+		log.Panic().Err(err).Msg("NewBacktest")
+	}
+
+	report, err := bt.Run()
+	if err != nil {
+		log.Panic().Err(err).Msg("backtest.Run")
+	}
+
+	for i := range report.Strategies {
+		report.Strategies[i].Log(backtestLog)
+	}
+
+	if cfg.Backtest.Report != "" {
+		if err := report.Save(cfg.Backtest.Report); err != nil {
+			log.Panic().Err(err).Str("file", cfg.Backtest.Report).Msg("report.Save")
+		}
+	}
+}
+
+func runRecorder(ctx context.Context, cfg Config) {
+	logLevel, err := zerolog.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		log.Panic().Err(err).Msg("zerolog.ParseLevel")
+	}
+
+	marketRecorder, err := NewMarketRecorder(cfg, logger.New(logger.WithLogLevel(logLevel), logger.WithModuleName("recorder")))
+	if err != nil {
+		log.Panic().Err(err).Msg("NewMarketRecorder")
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
+
+	if err := marketRecorder.Start(ctx); err != nil {
+		log.Panic().Err(err).Msg("marketRecorder.Start")
+	}
+
+	<-signals
+
+	if err := marketRecorder.Close(); err != nil {
+		log.Panic().Err(err).Msg("marketRecorder.Close")
+	}
+	close(signals)
+
+	log.Info().Msg("stopped")
+}
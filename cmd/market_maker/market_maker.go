@@ -17,22 +17,66 @@ import (
 	"github.com/atomex-protocol/watch_tower/internal/exchange/binance"
 	"github.com/atomex-protocol/watch_tower/internal/keys"
 	"github.com/atomex-protocol/watch_tower/internal/logger"
+	"github.com/atomex-protocol/watch_tower/internal/storage"
 	"github.com/atomex-protocol/watch_tower/internal/types"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 )
 
+// atomexExchange - websocket API of Atomex. It's implemented by `atomex.Exchange` and `PaperExchange`.
+type atomexExchange interface {
+	Connect(token atomex.TokenResponse) error
+	Close() error
+	Listen() <-chan atomex.Message
+	Errors() <-chan error
+	SendOrder(order atomex.AddOrderRequest) error
+	CancelOrder(order atomex.CancelOrderRequest) error
+}
+
+// atomexREST - REST API of Atomex. It's implemented by `atomex.Rest` and `PaperRest`.
+type atomexREST interface {
+	Auth(ctx context.Context, keys *signers.Key) error
+	GetToken() string
+	Orders(ctx context.Context, req atomex.OrdersRequest) ([]atomex.Order, error)
+	Order(ctx context.Context, id int64) (atomex.Order, error)
+	CancelOrder(ctx context.Context, id int64, symbol string, side atomex.Side) (atomex.DefaultResponse, error)
+	Swaps(ctx context.Context, req atomex.SwapsRequest) ([]atomex.Swap, error)
+}
+
+// swapTracker - tracker of swaps in chains. It's implemented by `tools.Tracker` and `PaperTracker`.
+type swapTracker interface {
+	balanceSource
+
+	Start(ctx context.Context) error
+	Close() error
+	StatusChanged() <-chan tools.Swap
+	Operations() <-chan chain.Operation
+	Restored() <-chan struct{}
+	Initiate(ctx context.Context, args chain.InitiateArgs, chainType chain.ChainType) error
+	Redeem(ctx context.Context, swap tools.Swap, leg tools.Leg) error
+	Refund(ctx context.Context, swap tools.Swap, leg tools.Leg) error
+	Wallet(typ chain.ChainType) (chain.Wallet, error)
+	Watch(chainType chain.ChainType, args chain.WatchArgs) error
+}
+
 // MarketMaker -
 type MarketMaker struct {
 	log zerolog.Logger
 
-	atomex     *atomex.Exchange
-	atomexAPI  *atomex.Rest
+	atomex     atomexExchange
+	atomexAPI  atomexREST
 	provider   exchange.Exchange
-	tracker    *tools.Tracker
+	hedger     *Hedger
+	tracker    swapTracker
+	storage    storage.Storage
+	inventory  *Inventory
+	ledger     *Ledger
 	strategies []strategy.Strategy
 	symbols    map[string]types.Symbol
 
+	warmup        WarmupConfig
+	warmupWindows map[string]int
+
 	keys              Keys
 	atomexMeta        config.Atomex
 	quoteProviderMeta QuoteProviderMeta
@@ -41,11 +85,21 @@ type MarketMaker struct {
 
 	orders     *OrdersMap
 	swaps      *SwapsMap
+	fills      *Fills
 	secrets    *Secrets
 	operations map[tools.OperationID]chain.Operation
 
+	// swaps whose amounts are reserved by Atomex data. It's used by Atomex listener only.
+	reservedSwaps map[chain.Hex]struct{}
+	// redeems of swaps' receiving legs. It's used by tracker listener only.
+	redeems map[chain.Hex]*redeemState
+
 	activeSwaps []atomex.Swap
 
+	inventoryUpdateInterval time.Duration
+	ledgerReportInterval    time.Duration
+	lastOrderIndex          int64
+
 	wg sync.WaitGroup
 }
 
@@ -57,45 +111,40 @@ func NewMarketMaker(cfg Config) (*MarketMaker, error) {
 	}
 
 	var provider exchange.Exchange
+	var trader exchange.Trader
 	switch cfg.QuoteProvider.Kind {
 	case QuoteProviderKindBinance:
-		provider = binance.NewBinance(
+		binanceProvider := binance.NewBinance(
 			binance.WithRestURL(binance.BaseURLServer2),
 			binance.WithWebsocketURL(binance.BaseURLWebsocket),
 			binance.WithLogLevel(logLevel),
 		)
+		provider = binanceProvider
+		trader = binanceProvider
 	default:
 		return nil, errors.Errorf("unknown quote provider: %s", cfg.QuoteProvider.Kind)
 	}
 
-	atomexExchange, err := atomex.NewExchange(
-		atomex.WithLogLevel(logLevel),
-		atomex.WithSignature(signers.AlgorithmBlake2bWithEcdsaSecp256k1),
-		atomex.WithWebsocketURI(cfg.General.Atomex.WsAPI),
-	)
-	if err != nil {
-		return nil, errors.Wrap(err, "atomex.NewExchange")
-	}
-
-	trackerOptions := []tools.TrackerOption{
-		tools.WithLogLevel(logLevel),
-	}
-	if cfg.Restore {
-		trackerOptions = append(trackerOptions, tools.WithRestore())
-	}
-	track, err := tools.NewTracker(cfg.General.Chains, trackerOptions...)
-	if err != nil {
-		return nil, err
+	if cfg.Warmup.Interval != "" && cfg.Warmup.Interval.Duration() == 0 {
+		return nil, errors.Errorf("unknown warmup interval: %s", cfg.Warmup.Interval)
 	}
 
 	symbols := make(map[string]types.Symbol)
 	strategies := make([]strategy.Strategy, 0)
+	warmupWindows := make(map[string]int)
 	for _, s := range cfg.Strategies {
-		strategy, err := strategy.New(s)
+		if s.Kind == strategy.KindAvellanedaStoikov && s.Horizon == 0 {
+			// swap's funds are locked until refund time, so it's the natural horizon
+			s.Horizon = cfg.General.Atomex.Settings.LockTime
+		}
+		strat, err := strategy.New(s)
 		if err != nil {
 			return nil, err
 		}
-		strategies = append(strategies, strategy)
+		strategies = append(strategies, strat)
+		if strategy.IsWarmer(strat) && s.Window > warmupWindows[s.SymbolName] {
+			warmupWindows[s.SymbolName] = s.Window
+		}
 
 		for _, symbol := range cfg.General.Symbols {
 			if symbol.Name == s.SymbolName {
@@ -118,43 +167,133 @@ func NewMarketMaker(cfg Config) (*MarketMaker, error) {
 		}
 	}
 
-	return &MarketMaker{
-		log:      logger.New(logger.WithLogLevel(logLevel), logger.WithModuleName("market_maker")),
-		provider: provider,
-		atomex:   atomexExchange,
-		atomexAPI: atomex.NewRest(
+	log := logger.New(logger.WithLogLevel(logLevel), logger.WithModuleName("market_maker"))
+
+	var atomexWs atomexExchange
+	var atomexAPI atomexREST
+	var track swapTracker
+	var store storage.Storage
+	switch cfg.Mode {
+	case ModePaper:
+		if cfg.Hedging.Enabled {
+			return nil, errors.New("hedging isn't supported in paper mode")
+		}
+
+		paperTracker, err := NewPaperTracker(cfg.Paper, cfg.General.Assets, log)
+		if err != nil {
+			return nil, errors.Wrap(err, "NewPaperTracker")
+		}
+
+		atomexSymbols := make(map[string]types.Symbol)
+		for name, symbol := range symbols {
+			if atomexSymbol, ok := cfg.General.Atomex.ToSymbols[name]; ok {
+				atomexSymbols[atomexSymbol] = symbol
+			}
+		}
+		paperExchange := NewPaperExchange(cfg.Paper, atomex.NewRest(atomex.WithURL(cfg.General.Atomex.RestAPI)), paperTracker, atomexSymbols, log)
+
+		atomexWs = paperExchange
+		atomexAPI = NewPaperRest(paperExchange)
+		track = paperTracker
+		log.Warn().Msg("paper mode: orders and swaps are simulated")
+	default:
+		atomexExchange, err := atomex.NewExchange(
+			atomex.WithLogLevel(logLevel),
+			atomex.WithSignature(signers.AlgorithmBlake2bWithEcdsaSecp256k1),
+			atomex.WithWebsocketURI(cfg.General.Atomex.WsAPI),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "atomex.NewExchange")
+		}
+
+		store, err = storage.New(cfg.Storage)
+		if err != nil {
+			return nil, errors.Wrap(err, "storage.New")
+		}
+
+		trackerOptions := []tools.TrackerOption{
+			tools.WithLogLevel(logLevel),
+			tools.WithWatches(store),
+		}
+		if cfg.Restore {
+			trackerOptions = append(trackerOptions, tools.WithRestore())
+		}
+		tracker, err := tools.NewTracker(cfg.General.Chains, trackerOptions...)
+		if err != nil {
+			return nil, err
+		}
+
+		atomexWs = atomexExchange
+		atomexAPI = atomex.NewRest(
 			atomex.WithURL(cfg.General.Atomex.RestAPI),
 			atomex.WithSignatureAlgorithm(signers.AlgorithmEd25519Blake2b),
-		),
+		)
+		track = tracker
+	}
+
+	inventory, err := NewInventory(track, symbols, cfg.General.Assets, cfg.Inventory, log)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewInventory")
+	}
+	if cfg.Inventory.UpdateInterval == 0 {
+		cfg.Inventory.UpdateInterval = defaultInventoryUpdateInterval
+	}
+
+	if cfg.Ledger.ReportInterval == 0 {
+		cfg.Ledger.ReportInterval = defaultLedgerReportInterval
+	}
+
+	var hedger *Hedger
+	if cfg.Hedging.Enabled {
+		hedger = NewHedger(trader, synthetics, log)
+	}
+
+	return &MarketMaker{
+		log:               log,
+		provider:          provider,
+		hedger:            hedger,
+		atomex:            atomexWs,
+		atomexAPI:         atomexAPI,
 		tracker:           track,
+		storage:           store,
+		inventory:         inventory,
+		ledger:            NewLedger(cfg.Ledger),
 		strategies:        strategies,
 		symbols:           symbols,
+		warmup:            cfg.Warmup,
+		warmupWindows:     warmupWindows,
 		synthetics:        synthetics,
 		keys:              cfg.Keys,
 		atomexMeta:        cfg.General.Atomex,
 		quoteProviderMeta: cfg.QuoteProviderMeta,
 		orders:            NewOrdersMap(),
 		swaps:             NewSwapsMap(),
+		reservedSwaps:     make(map[chain.Hex]struct{}),
+		redeems:           make(map[chain.Hex]*redeemState),
+		fills:             NewFills(),
 		secrets:           NewSecrets(),
 		tickers:           tickers,
 		operations:        make(map[tools.OperationID]chain.Operation),
 		activeSwaps:       make([]atomex.Swap, 0),
+
+		inventoryUpdateInterval: time.Duration(cfg.Inventory.UpdateInterval) * time.Second,
+		ledgerReportInterval:    time.Duration(cfg.Ledger.ReportInterval) * time.Second,
 	}, nil
 }
 
-func (mm *MarketMaker) loadKeys() (*signers.Key, error) {
-	keysStorage, err := keys.New(mm.keys.Kind)
+func loadKeys(cfg Keys) (*signers.Key, error) {
+	keysStorage, err := keys.New(cfg.Kind)
 	if err != nil {
 		return nil, err
 	}
 
-	loadedKeys, err := keysStorage.Get(mm.keys.File)
+	loadedKeys, err := keysStorage.Get(cfg.File)
 	if err == nil {
 		return loadedKeys, err
 	}
 
-	if os.IsNotExist(err) && mm.keys.GenerateIfNotExists {
-		return keysStorage.Create(mm.keys.File)
+	if os.IsNotExist(err) && cfg.GenerateIfNotExists {
+		return keysStorage.Create(cfg.File)
 	}
 
 	return nil, err
@@ -165,7 +304,7 @@ func (mm *MarketMaker) Start(ctx context.Context) error {
 	mm.wg.Add(1)
 	go mm.listenAtomex(ctx)
 
-	loadedKeys, err := mm.loadKeys()
+	loadedKeys, err := loadKeys(mm.keys)
 	if err != nil {
 		return err
 	}
@@ -197,8 +336,22 @@ func (mm *MarketMaker) Start(ctx context.Context) error {
 		return errors.Wrap(err, "tracker.Start")
 	}
 
+	// init inventory
+
+	mm.inventory.Update(ctx)
+
+	mm.wg.Add(1)
+	go func() {
+		defer mm.wg.Done()
+		mm.inventory.Run(ctx, mm.inventoryUpdateInterval)
+	}()
+
 	// init quote provider
 
+	if err := mm.warmUp(); err != nil {
+		mm.log.Warn().Err(err).Msg("strategies aren't warmed up")
+	}
+
 	mm.wg.Add(1)
 	go mm.listenProvider(ctx)
 
@@ -234,6 +387,11 @@ func (mm *MarketMaker) Close(ctx context.Context) error {
 	if err := mm.tracker.Close(); err != nil {
 		return err
 	}
+	if mm.storage != nil {
+		if err := mm.storage.Close(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -333,18 +491,18 @@ func (mm *MarketMaker) initializeSwaps(ctx context.Context) error {
 			continue
 		}
 
-		swap, err := mm.atomexSwapToInternal(mm.activeSwaps[i])
-		if err != nil {
-			return err
+		// swap is stored or merged and reserved by update if it isn't reserved yet
+		if err := mm.handleAtomexSwapUpdate(mm.activeSwaps[i]); err != nil {
+			return errors.Wrap(err, "handleAtomexSwapUpdate")
 		}
-		internalSwap := mm.swaps.LoadOrStore(swap.HashedSecret, swap)
 
-		if err := mm.restoreSecretForAtomexSwap(ctx, mm.activeSwaps[i], internalSwap); err != nil {
-			return errors.Wrap(err, "restoreSecretForAtomexSwap")
+		internalSwap, ok := mm.swaps.Load(chain.Hex(mm.activeSwaps[i].SecretHash))
+		if !ok {
+			continue
 		}
 
-		if err := mm.handleAtomexSwapUpdate(mm.activeSwaps[i]); err != nil {
-			return errors.Wrap(err, "handleAtomexSwapUpdate")
+		if err := mm.restoreSecretForAtomexSwap(ctx, mm.activeSwaps[i], internalSwap); err != nil {
+			return errors.Wrap(err, "restoreSecretForAtomexSwap")
 		}
 
 		if internalSwap.Status == tools.StatusEmpty {
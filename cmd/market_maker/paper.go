@@ -0,0 +1,84 @@
+package main
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// default interval of Atomex top of book polling in paper mode in seconds
+const defaultPaperTopOfBookInterval = 5
+
+// PaperConfig - settings of paper mode. `Balances` are initial balances of simulated wallets by asset names in asset units.
+// `Fees` are amounts of chain's native currency which are paid by each simulated operation by chain names.
+type PaperConfig struct {
+	TopOfBookInterval int64                      `yaml:"top_of_book_interval" validate:"omitempty,gt=0"`
+	Latency           PaperLatency               `yaml:"latency"`
+	Failure           PaperFailure               `yaml:"failure"`
+	Balances          map[string]decimal.Decimal `yaml:"balances"`
+	Fees              map[string]decimal.Decimal `yaml:"fees"`
+}
+
+// PaperLatency - latencies of simulated events in milliseconds
+type PaperLatency struct {
+	Order        int64 `yaml:"order" validate:"omitempty,gte=0"`
+	Operation    int64 `yaml:"operation" validate:"omitempty,gte=0"`
+	Counterparty int64 `yaml:"counterparty" validate:"omitempty,gte=0"`
+}
+
+// PaperFailure - probabilities of failures of simulated events
+type PaperFailure struct {
+	Order        float64 `yaml:"order" validate:"omitempty,gte=0,lte=1"`
+	Operation    float64 `yaml:"operation" validate:"omitempty,gte=0,lte=1"`
+	Counterparty float64 `yaml:"counterparty" validate:"omitempty,gte=0,lte=1"`
+}
+
+// simulator - runs delayed events of paper mode until it's closed
+type simulator struct {
+	stop chan struct{}
+	wg   sync.WaitGroup
+
+	mx  sync.Mutex
+	rnd *rand.Rand
+}
+
+func newSimulator() *simulator {
+	return &simulator{
+		stop: make(chan struct{}),
+		rnd:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// after - calls `f` after `latency` milliseconds if simulator isn't closed
+func (s *simulator) after(latency int64, f func()) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		timer := time.NewTimer(time.Duration(latency) * time.Millisecond)
+		defer timer.Stop()
+
+		select {
+		case <-s.stop:
+		case <-timer.C:
+			f()
+		}
+	}()
+}
+
+// fails - returns true with `probability`
+func (s *simulator) fails(probability float64) bool {
+	if probability <= 0 {
+		return false
+	}
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.rnd.Float64() < probability
+}
+
+func (s *simulator) close() {
+	close(s.stop)
+	s.wg.Wait()
+}
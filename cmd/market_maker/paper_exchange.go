@@ -0,0 +1,348 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/atomex-protocol/watch_tower/internal/atomex"
+	"github.com/atomex-protocol/watch_tower/internal/atomex/signers"
+	"github.com/atomex-protocol/watch_tower/internal/chain"
+	"github.com/atomex-protocol/watch_tower/internal/types"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+)
+
+// address of simulated counterparty
+const paperCounterparty = "paper_counterparty"
+
+type topOfBookSource interface {
+	TopOfBookQuotes(ctx context.Context, symbols ...string) ([]atomex.TopOfBook, error)
+}
+
+type paperOrder struct {
+	order      atomex.OrderWebsocket
+	requisites atomex.Requisites
+}
+
+// PaperExchange - simulates Atomex exchange for paper mode. Orders are filled entirely by their prices when they cross the real Atomex top of book.
+// Swaps of filled orders are passed to `PaperTracker`. REST API is implemented by `PaperRest`.
+type PaperExchange struct {
+	*simulator
+
+	cfg     PaperConfig
+	book    topOfBookSource
+	tracker *PaperTracker
+	symbols map[string]types.Symbol
+	log     zerolog.Logger
+
+	mx     sync.Mutex
+	orders map[int64]*paperOrder
+	tops   map[string]atomex.TopOfBook
+	lastID int64
+
+	msgs chan atomex.Message
+	errs chan error
+}
+
+// NewPaperExchange - `symbols` are keyed by Atomex symbol names
+func NewPaperExchange(cfg PaperConfig, book topOfBookSource, tracker *PaperTracker, symbols map[string]types.Symbol, log zerolog.Logger) *PaperExchange {
+	return &PaperExchange{
+		simulator: newSimulator(),
+		cfg:       cfg,
+		book:      book,
+		tracker:   tracker,
+		symbols:   symbols,
+		log:       log,
+		orders:    make(map[int64]*paperOrder),
+		tops:      make(map[string]atomex.TopOfBook),
+		msgs:      make(chan atomex.Message, 1024),
+		errs:      make(chan error, 1024),
+	}
+}
+
+// Connect - starts polling of top of book
+func (ex *PaperExchange) Connect(token atomex.TokenResponse) error {
+	ex.wg.Add(1)
+	go ex.poll()
+	return nil
+}
+
+// Close -
+func (ex *PaperExchange) Close() error {
+	ex.close()
+	return nil
+}
+
+// Listen -
+func (ex *PaperExchange) Listen() <-chan atomex.Message {
+	return ex.msgs
+}
+
+// Errors -
+func (ex *PaperExchange) Errors() <-chan error {
+	return ex.errs
+}
+
+// SendOrder - places order after latency. Order is rejected with configured probability.
+func (ex *PaperExchange) SendOrder(request atomex.AddOrderRequest) error {
+	if _, ok := ex.symbols[request.Symbol]; !ok {
+		return errors.Errorf("unknown symbol: %s", request.Symbol)
+	}
+	if request.Requisites == nil {
+		return errors.New("empty requisites")
+	}
+
+	order := atomex.OrderWebsocket{
+		ClientOrderID: request.ClientOrderID,
+		Symbol:        request.Symbol,
+		Side:          request.Side,
+		Price:         decimal.NewFromFloat(request.Price),
+		Qty:           decimal.NewFromFloat(request.Qty),
+		LeaveQty:      decimal.NewFromFloat(request.Qty),
+		Type:          request.Type,
+	}
+	requisites := *request.Requisites
+
+	ex.after(ex.cfg.Latency.Order, func() {
+		ex.mx.Lock()
+		defer ex.mx.Unlock()
+
+		ex.lastID++
+		order.ID = ex.lastID
+		order.Timestamp = time.Now()
+
+		if ex.fails(ex.cfg.Failure.Order) {
+			order.Status = atomex.OrderStatusRejected
+			ex.emit(atomex.WebsocketMethodOrderReply, order)
+			return
+		}
+
+		order.Status = atomex.OrderStatusPlaced
+		ex.emit(atomex.WebsocketMethodOrderReply, order)
+
+		placed := &paperOrder{order, requisites}
+		ex.orders[order.ID] = placed
+		ex.match(placed)
+	})
+	return nil
+}
+
+// CancelOrder - cancels order after latency
+func (ex *PaperExchange) CancelOrder(request atomex.CancelOrderRequest) error {
+	ex.after(ex.cfg.Latency.Order, func() {
+		ex.mx.Lock()
+		defer ex.mx.Unlock()
+
+		order, ok := ex.cancel(request.ID)
+		if !ok {
+			ex.emitError(errors.Errorf("paper: unknown order %d", request.ID))
+			return
+		}
+		ex.emit(atomex.WebsocketMethodOrderReply, order)
+	})
+	return nil
+}
+
+func (ex *PaperExchange) cancel(id int64) (atomex.OrderWebsocket, bool) {
+	order, ok := ex.orders[id]
+	if !ok {
+		return atomex.OrderWebsocket{}, false
+	}
+	delete(ex.orders, id)
+
+	order.order.Status = atomex.OrderStatusCanceled
+	return order.order, true
+}
+
+func (ex *PaperExchange) poll() {
+	defer ex.wg.Done()
+
+	interval := ex.cfg.TopOfBookInterval
+	if interval == 0 {
+		interval = defaultPaperTopOfBookInterval
+	}
+	ticker := time.NewTicker(time.Duration(interval) * time.Second)
+	defer ticker.Stop()
+
+	symbols := make([]string, 0, len(ex.symbols))
+	for symbol := range ex.symbols {
+		symbols = append(symbols, symbol)
+	}
+
+	for {
+		select {
+		case <-ex.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+			tops, err := ex.book.TopOfBookQuotes(ctx, symbols...)
+			cancel()
+			if err != nil {
+				ex.emitError(errors.Wrap(err, "paper: TopOfBookQuotes"))
+				continue
+			}
+			ex.update(tops)
+		}
+	}
+}
+
+// update - updates top of book and fills crossed orders
+func (ex *PaperExchange) update(tops []atomex.TopOfBook) {
+	ex.mx.Lock()
+	defer ex.mx.Unlock()
+
+	for i := range tops {
+		ex.tops[tops[i].Symbol] = tops[i]
+	}
+	for _, order := range ex.orders {
+		ex.match(order)
+	}
+}
+
+// match - fills order entirely by its price if it crosses the top of book. It's called under lock.
+func (ex *PaperExchange) match(order *paperOrder) {
+	top, ok := ex.tops[order.order.Symbol]
+	if !ok {
+		return
+	}
+
+	switch order.order.Side {
+	case atomex.SideBuy:
+		if !top.Ask.IsPositive() || order.order.Price.LessThan(top.Ask) {
+			return
+		}
+	case atomex.SideSell:
+		if !top.Bid.IsPositive() || order.order.Price.GreaterThan(top.Bid) {
+			return
+		}
+	default:
+		return
+	}
+
+	delete(ex.orders, order.order.ID)
+
+	trade := atomex.Trade{
+		OrderID: order.order.ID,
+		Price:   order.order.Price,
+		Qty:     order.order.LeaveQty,
+	}
+	order.order.LeaveQty = decimal.Zero
+	order.order.Status = atomex.OrderStatusFilled
+	order.order.Trades = []atomex.Trade{trade}
+	ex.emit(atomex.WebsocketMethodOrderReply, order.order)
+
+	symbol := ex.symbols[order.order.Symbol]
+	received, amount := symbol.Base, trade.Qty
+	if order.order.Side == atomex.SideSell {
+		received, amount = symbol.Quote, trade.Qty.Mul(trade.Price)
+	}
+	reward := decimal.NewFromFloat(order.requisites.RewardForRedeem)
+	ex.tracker.Expect(chain.Hex(order.requisites.SecretHash), received, amount, reward)
+
+	swap := atomex.Swap{
+		ID:          order.order.ID,
+		Symbol:      order.order.Symbol,
+		Side:        order.order.Side,
+		TimeStamp:   time.Now(),
+		Price:       trade.Price,
+		Qty:         trade.Qty,
+		SecretHash:  order.requisites.SecretHash,
+		IsInitiator: true,
+		User: atomex.User{
+			Requisites: order.requisites,
+			Status:     atomex.SwapStatusInvolved,
+			Trades:     []atomex.Trade{trade},
+		},
+		CounterParty: atomex.User{
+			Requisites: atomex.Requisites{
+				SecretHash:       order.requisites.SecretHash,
+				ReceivingAddress: paperCounterparty,
+				RefundAddress:    paperCounterparty,
+				RewardForRedeem:  order.requisites.RewardForRedeem,
+				LockTime:         order.requisites.LockTime / 2,
+			},
+			Status: atomex.SwapStatusInvolved,
+		},
+	}
+	ex.after(ex.cfg.Latency.Order, func() {
+		ex.emit(atomex.WebsocketMethodSwapReply, swap)
+	})
+}
+
+func (ex *PaperExchange) emit(event atomex.WebsocketMethod, value interface{}) {
+	select {
+	case ex.msgs <- atomex.Message{Event: event, Value: value}:
+	case <-ex.stop:
+	}
+}
+
+func (ex *PaperExchange) emitError(err error) {
+	select {
+	case ex.errs <- err:
+	case <-ex.stop:
+	}
+}
+
+// PaperRest - REST API of `PaperExchange`
+type PaperRest struct {
+	ex *PaperExchange
+}
+
+// NewPaperRest -
+func NewPaperRest(ex *PaperExchange) PaperRest {
+	return PaperRest{ex}
+}
+
+// Auth - authentication isn't needed in paper mode
+func (rest PaperRest) Auth(ctx context.Context, keys *signers.Key) error {
+	return nil
+}
+
+// GetToken -
+func (rest PaperRest) GetToken() string {
+	return ""
+}
+
+// Orders - there are no orders of previous runs in paper mode
+func (rest PaperRest) Orders(ctx context.Context, req atomex.OrdersRequest) ([]atomex.Order, error) {
+	return nil, nil
+}
+
+// Order -
+func (rest PaperRest) Order(ctx context.Context, id int64) (atomex.Order, error) {
+	rest.ex.mx.Lock()
+	defer rest.ex.mx.Unlock()
+
+	order, ok := rest.ex.orders[id]
+	if !ok {
+		return atomex.Order{}, errors.Errorf("unknown order: %d", id)
+	}
+	return atomex.Order{
+		ID:            order.order.ID,
+		ClientOrderID: order.order.ClientOrderID,
+		Symbol:        order.order.Symbol,
+		Side:          order.order.Side,
+		Timestamp:     order.order.Timestamp,
+		Price:         order.order.Price,
+		Qty:           order.order.Qty,
+		LeaveQty:      order.order.LeaveQty,
+		Type:          order.order.Type,
+		Status:        order.order.Status,
+	}, nil
+}
+
+// Swaps - there are no swaps of previous runs in paper mode
+func (rest PaperRest) Swaps(ctx context.Context, req atomex.SwapsRequest) ([]atomex.Swap, error) {
+	return nil, nil
+}
+
+// CancelOrder - cancels order immediately
+func (rest PaperRest) CancelOrder(ctx context.Context, id int64, symbol string, side atomex.Side) (atomex.DefaultResponse, error) {
+	rest.ex.mx.Lock()
+	defer rest.ex.mx.Unlock()
+
+	_, ok := rest.ex.cancel(id)
+	return atomex.DefaultResponse{Result: ok}, nil
+}
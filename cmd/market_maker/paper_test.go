@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	"github.com/atomex-protocol/watch_tower/internal/atomex"
+	"github.com/atomex-protocol/watch_tower/internal/chain"
+	"github.com/atomex-protocol/watch_tower/internal/chain/tools"
+	"github.com/atomex-protocol/watch_tower/internal/types"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testBook []atomex.TopOfBook
+
+func (b testBook) TopOfBookQuotes(ctx context.Context, symbols ...string) ([]atomex.TopOfBook, error) {
+	return b, nil
+}
+
+func receiveMessage(t *testing.T, ch <-chan atomex.Message) atomex.Message {
+	select {
+	case msg := <-ch:
+		return msg
+	case <-time.After(time.Second):
+		require.FailNow(t, "message timeout")
+	}
+	return atomex.Message{}
+}
+
+func receiveOperation(t *testing.T, ch <-chan chain.Operation) chain.Operation {
+	select {
+	case operation := <-ch:
+		return operation
+	case <-time.After(time.Second):
+		require.FailNow(t, "operation timeout")
+	}
+	return chain.Operation{}
+}
+
+func receiveSwap(t *testing.T, ch <-chan tools.Swap) tools.Swap {
+	select {
+	case swap := <-ch:
+		return swap
+	case <-time.After(time.Second):
+		require.FailNow(t, "swap timeout")
+	}
+	return tools.Swap{}
+}
+
+func TestPaper(t *testing.T) {
+	d := decimal.RequireFromString
+
+	xtz := types.Asset{Name: "XTZ", Chain: "tezos", Decimals: 6, AtomexContract: "KT1"}
+	eth := types.Asset{Name: "ETH", Chain: "ethereum", Decimals: 18, AtomexContract: "0x1"}
+	symbol := types.Symbol{Name: "XTZ_ETH", Base: xtz, Quote: eth}
+
+	cfg := PaperConfig{
+		Balances: map[string]decimal.Decimal{"XTZ": d("100"), "ETH": d("1")},
+		Fees:     map[string]decimal.Decimal{"ethereum": d("0.001"), "tezos": d("0.01")},
+	}
+	tracker, err := NewPaperTracker(cfg, map[string]types.Asset{"XTZ": xtz, "ETH": eth}, zerolog.Nop())
+	require.NoError(t, err)
+	defer tracker.Close()
+
+	ex := NewPaperExchange(cfg, testBook{}, tracker, map[string]types.Symbol{"XTZ/ETH": symbol}, zerolog.Nop())
+	defer ex.Close()
+
+	secret := chain.Hex("0102030405060708091011121314151617181920212223242526272829303132")
+	data, err := secret.Bytes()
+	require.NoError(t, err)
+	first := sha256.Sum256(data)
+	hashedSecret := sha256.Sum256(first[:])
+
+	require.NoError(t, ex.SendOrder(atomex.AddOrderRequest{
+		ClientOrderID: "1",
+		Symbol:        "XTZ/ETH",
+		Price:         0.001,
+		Qty:           10,
+		Side:          atomex.SideBuy,
+		Requisites: &atomex.Requisites{
+			SecretHash:      chain.NewHexFromBytes(hashedSecret[:]).String(),
+			RewardForRedeem: 1,
+			LockTime:        3600,
+		},
+	}))
+
+	placed := receiveMessage(t, ex.Listen()).Value.(atomex.OrderWebsocket)
+	assert.Equal(t, atomex.OrderStatusPlaced, placed.Status)
+
+	// top of book doesn't cross the order
+	ex.update([]atomex.TopOfBook{{Symbol: "XTZ/ETH", Bid: d("0.0009"), Ask: d("0.0011")}})
+	ex.update([]atomex.TopOfBook{{Symbol: "XTZ/ETH", Bid: d("0.0009"), Ask: d("0.001")}})
+
+	filled := receiveMessage(t, ex.Listen()).Value.(atomex.OrderWebsocket)
+	assert.Equal(t, atomex.OrderStatusFilled, filled.Status)
+	assert.True(t, filled.LeaveQty.IsZero())
+
+	swap := receiveMessage(t, ex.Listen()).Value.(atomex.Swap)
+	assert.Equal(t, atomex.SwapStatusInvolved, swap.User.Status)
+	assert.Equal(t, atomex.SwapStatusInvolved, swap.CounterParty.Status)
+	assert.Equal(t, "10", swap.Qty.String())
+
+	require.NoError(t, tracker.Initiate(context.Background(), chain.InitiateArgs{
+		HashedSecret: chain.Hex(swap.SecretHash),
+		Participant:  paperCounterparty,
+		Contract:     eth.AtomexContract,
+		Amount:       d("10000000000000000"),
+	}, chain.ChainTypeEthereum))
+
+	assert.Equal(t, chain.Pending, receiveOperation(t, tracker.Operations()).Status)
+	applied := receiveOperation(t, tracker.Operations())
+	assert.Equal(t, chain.Applied, applied.Status)
+	assert.Equal(t, "1000000000000000", applied.Fee.String())
+
+	assert.Equal(t, tools.StatusInitiatedOnce, receiveSwap(t, tracker.StatusChanged()).Status)
+	initiated := receiveSwap(t, tracker.StatusChanged())
+	require.Equal(t, tools.StatusInitiated, initiated.Status)
+	assert.Equal(t, chain.ChainTypeTezos, initiated.Acceptor.ChainType)
+
+	assert.Error(t, tracker.Redeem(context.Background(), initiated, initiated.Acceptor))
+
+	initiated.Secret = secret
+	require.NoError(t, tracker.Redeem(context.Background(), initiated, initiated.Acceptor))
+	assert.Equal(t, chain.Pending, receiveOperation(t, tracker.Operations()).Status)
+	assert.Equal(t, chain.Applied, receiveOperation(t, tracker.Operations()).Status)
+	assert.Equal(t, tools.StatusRedeemedOnce, receiveSwap(t, tracker.StatusChanged()).Status)
+	assert.Equal(t, tools.StatusRedeemed, receiveSwap(t, tracker.StatusChanged()).Status)
+
+	balance, err := tracker.Balance(context.Background(), chain.ChainTypeTezos, chain.BalanceArgs{})
+	require.NoError(t, err)
+	assert.Equal(t, "109990000", balance.String())
+
+	balance, err = tracker.Balance(context.Background(), chain.ChainTypeEthereum, chain.BalanceArgs{})
+	require.NoError(t, err)
+	assert.Equal(t, "989000000000000000", balance.String())
+}
+
+func TestPaperTracker_CounterpartyFailure(t *testing.T) {
+	d := decimal.RequireFromString
+
+	xtz := types.Asset{Name: "XTZ", Chain: "tezos", Decimals: 6, AtomexContract: "KT1"}
+	cfg := PaperConfig{
+		Failure:  PaperFailure{Counterparty: 1},
+		Balances: map[string]decimal.Decimal{"XTZ": d("100")},
+		Fees:     map[string]decimal.Decimal{"tezos": d("0.01")},
+	}
+	tracker, err := NewPaperTracker(cfg, map[string]types.Asset{"XTZ": xtz}, zerolog.Nop())
+	require.NoError(t, err)
+	defer tracker.Close()
+
+	hashedSecret := chain.Hex("0102030405060708091011121314151617181920212223242526272829303132")
+	tracker.Expect(hashedSecret, xtz, d("1"), decimal.Zero)
+
+	require.NoError(t, tracker.Initiate(context.Background(), chain.InitiateArgs{
+		HashedSecret: hashedSecret,
+		Participant:  paperCounterparty,
+		Contract:     xtz.AtomexContract,
+		Amount:       d("10000000"),
+		RefundTime:   time.Now().Add(100 * time.Millisecond),
+	}, chain.ChainTypeTezos))
+
+	assert.Equal(t, chain.Pending, receiveOperation(t, tracker.Operations()).Status)
+	assert.Equal(t, chain.Applied, receiveOperation(t, tracker.Operations()).Status)
+	assert.Equal(t, tools.StatusInitiatedOnce, receiveSwap(t, tracker.StatusChanged()).Status)
+
+	// counterparty doesn't initiate, so the leg is refunded at refund time
+	assert.Equal(t, chain.Pending, receiveOperation(t, tracker.Operations()).Status)
+	assert.Equal(t, chain.Applied, receiveOperation(t, tracker.Operations()).Status)
+	refunded := receiveSwap(t, tracker.StatusChanged())
+	assert.Equal(t, tools.StatusRefunded, refunded.Status)
+	assert.Equal(t, tools.StatusRefunded, refunded.Initiator.Status)
+
+	balance, err := tracker.Balance(context.Background(), chain.ChainTypeTezos, chain.BalanceArgs{})
+	require.NoError(t, err)
+	assert.Equal(t, "99980000", balance.String())
+}
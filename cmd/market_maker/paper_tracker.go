@@ -0,0 +1,420 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/atomex-protocol/watch_tower/internal/atomex/signers"
+	"github.com/atomex-protocol/watch_tower/internal/chain"
+	"github.com/atomex-protocol/watch_tower/internal/chain/tools"
+	"github.com/atomex-protocol/watch_tower/internal/types"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+)
+
+// paperSwap - simulated swap. Amounts are in minimal units.
+type paperSwap struct {
+	swap tools.Swap
+
+	sent     assetKey
+	amount   decimal.Decimal
+	received types.Asset
+	payment  decimal.Decimal
+	reward   decimal.Decimal
+}
+
+// PaperTracker - simulates chains for paper mode. It keeps balances of simulated wallets, sends operations of market maker
+// with configured latency and failure probability and simulates counterparty's legs of swaps which are matched by `PaperExchange`.
+type PaperTracker struct {
+	*simulator
+
+	cfg     PaperConfig
+	log     zerolog.Logger
+	wallets map[chain.ChainType]chain.Wallet
+	natives map[chain.ChainType]types.Asset
+	fees    map[chain.ChainType]decimal.Decimal
+
+	mx          sync.Mutex
+	balances    map[assetKey]decimal.Decimal
+	swaps       map[chain.Hex]*paperSwap
+	operationID int64
+
+	operations    chan chain.Operation
+	statusChanged chan tools.Swap
+	restored      chan struct{}
+}
+
+// NewPaperTracker - creates simulated wallets for chains of `assets`. Chain's native asset is an asset without contract.
+func NewPaperTracker(cfg PaperConfig, assets map[string]types.Asset, log zerolog.Logger) (*PaperTracker, error) {
+	t := &PaperTracker{
+		simulator:     newSimulator(),
+		cfg:           cfg,
+		log:           log,
+		wallets:       make(map[chain.ChainType]chain.Wallet),
+		natives:       make(map[chain.ChainType]types.Asset),
+		fees:          make(map[chain.ChainType]decimal.Decimal),
+		balances:      make(map[assetKey]decimal.Decimal),
+		swaps:         make(map[chain.Hex]*paperSwap),
+		operations:    make(chan chain.Operation, 1024),
+		statusChanged: make(chan tools.Swap, 1024),
+		restored:      make(chan struct{}, 1),
+	}
+
+	for _, asset := range assets {
+		typ := asset.ChainType()
+		if asset.Contract == "" {
+			t.natives[typ] = asset
+		}
+		if _, ok := t.wallets[typ]; ok {
+			continue
+		}
+
+		key, err := signers.Ed25519Blake2b{}.Generate()
+		if err != nil {
+			return nil, errors.Wrap(err, "Generate")
+		}
+		t.wallets[typ] = chain.Wallet{
+			Address:   fmt.Sprintf("paper_%s", typ),
+			PublicKey: key.Public,
+			Private:   key.Private,
+		}
+	}
+
+	for name, balance := range cfg.Balances {
+		asset, ok := assets[name]
+		if !ok {
+			return nil, errors.Errorf("unknown asset in paper balances: %s", name)
+		}
+		t.balances[newAssetKey(asset)] = balance.Shift(int32(asset.Decimals))
+	}
+
+	for name, fee := range cfg.Fees {
+		typ := chain.ChainTypeFromString(name)
+		native, ok := t.natives[typ]
+		if !ok {
+			return nil, errors.Errorf("unknown chain in paper fees: %s", name)
+		}
+		t.fees[typ] = fee.Shift(int32(native.Decimals))
+	}
+
+	return t, nil
+}
+
+// StatusChanged -
+func (t *PaperTracker) StatusChanged() <-chan tools.Swap {
+	return t.statusChanged
+}
+
+// Operations -
+func (t *PaperTracker) Operations() <-chan chain.Operation {
+	return t.operations
+}
+
+// Restored -
+func (t *PaperTracker) Restored() <-chan struct{} {
+	return t.restored
+}
+
+// Start - nothing is restored in paper mode
+func (t *PaperTracker) Start(ctx context.Context) error {
+	t.restored <- struct{}{}
+	return nil
+}
+
+// Close -
+func (t *PaperTracker) Close() error {
+	t.close()
+	return nil
+}
+
+// Wallet -
+func (t *PaperTracker) Wallet(typ chain.ChainType) (chain.Wallet, error) {
+	wallet, ok := t.wallets[typ]
+	if !ok {
+		return chain.Wallet{}, errors.Wrap(tools.ErrUnknownChainType, typ.String())
+	}
+	return wallet, nil
+}
+
+// Balance - returns balance of simulated wallet in minimal units
+func (t *PaperTracker) Balance(ctx context.Context, typ chain.ChainType, args chain.BalanceArgs) (decimal.Decimal, error) {
+	if _, ok := t.wallets[typ]; !ok {
+		return decimal.Zero, errors.Wrapf(tools.ErrUnknownChainType, "Balance %v", typ)
+	}
+
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	return t.balances[assetKey{typ, args.Contract, args.TokenID}], nil
+}
+
+// Watch - counterparty's legs are known from `Expect`, so it's ignored
+func (t *PaperTracker) Watch(chainType chain.ChainType, args chain.WatchArgs) error {
+	return nil
+}
+
+// Expect - registers swap matched by exchange. Market maker will receive `amount` of `received` asset in its units
+// and will pay `reward` for redeem if its leg is redeemed by a third party.
+func (t *PaperTracker) Expect(hashedSecret chain.Hex, received types.Asset, amount, reward decimal.Decimal) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	t.swaps[hashedSecret] = &paperSwap{
+		swap: tools.Swap{
+			HashedSecret: hashedSecret,
+			Status:       tools.StatusEmpty,
+		},
+		received: received,
+		payment:  amountToInt(amount, received.Decimals),
+		reward:   amountToInt(reward, received.Decimals),
+	}
+}
+
+// Initiate - debits amount and fee from simulated wallet and initiates market maker's leg. Counterparty initiates its leg after that.
+// If counterparty doesn't initiate its leg, market maker's leg is refunded at refund time as the watch tower does.
+func (t *PaperTracker) Initiate(ctx context.Context, args chain.InitiateArgs, chainType chain.ChainType) error {
+	wallet, ok := t.wallets[chainType]
+	if !ok {
+		return errors.Wrapf(tools.ErrUnknownChainType, "Initiate %v", chainType)
+	}
+
+	key := assetKey{chainType, args.TokenAddress, args.TokenID}
+	fee := t.fees[chainType]
+
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	s, ok := t.swaps[args.HashedSecret]
+	if !ok {
+		return errors.Errorf("unknown swap: %s", args.HashedSecret)
+	}
+	if s.swap.Status != tools.StatusEmpty {
+		return errors.Errorf("swap is already initiated: %s", args.HashedSecret)
+	}
+	if err := t.debit(key, args.Amount, fee); err != nil {
+		return err
+	}
+	s.sent = key
+	s.amount = args.Amount
+	s.swap.RefundTime = args.RefundTime
+	s.swap.Status = tools.StatusInitiatedOnce
+	s.swap.Initiator = tools.Leg{
+		ChainType: chainType,
+		Address:   wallet.Address,
+		Contract:  args.Contract,
+	}
+
+	t.send(chainType, args.HashedSecret, fee, func(applied bool) {
+		if !applied {
+			t.balances[key] = t.balances[key].Add(args.Amount)
+			s.swap.Status = tools.StatusEmpty
+			return
+		}
+
+		s.swap.Initiator.Status = tools.StatusInitiated
+		t.notify(s)
+
+		t.after(t.cfg.Latency.Counterparty, func() {
+			t.mx.Lock()
+			defer t.mx.Unlock()
+
+			if t.fails(t.cfg.Failure.Counterparty) {
+				t.log.Warn().Str("hashed_secret", args.HashedSecret.String()).Msg("paper: counterparty doesn't initiate its leg")
+				t.scheduleRefund(s, chainType, args.RefundTime)
+				return
+			}
+
+			s.swap.Status = tools.StatusInitiated
+			s.swap.Acceptor = tools.Leg{
+				ChainType: s.received.ChainType(),
+				Address:   args.Participant,
+				Contract:  s.received.AtomexContract,
+				Status:    tools.StatusInitiated,
+			}
+			t.notify(s)
+		})
+	})
+	return nil
+}
+
+// Redeem - redeems counterparty's leg. If the operation fails, the leg is redeemed by a third party who receives reward for redeem.
+// Counterparty redeems market maker's leg after that.
+func (t *PaperTracker) Redeem(ctx context.Context, swap tools.Swap, leg tools.Leg) error {
+	if err := checkSecret(swap.HashedSecret, swap.Secret); err != nil {
+		return err
+	}
+	native, ok := t.natives[leg.ChainType]
+	if !ok {
+		return errors.Wrapf(tools.ErrUnknownChainType, "Redeem %v", leg.ChainType)
+	}
+	fee := t.fees[leg.ChainType]
+
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	s, ok := t.swaps[swap.HashedSecret]
+	if !ok || s.swap.Status != tools.StatusInitiated {
+		return errors.Errorf("swap isn't initiated: %s", swap.HashedSecret)
+	}
+	if err := t.debit(newAssetKey(native), decimal.Zero, fee); err != nil {
+		return err
+	}
+
+	t.send(leg.ChainType, swap.HashedSecret, fee, func(applied bool) {
+		received := newAssetKey(s.received)
+		if applied {
+			t.balances[received] = t.balances[received].Add(s.payment)
+		} else {
+			t.balances[received] = t.balances[received].Add(s.payment.Sub(s.reward))
+		}
+
+		s.swap.Secret = swap.Secret
+		s.swap.Status = tools.StatusRedeemedOnce
+		s.swap.Acceptor.Status = tools.StatusRedeemed
+		t.notify(s)
+
+		t.after(t.cfg.Latency.Counterparty, func() {
+			t.mx.Lock()
+			defer t.mx.Unlock()
+
+			s.swap.Status = tools.StatusRedeemed
+			s.swap.Initiator.Status = tools.StatusRedeemed
+			t.notify(s)
+			delete(t.swaps, s.swap.HashedSecret)
+		})
+	})
+	return nil
+}
+
+// Refund - refunds market maker's leg
+func (t *PaperTracker) Refund(ctx context.Context, swap tools.Swap, leg tools.Leg) error {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	s, ok := t.swaps[swap.HashedSecret]
+	if !ok {
+		return errors.Errorf("swap isn't initiated: %s", swap.HashedSecret)
+	}
+	return t.refund(s, leg.ChainType, nil)
+}
+
+// scheduleRefund - refunds market maker's leg of `s` at `refundTime`. Failed refund is retried.
+func (t *PaperTracker) scheduleRefund(s *paperSwap, typ chain.ChainType, refundTime time.Time) {
+	t.after(time.Until(refundTime).Milliseconds(), func() {
+		t.mx.Lock()
+		defer t.mx.Unlock()
+
+		if err := t.refund(s, typ, func() {
+			t.scheduleRefund(s, typ, time.Now())
+		}); err != nil {
+			t.log.Err(err).Str("hashed_secret", s.swap.HashedSecret.String()).Msg("paper: refund")
+		}
+	})
+}
+
+// refund - debits fee and refunds market maker's leg of `s`. `failed` is called if the operation fails. It's called under lock.
+func (t *PaperTracker) refund(s *paperSwap, typ chain.ChainType, failed func()) error {
+	native, ok := t.natives[typ]
+	if !ok {
+		return errors.Wrapf(tools.ErrUnknownChainType, "Refund %v", typ)
+	}
+	fee := t.fees[typ]
+
+	if s.swap.Initiator.Status != tools.StatusInitiated {
+		return errors.Errorf("swap isn't initiated: %s", s.swap.HashedSecret)
+	}
+	if err := t.debit(newAssetKey(native), decimal.Zero, fee); err != nil {
+		return err
+	}
+
+	t.send(typ, s.swap.HashedSecret, fee, func(applied bool) {
+		if !applied {
+			if failed != nil {
+				failed()
+			}
+			return
+		}
+		t.balances[s.sent] = t.balances[s.sent].Add(s.amount)
+
+		s.swap.Status = tools.StatusRefunded
+		s.swap.Initiator.Status = tools.StatusRefunded
+		t.notify(s)
+		delete(t.swaps, s.swap.HashedSecret)
+	})
+	return nil
+}
+
+// debit - debits `amount` of asset and `fee` of native currency. It's called under lock.
+func (t *PaperTracker) debit(key assetKey, amount, fee decimal.Decimal) error {
+	native := newAssetKey(t.natives[key.chain])
+	if key == native {
+		amount = amount.Add(fee)
+	} else if t.balances[native].LessThan(fee) {
+		return errors.Errorf("insufficient balance for fee: %s < %s", t.balances[native], fee)
+	}
+	if t.balances[key].LessThan(amount) {
+		return errors.Errorf("insufficient balance: %s < %s", t.balances[key], amount)
+	}
+
+	t.balances[key] = t.balances[key].Sub(amount)
+	if key != native {
+		t.balances[native] = t.balances[native].Sub(fee)
+	}
+	return nil
+}
+
+// send - emits pending operation and its result after latency. `result` is called under lock. Fee is paid anyway. It's called under lock.
+func (t *PaperTracker) send(typ chain.ChainType, hashedSecret chain.Hex, fee decimal.Decimal, result func(applied bool)) {
+	t.operationID++
+	operation := chain.Operation{
+		Hash:         fmt.Sprintf("paper_%d", t.operationID),
+		ChainType:    typ,
+		Status:       chain.Pending,
+		HashedSecret: hashedSecret,
+	}
+	t.emitOperation(operation)
+
+	t.after(t.cfg.Latency.Operation, func() {
+		t.mx.Lock()
+		defer t.mx.Unlock()
+
+		operation.Status = chain.Applied
+		if t.fails(t.cfg.Failure.Operation) {
+			operation.Status = chain.Failed
+		}
+		operation.Fee = fee
+		t.emitOperation(operation)
+
+		result(operation.Status == chain.Applied)
+	})
+}
+
+func (t *PaperTracker) emitOperation(operation chain.Operation) {
+	select {
+	case t.operations <- operation:
+	case <-t.stop:
+	}
+}
+
+func (t *PaperTracker) notify(s *paperSwap) {
+	select {
+	case t.statusChanged <- s.swap:
+	case <-t.stop:
+	}
+}
+
+func checkSecret(hashedSecret, secret chain.Hex) error {
+	data, err := secret.Bytes()
+	if err != nil {
+		return errors.Wrap(err, "secret")
+	}
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	if chain.NewHexFromBytes(second[:]) != hashedSecret {
+		return errors.Errorf("invalid secret of swap %s", hashedSecret)
+	}
+	return nil
+}
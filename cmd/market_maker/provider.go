@@ -1,15 +1,25 @@
 package main
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 func (mm *MarketMaker) listenProvider(ctx context.Context) {
 	defer mm.wg.Done()
 
+	// PnL is reported here, because tickers are updated by the same goroutine
+	report := time.NewTicker(mm.ledgerReportInterval)
+	defer report.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
 
+		case <-report.C:
+			mm.reportPnL()
+
 		case tick := <-mm.provider.Tickers():
 			mm.log.Debug().Str("ask", tick.Ask.String()).Str("bid", tick.Bid.String()).Str("symbol", tick.Symbol).Msg("quote provider's tick")
 
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/atomex-protocol/watch_tower/internal/atomex"
+	"github.com/atomex-protocol/watch_tower/internal/atomex/signers"
+	"github.com/atomex-protocol/watch_tower/internal/exchange/binance"
+	"github.com/atomex-protocol/watch_tower/internal/recorder"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// default values of recorder
+const (
+	defaultRecorderDir              = "records"
+	defaultRecorderRotationInterval = 3600
+	recorderFilePrefix              = "market"
+)
+
+// RecorderConfig - files are rotated each `Rotation.Interval` seconds or after `Rotation.Size` megabytes of uncompressed JSON
+type RecorderConfig struct {
+	Dir      string         `yaml:"dir"`
+	Rotation RotationConfig `yaml:"rotation"`
+	Binance  struct {
+		Tickers     bool               `yaml:"tickers"`
+		BookTickers bool               `yaml:"book_tickers"`
+		Klines      []binance.Interval `yaml:"klines"`
+	} `yaml:"binance"`
+	Atomex struct {
+		TopOfBook bool `yaml:"top_of_book"`
+		OrderBook bool `yaml:"order_book"`
+	} `yaml:"atomex"`
+}
+
+// RotationConfig -
+type RotationConfig struct {
+	Interval int64 `yaml:"interval" validate:"omitempty,gte=0"`
+	Size     int64 `yaml:"size" validate:"omitempty,gte=0"`
+}
+
+// MarketRecorder - records market data of the quote provider and Atomex for symbols of strategies
+type MarketRecorder struct {
+	cfg     RecorderConfig
+	keys    Keys
+	log     zerolog.Logger
+	writer  *recorder.Writer
+	binance *binance.Websocket
+	market  *atomex.Market
+	rest    *atomex.Rest
+
+	providerSymbols []string
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewMarketRecorder -
+func NewMarketRecorder(cfg Config, log zerolog.Logger) (*MarketRecorder, error) {
+	if cfg.QuoteProvider.Kind != QuoteProviderKindBinance {
+		return nil, errors.Errorf("unknown quote provider: %s", cfg.QuoteProvider.Kind)
+	}
+
+	logLevel, err := zerolog.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		return nil, errors.Wrap(err, "zerolog.ParseLevel")
+	}
+
+	if cfg.Recorder.Dir == "" {
+		cfg.Recorder.Dir = defaultRecorderDir
+	}
+	if cfg.Recorder.Rotation.Interval == 0 {
+		cfg.Recorder.Rotation.Interval = defaultRecorderRotationInterval
+	}
+	writer, err := recorder.NewWriter(
+		cfg.Recorder.Dir,
+		recorderFilePrefix,
+		time.Duration(cfg.Recorder.Rotation.Interval)*time.Second,
+		cfg.Recorder.Rotation.Size*1024*1024,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "recorder.NewWriter")
+	}
+
+	providerSymbols := make([]string, 0)
+	unique := make(map[string]struct{})
+	for _, s := range cfg.Strategies {
+		synth, ok := cfg.QuoteProviderMeta.FromSymbols[s.SymbolName]
+		if !ok {
+			continue
+		}
+		for _, symbol := range synth.Symbols {
+			if _, ok := unique[symbol]; !ok {
+				unique[symbol] = struct{}{}
+				providerSymbols = append(providerSymbols, symbol)
+			}
+		}
+	}
+
+	market, err := atomex.NewMarket(
+		atomex.WithLogLevel(logLevel),
+		atomex.WithSignature(signers.AlgorithmBlake2bWithEcdsaSecp256k1),
+		atomex.WithWebsocketURI(cfg.General.Atomex.WsAPI),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "atomex.NewMarket")
+	}
+
+	return &MarketRecorder{
+		cfg:    cfg.Recorder,
+		keys:   cfg.Keys,
+		log:    log,
+		writer: writer,
+		binance: binance.NewWebsocket(
+			binance.WithWebsocketURL(binance.BaseURLWebsocket),
+			binance.WithLogLevel(logLevel),
+		),
+		market: market,
+		rest: atomex.NewRest(
+			atomex.WithURL(cfg.General.Atomex.RestAPI),
+			atomex.WithSignatureAlgorithm(signers.AlgorithmEd25519Blake2b),
+		),
+		providerSymbols: providerSymbols,
+		stop:            make(chan struct{}, 2),
+	}, nil
+}
+
+// Start -
+func (r *MarketRecorder) Start(ctx context.Context) error {
+	if r.recordsBinance() {
+		if err := r.startBinance(); err != nil {
+			return err
+		}
+	}
+
+	if r.recordsAtomex() {
+		if err := r.startAtomex(ctx); err != nil {
+			return err
+		}
+	}
+
+	r.log.Info().Str("dir", r.cfg.Dir).Strs("symbols", r.providerSymbols).Msg("recording is started")
+	return nil
+}
+
+func (r *MarketRecorder) recordsBinance() bool {
+	return len(r.providerSymbols) > 0 && (r.cfg.Binance.Tickers || r.cfg.Binance.BookTickers || len(r.cfg.Binance.Klines) > 0)
+}
+
+func (r *MarketRecorder) recordsAtomex() bool {
+	return r.cfg.Atomex.TopOfBook || r.cfg.Atomex.OrderBook
+}
+
+func (r *MarketRecorder) startBinance() error {
+	if err := r.binance.Connect(); err != nil {
+		return errors.Wrap(err, "binance.Connect")
+	}
+
+	r.wg.Add(1)
+	go r.listenBinance()
+
+	if r.cfg.Binance.Tickers {
+		if err := r.binance.SubscribeOnTickers(r.providerSymbols...); err != nil {
+			return errors.Wrap(err, "SubscribeOnTickers")
+		}
+	}
+	if r.cfg.Binance.BookTickers {
+		if err := r.binance.SubscribeOnBookTickers(r.providerSymbols...); err != nil {
+			return errors.Wrap(err, "SubscribeOnBookTickers")
+		}
+	}
+	for _, interval := range r.cfg.Binance.Klines {
+		for _, symbol := range r.providerSymbols {
+			if err := r.binance.SubscribeOnKLine(interval, symbol); err != nil {
+				return errors.Wrap(err, "SubscribeOnKLine")
+			}
+		}
+	}
+	return nil
+}
+
+func (r *MarketRecorder) startAtomex(ctx context.Context) error {
+	loadedKeys, err := loadKeys(r.keys)
+	if err != nil {
+		return err
+	}
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	if err := r.rest.Auth(ctxTimeout, loadedKeys); err != nil {
+		return errors.Wrap(err, "Token")
+	}
+
+	if err := r.market.Connect(atomex.TokenResponse{
+		Token: r.rest.GetToken(),
+	}); err != nil {
+		return errors.Wrap(err, "atomex.Connect")
+	}
+
+	r.wg.Add(1)
+	go r.listenAtomex()
+
+	if r.cfg.Atomex.TopOfBook {
+		if err := r.market.SubscribeToTopOfBook(); err != nil {
+			return errors.Wrap(err, "SubscribeToTopOfBook")
+		}
+	}
+	if r.cfg.Atomex.OrderBook {
+		if err := r.market.SubscribeToOrderBook(); err != nil {
+			return errors.Wrap(err, "SubscribeToOrderBook")
+		}
+	}
+	return nil
+}
+
+func (r *MarketRecorder) listenBinance() {
+	defer r.wg.Done()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case event, ok := <-r.binance.Listen():
+			if !ok {
+				return
+			}
+			if err := r.writer.Binance(event); err != nil {
+				r.log.Err(err).Msg("write Binance event")
+			}
+		}
+	}
+}
+
+func (r *MarketRecorder) listenAtomex() {
+	defer r.wg.Done()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case msg, ok := <-r.market.Listen():
+			if !ok {
+				return
+			}
+			if err := r.writer.Atomex(msg); err != nil {
+				r.log.Err(err).Msg("write Atomex message")
+			}
+		case err, ok := <-r.market.Errors():
+			if !ok {
+				return
+			}
+			r.log.Err(err).Msg("atomex market data")
+		}
+	}
+}
+
+// Close - stops recording and flushes the current file
+func (r *MarketRecorder) Close() error {
+	r.stop <- struct{}{}
+	r.stop <- struct{}{}
+	r.wg.Wait()
+
+	if r.recordsBinance() {
+		if err := r.binance.Close(); err != nil {
+			return errors.Wrap(err, "binance.Close")
+		}
+	}
+	if r.recordsAtomex() {
+		if err := r.market.Close(); err != nil {
+			return errors.Wrap(err, "atomex.Close")
+		}
+	}
+	close(r.stop)
+
+	return r.writer.Close()
+}
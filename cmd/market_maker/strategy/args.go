@@ -1,6 +1,10 @@
 package strategy
 
-import "github.com/shopspring/decimal"
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 // Args -
 type Args struct {
@@ -10,7 +14,11 @@ type Args struct {
 	bidVolume decimal.Decimal
 	close     decimal.Decimal
 
+	baseBalance  decimal.Decimal
+	quoteBalance decimal.Decimal
+
 	symbol string
+	time   time.Time
 }
 
 // NewArgs -
@@ -48,8 +56,26 @@ func (a *Args) Close(value decimal.Decimal) *Args {
 	return a
 }
 
+// BaseBalance - market maker's balance of base asset
+func (a *Args) BaseBalance(value decimal.Decimal) *Args {
+	a.baseBalance = value
+	return a
+}
+
+// QuoteBalance - market maker's balance of quote asset
+func (a *Args) QuoteBalance(value decimal.Decimal) *Args {
+	a.quoteBalance = value
+	return a
+}
+
 // Symbol -
 func (a *Args) Symbol(symbol string) *Args {
 	a.symbol = symbol
 	return a
 }
+
+// Time - time of ticker. Current time is used if it's not set, e.g. in live trading.
+func (a *Args) Time(value time.Time) *Args {
+	a.time = value
+	return a
+}
@@ -0,0 +1,187 @@
+package strategy
+
+import (
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// AvellanedaStoikov - quotes around reservation price r = s - q * γ * σ² * τ with optimal spread δ = γ * σ² * τ + 2/γ * ln(1 + γ/k), where
+// s is provider's mid price, q is deviation of base asset inventory from target ratio, γ is risk aversion, σ² is price variance per second,
+// τ is horizon in seconds and k is order arrival intensity.
+type AvellanedaStoikov struct {
+	mids  []float64
+	times []time.Time
+	now   func() time.Time
+
+	riskAversion float64
+	intensity    float64
+	horizon      float64
+	targetRatio  decimal.Decimal
+
+	spread Spread
+	volume decimal.Decimal
+	window int
+	symbol string
+}
+
+// NewAvellanedaStoikov -
+func NewAvellanedaStoikov(cfg Config) (*AvellanedaStoikov, error) {
+	if cfg.Window < 2 {
+		return nil, errors.Wrapf(ErrInvalidArg, "window=%d", cfg.Window)
+	}
+	if !cfg.RiskAversion.IsPositive() {
+		return nil, errors.Wrapf(ErrInvalidArg, "risk_aversion=%v", cfg.RiskAversion)
+	}
+	if !cfg.Intensity.IsPositive() {
+		return nil, errors.Wrapf(ErrInvalidArg, "intensity=%v", cfg.Intensity)
+	}
+	if cfg.Horizon <= 0 {
+		return nil, errors.Wrapf(ErrInvalidArg, "horizon=%d", cfg.Horizon)
+	}
+	targetRatio := cfg.TargetRatio
+	if targetRatio.IsZero() {
+		targetRatio = defaultTargetRatio
+	}
+	if !targetRatio.IsPositive() || targetRatio.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+		return nil, errors.Wrapf(ErrInvalidArg, "target_ratio=%v", targetRatio)
+	}
+
+	riskAversion, _ := cfg.RiskAversion.Float64()
+	intensity, _ := cfg.Intensity.Float64()
+
+	return &AvellanedaStoikov{
+		mids:         make([]float64, 0, cfg.Window),
+		times:        make([]time.Time, 0, cfg.Window),
+		now:          time.Now,
+		riskAversion: riskAversion,
+		intensity:    intensity,
+		horizon:      float64(cfg.Horizon),
+		targetRatio:  targetRatio,
+		spread:       cfg.Spread,
+		volume:       cfg.Volume,
+		window:       cfg.Window,
+		symbol:       cfg.SymbolName,
+	}, nil
+}
+
+// Quotes -
+func (s *AvellanedaStoikov) Quotes(args *Args) ([]Quote, error) {
+	if args == nil {
+		return nil, errors.Wrapf(ErrInvalidArg, "nil")
+	}
+	if args.symbol != s.symbol {
+		return nil, nil
+	}
+	if !args.bid.IsPositive() {
+		return nil, errors.Wrapf(ErrInvalidArg, "bid=%v", args.bid)
+	}
+	if !args.ask.IsPositive() {
+		return nil, errors.Wrapf(ErrInvalidArg, "ask=%v", args.ask)
+	}
+
+	mid := decimal.Avg(args.ask, args.bid)
+	s.add(mid, args.time)
+
+	variance, ok := s.variance()
+	if !ok {
+		return []Quote{}, nil
+	}
+
+	midValue, _ := mid.Float64()
+	q, _ := s.inventory(args, mid).Float64()
+
+	risk := s.riskAversion * variance * s.horizon
+	reservation := midValue - q*risk
+	halfSpread := (risk + 2/s.riskAversion*math.Log(1+s.riskAversion/s.intensity)) / 2
+
+	one := decimal.NewFromInt(1)
+	// quotes can't be better than provider's prices with minimal spread
+	ask := decimal.Max(decimal.NewFromFloat(reservation+halfSpread), args.ask.Mul(one.Add(s.spread.Ask)))
+	bid := decimal.Min(decimal.NewFromFloat(reservation-halfSpread), args.bid.Mul(one.Sub(s.spread.Bid)))
+
+	quotes := make([]Quote, 0, 2)
+	if bid.IsPositive() {
+		quotes = append(quotes, Quote{
+			Side:     Bid,
+			Price:    bid,
+			Volume:   s.volume,
+			Symbol:   s.symbol,
+			Strategy: KindAvellanedaStoikov,
+		})
+	}
+	quotes = append(quotes, Quote{
+		Side:     Ask,
+		Price:    ask,
+		Volume:   s.volume,
+		Symbol:   s.symbol,
+		Strategy: KindAvellanedaStoikov,
+	})
+	return quotes, nil
+}
+
+// Warm - adds historical mid prices of the symbol to rolling window
+func (s *AvellanedaStoikov) Warm(symbol string, prices []HistoricalPrice) {
+	if symbol != s.symbol {
+		return
+	}
+	for i := range prices {
+		if !prices[i].Ask.IsPositive() || !prices[i].Bid.IsPositive() || prices[i].Time.IsZero() {
+			continue
+		}
+		s.add(decimal.Avg(prices[i].Ask, prices[i].Bid), prices[i].Time)
+	}
+}
+
+// add - adds sample of mid price. Quotes may be requested again with the same ticker, so the sample is skipped if neither time nor price is changed.
+func (s *AvellanedaStoikov) add(mid decimal.Decimal, ts time.Time) {
+	value, _ := mid.Float64()
+	if last := len(s.mids) - 1; last >= 0 && s.mids[last] == value && (ts.IsZero() || ts.Equal(s.times[last])) {
+		return
+	}
+	if ts.IsZero() {
+		ts = s.now()
+	}
+	if len(s.mids) == s.window {
+		s.mids = s.mids[1:]
+		s.times = s.times[1:]
+	}
+	s.mids = append(s.mids, value)
+	s.times = append(s.times, ts)
+}
+
+// variance - returns variance of mid price per second: sum of squared changes of successive mid prices divided by sum of their intervals
+func (s *AvellanedaStoikov) variance() (float64, bool) {
+	if len(s.mids) < s.window {
+		return 0, false
+	}
+	var squares float64
+	for i := 1; i < len(s.mids); i++ {
+		change := s.mids[i] - s.mids[i-1]
+		squares += change * change
+	}
+	duration := s.times[len(s.times)-1].Sub(s.times[0]).Seconds()
+	if duration <= 0 {
+		return 0, false
+	}
+	return squares / duration, true
+}
+
+// inventory - returns deviation of base asset balance from the balance of target ratio in base asset units
+func (s *AvellanedaStoikov) inventory(args *Args, mid decimal.Decimal) decimal.Decimal {
+	total := args.baseBalance.Mul(mid).Add(args.quoteBalance)
+	target := total.Mul(s.targetRatio).Div(mid)
+	return args.baseBalance.Sub(target)
+}
+
+// Is -
+func (s *AvellanedaStoikov) Is(kind Kind) bool {
+	return KindAvellanedaStoikov == kind
+}
+
+// Kind -
+func (s *AvellanedaStoikov) Kind() Kind {
+	return KindAvellanedaStoikov
+}
@@ -0,0 +1,96 @@
+package strategy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAvellanedaStoikov_Quotes(t *testing.T) {
+	tests := []struct {
+		name         string
+		spread       string
+		baseBalance  string
+		quoteBalance string
+		wantBid      float64
+		wantAsk      float64
+	}{
+		{
+			name:         "balanced inventory",
+			spread:       "0",
+			baseBalance:  "1",
+			quoteBalance: "1.01",
+			wantBid:      0.9890005,
+			wantAsk:      1.0309995,
+		}, {
+			name:         "excess of base asset",
+			spread:       "0",
+			baseBalance:  "1.5",
+			quoteBalance: "0.505",
+			wantBid:      0.9690005,
+			wantAsk:      1.0109995,
+		}, {
+			name:         "minimal spread",
+			spread:       "0.03",
+			baseBalance:  "1",
+			quoteBalance: "1.01",
+			wantBid:      0.9797,
+			wantAsk:      1.0403,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spread := decimal.RequireFromString(tt.spread)
+			s, err := NewAvellanedaStoikov(Config{
+				SymbolName:   "XTZ_ETH",
+				Kind:         KindAvellanedaStoikov,
+				Spread:       Spread{Ask: spread, Bid: spread},
+				Volume:       decimal.NewFromInt(10),
+				Window:       2,
+				RiskAversion: decimal.NewFromInt(1),
+				Intensity:    decimal.NewFromInt(1000),
+				Horizon:      100,
+			})
+			require.NoError(t, err)
+
+			ts := time.Unix(1650000000, 0)
+			s.now = func() time.Time {
+				ts = ts.Add(time.Second)
+				return ts
+			}
+
+			args := NewArgs().Symbol("XTZ_ETH").
+				BaseBalance(decimal.RequireFromString(tt.baseBalance)).
+				QuoteBalance(decimal.RequireFromString(tt.quoteBalance))
+
+			got, err := s.Quotes(args.Ask(decimal.RequireFromString("0.99")).Bid(decimal.RequireFromString("0.99")))
+			require.NoError(t, err)
+			require.Empty(t, got)
+
+			got, err = s.Quotes(args.Ask(decimal.RequireFromString("1.01")).Bid(decimal.RequireFromString("1.01")))
+			require.NoError(t, err)
+			require.Len(t, got, 2)
+
+			assert.Equal(t, Bid, got[0].Side)
+			bid, _ := got[0].Price.Float64()
+			assert.InDelta(t, tt.wantBid, bid, 1e-7)
+
+			assert.Equal(t, Ask, got[1].Side)
+			ask, _ := got[1].Price.Float64()
+			assert.InDelta(t, tt.wantAsk, ask, 1e-7)
+
+			for i := range got {
+				assert.Equal(t, "10", got[i].Volume.String())
+				assert.Equal(t, KindAvellanedaStoikov, string(got[i].Strategy))
+			}
+
+			// the same ticker doesn't add a sample
+			again, err := s.Quotes(args)
+			require.NoError(t, err)
+			assert.Equal(t, got, again)
+		})
+	}
+}
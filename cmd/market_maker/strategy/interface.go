@@ -1,6 +1,8 @@
 package strategy
 
 import (
+	"time"
+
 	"github.com/pkg/errors"
 	"github.com/shopspring/decimal"
 )
@@ -11,8 +13,40 @@ type Strategy interface {
 	Is(kind Kind) bool
 }
 
+// Warmer - strategy with indicators which may be seeded by historical prices of its symbol before quoting
+type Warmer interface {
+	Warm(symbol string, prices []HistoricalPrice)
+}
+
+// IsWarmer - returns true if the strategy or the strategy wrapped by ladder is `Warmer`
+func IsWarmer(strategy Strategy) bool {
+	if ladder, ok := strategy.(*Ladder); ok {
+		strategy = ladder.strategy
+	}
+	_, ok := strategy.(Warmer)
+	return ok
+}
+
+// HistoricalPrice - historical prices of symbol, e.g. closes of candles
+type HistoricalPrice struct {
+	Time time.Time
+	Ask  decimal.Decimal
+	Bid  decimal.Decimal
+}
+
 // New -
 func New(cfg Config) (Strategy, error) {
+	strategy, err := newStrategy(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Levels.Count > 1 {
+		return NewLadder(strategy, cfg.Levels)
+	}
+	return strategy, nil
+}
+
+func newStrategy(cfg Config) (Strategy, error) {
 	switch cfg.Kind {
 	case KindFollow:
 		return NewFollow(cfg), nil
@@ -23,6 +57,10 @@ func New(cfg Config) (Strategy, error) {
 			return nil, errors.Wrapf(ErrInvalidArg, "window=%d", cfg.Window)
 		}
 		return NewVolatility(cfg), nil
+	case KindInventory:
+		return NewInventory(cfg)
+	case KindAvellanedaStoikov:
+		return NewAvellanedaStoikov(cfg)
 	default:
 		return nil, errors.Wrap(ErrUnknownStrategy, string(cfg.Kind))
 	}
@@ -36,7 +74,10 @@ const (
 	KindFollow     = "follow"
 	KindOneByOne   = "one-by-one"
 	KindVolatility = "volatility"
+	KindInventory  = "inventory"
 	KindUnknown    = "unknown"
+
+	KindAvellanedaStoikov = "avellaneda-stoikov"
 )
 
 // Spread -
@@ -69,6 +110,7 @@ type Quote struct {
 	Price    decimal.Decimal
 	Volume   decimal.Decimal
 	Strategy Kind
+	Level    int
 }
 
 // Side -
@@ -91,5 +133,14 @@ type Config struct {
 		Min decimal.Decimal `yaml:"min"`
 		Max decimal.Decimal `yaml:"max"`
 	} `yaml:"dist"`
-	Width decimal.Decimal `yaml:"width"`
+	Width       decimal.Decimal `yaml:"width"`
+	TargetRatio decimal.Decimal `yaml:"target_ratio"`
+	MaxSkew     decimal.Decimal `yaml:"max_skew"`
+	MinVolume   decimal.Decimal `yaml:"min_volume"`
+
+	RiskAversion decimal.Decimal `yaml:"risk_aversion"`
+	Intensity    decimal.Decimal `yaml:"intensity"`
+	Horizon      int64           `yaml:"horizon"`
+
+	Levels Levels `yaml:"levels"`
 }
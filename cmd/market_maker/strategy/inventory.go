@@ -0,0 +1,116 @@
+package strategy
+
+import (
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// default target share of base asset value in inventory
+var defaultTargetRatio = decimal.RequireFromString("0.5")
+
+// Inventory - quotes around provider's prices which are shifted against current position. If base asset share exceeds target ratio, prices go down and ask volume grows, so the position returns to the target.
+type Inventory struct {
+	spread      Spread
+	volume      decimal.Decimal
+	minVolume   decimal.Decimal
+	targetRatio decimal.Decimal
+	maxSkew     decimal.Decimal
+	symbol      string
+}
+
+// NewInventory -
+func NewInventory(cfg Config) (*Inventory, error) {
+	targetRatio := cfg.TargetRatio
+	if targetRatio.IsZero() {
+		targetRatio = defaultTargetRatio
+	}
+	if !targetRatio.IsPositive() || targetRatio.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+		return nil, errors.Wrapf(ErrInvalidArg, "target_ratio=%v", targetRatio)
+	}
+	if cfg.MaxSkew.IsNegative() || cfg.MaxSkew.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+		return nil, errors.Wrapf(ErrInvalidArg, "max_skew=%v", cfg.MaxSkew)
+	}
+	if !cfg.Volume.IsPositive() {
+		return nil, errors.Wrapf(ErrInvalidArg, "volume=%v", cfg.Volume)
+	}
+
+	return &Inventory{
+		spread:      cfg.Spread,
+		volume:      cfg.Volume,
+		minVolume:   cfg.MinVolume,
+		targetRatio: targetRatio,
+		maxSkew:     cfg.MaxSkew,
+		symbol:      cfg.SymbolName,
+	}, nil
+}
+
+// Quotes -
+func (s *Inventory) Quotes(args *Args) ([]Quote, error) {
+	if args == nil {
+		return nil, errors.Wrapf(ErrInvalidArg, "nil")
+	}
+	if args.symbol != s.symbol {
+		return nil, nil
+	}
+	if !args.bid.IsPositive() {
+		return nil, errors.Wrapf(ErrInvalidArg, "bid=%v", args.bid)
+	}
+	if !args.ask.IsPositive() {
+		return nil, errors.Wrapf(ErrInvalidArg, "ask=%v", args.ask)
+	}
+
+	deviation, ok := s.deviation(args)
+	if !ok {
+		return []Quote{}, nil
+	}
+
+	one := decimal.NewFromInt(1)
+	shift := one.Sub(deviation.Mul(s.maxSkew))
+
+	quotes := make([]Quote, 0, 2)
+	if volume := s.volume.Mul(one.Sub(deviation)); volume.IsPositive() && volume.GreaterThanOrEqual(s.minVolume) {
+		quotes = append(quotes, Quote{
+			Side:     Bid,
+			Price:    args.bid.Mul(shift).Mul(one.Sub(s.spread.Bid)),
+			Volume:   volume,
+			Symbol:   s.symbol,
+			Strategy: KindInventory,
+		})
+	}
+	if volume := s.volume.Mul(one.Add(deviation)); volume.IsPositive() && volume.GreaterThanOrEqual(s.minVolume) {
+		quotes = append(quotes, Quote{
+			Side:     Ask,
+			Price:    args.ask.Mul(shift).Mul(one.Add(s.spread.Ask)),
+			Volume:   volume,
+			Symbol:   s.symbol,
+			Strategy: KindInventory,
+		})
+	}
+	return quotes, nil
+}
+
+// deviation - returns deviation of base asset share from target ratio normalized to [-1, 1]. Positive value means excess of base asset. It returns false if inventory is empty.
+func (s *Inventory) deviation(args *Args) (decimal.Decimal, bool) {
+	mid := decimal.Avg(args.ask, args.bid)
+	baseValue := args.baseBalance.Mul(mid)
+	total := baseValue.Add(args.quoteBalance)
+	if !total.IsPositive() {
+		return decimal.Zero, false
+	}
+
+	ratio := baseValue.Div(total)
+	if ratio.GreaterThan(s.targetRatio) {
+		return ratio.Sub(s.targetRatio).Div(decimal.NewFromInt(1).Sub(s.targetRatio)), true
+	}
+	return ratio.Sub(s.targetRatio).Div(s.targetRatio), true
+}
+
+// Is -
+func (s *Inventory) Is(kind Kind) bool {
+	return KindInventory == kind
+}
+
+// Kind -
+func (s *Inventory) Kind() Kind {
+	return KindInventory
+}
@@ -0,0 +1,85 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInventory_Quotes(t *testing.T) {
+	cfg := Config{
+		SymbolName: "XTZ_ETH",
+		Kind:       KindInventory,
+		Spread:     Spread{Ask: decimal.RequireFromString("0.01"), Bid: decimal.RequireFromString("0.01")},
+		Volume:     decimal.RequireFromString("10"),
+		MaxSkew:    decimal.RequireFromString("0.02"),
+		MinVolume:  decimal.RequireFromString("1"),
+	}
+
+	tests := []struct {
+		name         string
+		baseBalance  string
+		quoteBalance string
+		want         []Quote
+	}{
+		{
+			name:         "balanced inventory",
+			baseBalance:  "100",
+			quoteBalance: "100",
+			want: []Quote{
+				{Side: Bid, Price: decimal.RequireFromString("0.99"), Volume: decimal.RequireFromString("10")},
+				{Side: Ask, Price: decimal.RequireFromString("1.01"), Volume: decimal.RequireFromString("10")},
+			},
+		}, {
+			name:         "excess of base asset",
+			baseBalance:  "150",
+			quoteBalance: "50",
+			want: []Quote{
+				{Side: Bid, Price: decimal.RequireFromString("0.9801"), Volume: decimal.RequireFromString("5")},
+				{Side: Ask, Price: decimal.RequireFromString("0.9999"), Volume: decimal.RequireFromString("15")},
+			},
+		}, {
+			name:         "only quote asset",
+			baseBalance:  "0",
+			quoteBalance: "100",
+			want: []Quote{
+				{Side: Bid, Price: decimal.RequireFromString("1.0098"), Volume: decimal.RequireFromString("20")},
+			},
+		}, {
+			name:         "empty inventory",
+			baseBalance:  "0",
+			quoteBalance: "0",
+			want:         []Quote{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewInventory(cfg)
+			require.NoError(t, err)
+
+			args := NewArgs().Ask(decimal.NewFromInt(1)).Bid(decimal.NewFromInt(1)).Symbol("XTZ_ETH").
+				BaseBalance(decimal.RequireFromString(tt.baseBalance)).
+				QuoteBalance(decimal.RequireFromString(tt.quoteBalance))
+
+			got, err := s.Quotes(args)
+			require.NoError(t, err)
+			require.Len(t, got, len(tt.want))
+			for i := range tt.want {
+				assert.Equal(t, tt.want[i].Side, got[i].Side)
+				assert.Equal(t, tt.want[i].Price.String(), got[i].Price.String())
+				assert.Equal(t, tt.want[i].Volume.String(), got[i].Volume.String())
+				assert.Equal(t, KindInventory, string(got[i].Strategy))
+			}
+		})
+	}
+}
+
+func TestNewInventory(t *testing.T) {
+	_, err := NewInventory(Config{Volume: decimal.NewFromInt(1), TargetRatio: decimal.NewFromInt(1)})
+	assert.ErrorIs(t, err, ErrInvalidArg)
+
+	_, err = NewInventory(Config{Volume: decimal.Zero})
+	assert.ErrorIs(t, err, ErrInvalidArg)
+}
@@ -0,0 +1,90 @@
+package strategy
+
+import (
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// MaxLevels - maximum count of ladder levels per side
+const MaxLevels = 100
+
+// Levels - ladder config. `Step` is relative price distance between neighbouring levels, `VolumeFactor` is a multiplier of volume of each next level (1 by default).
+type Levels struct {
+	Count        int             `yaml:"count"`
+	Step         decimal.Decimal `yaml:"step"`
+	VolumeFactor decimal.Decimal `yaml:"volume_factor"`
+}
+
+// Ladder - expands each quote of the wrapped strategy into `count` levels with geometric spacing of prices and volumes. Level 0 is the quote of the wrapped strategy.
+type Ladder struct {
+	strategy     Strategy
+	count        int
+	step         decimal.Decimal
+	volumeFactor decimal.Decimal
+}
+
+// NewLadder -
+func NewLadder(strategy Strategy, cfg Levels) (*Ladder, error) {
+	if cfg.Count < 1 || cfg.Count > MaxLevels {
+		return nil, errors.Wrapf(ErrInvalidArg, "levels.count=%d", cfg.Count)
+	}
+	if !cfg.Step.IsPositive() || cfg.Step.GreaterThanOrEqual(decimal.NewFromInt(1)) {
+		return nil, errors.Wrapf(ErrInvalidArg, "levels.step=%v", cfg.Step)
+	}
+	volumeFactor := cfg.VolumeFactor
+	if volumeFactor.IsZero() {
+		volumeFactor = decimal.NewFromInt(1)
+	}
+	if !volumeFactor.IsPositive() {
+		return nil, errors.Wrapf(ErrInvalidArg, "levels.volume_factor=%v", volumeFactor)
+	}
+
+	return &Ladder{
+		strategy:     strategy,
+		count:        cfg.Count,
+		step:         cfg.Step,
+		volumeFactor: volumeFactor,
+	}, nil
+}
+
+// Warm - warms the wrapped strategy if it has indicators
+func (s *Ladder) Warm(symbol string, prices []HistoricalPrice) {
+	if warmer, ok := s.strategy.(Warmer); ok {
+		warmer.Warm(symbol, prices)
+	}
+}
+
+// Quotes -
+func (s *Ladder) Quotes(args *Args) ([]Quote, error) {
+	quotes, err := s.strategy.Quotes(args)
+	if err != nil || len(quotes) == 0 {
+		return quotes, err
+	}
+
+	one := decimal.NewFromInt(1)
+	askMultiplier := one.Add(s.step)
+	bidMultiplier := one.Sub(s.step)
+
+	result := make([]Quote, 0, len(quotes)*s.count)
+	for _, quote := range quotes {
+		multiplier := askMultiplier
+		if quote.Side == Bid {
+			multiplier = bidMultiplier
+		}
+
+		level := quote
+		for i := 0; i < s.count; i++ {
+			level.Level = i
+			result = append(result, level)
+
+			level.Price = level.Price.Mul(multiplier)
+			level.Volume = level.Volume.Mul(s.volumeFactor)
+		}
+	}
+	return result, nil
+}
+
+// Is -
+func (s *Ladder) Is(kind Kind) bool {
+	return s.strategy.Is(kind)
+}
@@ -0,0 +1,100 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsWarmer(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want bool
+	}{
+		{
+			name: "follow",
+			cfg:  Config{Kind: KindFollow},
+			want: false,
+		}, {
+			name: "ladder of follow",
+			cfg:  Config{Kind: KindFollow, Levels: Levels{Count: 2, Step: decimal.RequireFromString("0.1")}},
+			want: false,
+		}, {
+			name: "volatility",
+			cfg:  Config{Kind: KindVolatility, Window: 10},
+			want: true,
+		}, {
+			name: "ladder of volatility",
+			cfg:  Config{Kind: KindVolatility, Window: 10, Levels: Levels{Count: 2, Step: decimal.RequireFromString("0.1")}},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.cfg)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, IsWarmer(s))
+		})
+	}
+}
+
+func TestLadder_Quotes(t *testing.T) {
+	s, err := New(Config{
+		SymbolName: "XTZ_ETH",
+		Kind:       KindFollow,
+		Levels: Levels{
+			Count:        3,
+			Step:         decimal.RequireFromString("0.1"),
+			VolumeFactor: decimal.RequireFromString("2"),
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, s.Is(KindFollow))
+
+	got, err := s.Quotes(NewArgs().Ask(decimal.NewFromInt(10)).Bid(decimal.NewFromInt(10)).Symbol("XTZ_ETH"))
+	require.NoError(t, err)
+
+	want := []Quote{
+		{Side: Bid, Level: 0, Price: decimal.RequireFromString("10")},
+		{Side: Bid, Level: 1, Price: decimal.RequireFromString("9")},
+		{Side: Bid, Level: 2, Price: decimal.RequireFromString("8.1")},
+		{Side: Ask, Level: 0, Price: decimal.RequireFromString("10")},
+		{Side: Ask, Level: 1, Price: decimal.RequireFromString("11")},
+		{Side: Ask, Level: 2, Price: decimal.RequireFromString("12.1")},
+	}
+	require.Len(t, got, len(want))
+	for i := range want {
+		assert.Equal(t, want[i].Side, got[i].Side)
+		assert.Equal(t, want[i].Level, got[i].Level)
+		assert.Equal(t, want[i].Price.String(), got[i].Price.String())
+		assert.Equal(t, KindFollow, string(got[i].Strategy))
+	}
+}
+
+func TestLadder_Volumes(t *testing.T) {
+	inner, err := NewInventory(Config{SymbolName: "XTZ_ETH", Volume: decimal.NewFromInt(1)})
+	require.NoError(t, err)
+
+	s, err := NewLadder(inner, Levels{Count: 3, Step: decimal.RequireFromString("0.01"), VolumeFactor: decimal.RequireFromString("1.5")})
+	require.NoError(t, err)
+
+	got, err := s.Quotes(NewArgs().Ask(decimal.NewFromInt(1)).Bid(decimal.NewFromInt(1)).Symbol("XTZ_ETH").
+		BaseBalance(decimal.NewFromInt(1)).QuoteBalance(decimal.NewFromInt(1)))
+	require.NoError(t, err)
+	require.Len(t, got, 6)
+
+	for i, want := range []string{"1", "1.5", "2.25", "1", "1.5", "2.25"} {
+		assert.Equal(t, want, got[i].Volume.String())
+	}
+}
+
+func TestNewLadder(t *testing.T) {
+	_, err := NewLadder(NewFollow(Config{}), Levels{Count: MaxLevels + 1, Step: decimal.RequireFromString("0.01")})
+	assert.ErrorIs(t, err, ErrInvalidArg)
+
+	_, err = NewLadder(NewFollow(Config{}), Levels{Count: 2})
+	assert.ErrorIs(t, err, ErrInvalidArg)
+}
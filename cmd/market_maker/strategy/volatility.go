@@ -90,6 +90,20 @@ func (s *Volatility) Quotes(args *Args) ([]Quote, error) {
 	}, nil
 }
 
+// Warm - adds historical prices of the symbol to rolling windows
+func (s *Volatility) Warm(symbol string, prices []HistoricalPrice) {
+	if symbol != s.symbol {
+		return
+	}
+	for i := range prices {
+		if !prices[i].Ask.IsPositive() || !prices[i].Bid.IsPositive() {
+			continue
+		}
+		s.askStd.Add(prices[i].Ask)
+		s.bidStd.Add(prices[i].Bid)
+	}
+}
+
 // Is -
 func (s *Volatility) Is(kind Kind) bool {
 	return KindVolatility == kind
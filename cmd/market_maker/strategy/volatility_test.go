@@ -120,3 +120,25 @@ func TestVolatility_Quotes(t *testing.T) {
 		})
 	}
 }
+
+func TestVolatility_Warm(t *testing.T) {
+	s := NewVolatility(Config{
+		SymbolName: "XTZ_ETH",
+		Kind:       KindVolatility,
+		Window:     3,
+		Volume:     decimal.NewFromInt(1),
+		Width:      decimal.NewFromInt(1),
+	})
+
+	prices := []HistoricalPrice{
+		{Ask: decimal.RequireFromString("2"), Bid: decimal.RequireFromString("1")},
+		{Ask: decimal.RequireFromString("3"), Bid: decimal.RequireFromString("2")},
+	}
+	s.Warm("ETH_USDT", prices)
+	assert.False(t, s.askStd.Full())
+
+	s.Warm("XTZ_ETH", prices)
+	quotes, err := s.Quotes(NewArgs().Symbol("XTZ_ETH").Ask(decimal.NewFromInt(3)).Bid(decimal.NewFromInt(2)))
+	assert.NoError(t, err)
+	assert.Len(t, quotes, 2)
+}
@@ -3,6 +3,7 @@ package synthetic
 import (
 	"github.com/atomex-protocol/watch_tower/internal/exchange"
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 )
 
 // Direct -
@@ -38,3 +39,15 @@ func (d *Direct) Ticker(tick exchange.Ticker, tickers map[string]exchange.Ticker
 		BidVolume: tick.BidVolume,
 	}, nil
 }
+
+// Hedge - returns market order of `qty` with `side` on the symbol
+func (d *Direct) Hedge(side exchange.OrderSide, qty, price decimal.Decimal) []exchange.OrderRequest {
+	return []exchange.OrderRequest{
+		{
+			Symbol: d.symbol,
+			Side:   side,
+			Type:   exchange.OrderTypeMarket,
+			Qty:    qty,
+		},
+	}
+}
@@ -65,3 +65,20 @@ func (d *Divided) Ticker(tick exchange.Ticker, tickers map[string]exchange.Ticke
 
 	return ticker, nil
 }
+
+// Hedge - returns market orders of both legs: `qty` of base asset with `side` on the first symbol and `qty * price` of quote asset with opposite side on the second one
+func (d *Divided) Hedge(side exchange.OrderSide, qty, price decimal.Decimal) []exchange.OrderRequest {
+	return []exchange.OrderRequest{
+		{
+			Symbol: d.first,
+			Side:   side,
+			Type:   exchange.OrderTypeMarket,
+			Qty:    qty,
+		}, {
+			Symbol: d.second,
+			Side:   side.Opposite(),
+			Type:   exchange.OrderTypeMarket,
+			Qty:    qty.Mul(price),
+		},
+	}
+}
@@ -3,12 +3,14 @@ package synthetic
 import (
 	"github.com/atomex-protocol/watch_tower/internal/exchange"
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 )
 
 // Synthetic -
 type Synthetic interface {
 	Type() Type
 	Ticker(tick exchange.Ticker, tickers map[string]exchange.Ticker, toSymbols map[string]string) (exchange.Ticker, error)
+	Hedge(side exchange.OrderSide, qty, price decimal.Decimal) []exchange.OrderRequest
 }
 
 // Type -
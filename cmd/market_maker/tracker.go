@@ -6,6 +6,7 @@ import (
 	"github.com/atomex-protocol/watch_tower/internal/chain"
 	"github.com/atomex-protocol/watch_tower/internal/chain/tools"
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 )
 
 func (mm *MarketMaker) listenTracker(ctx context.Context) {
@@ -25,6 +26,7 @@ func (mm *MarketMaker) listenTracker(ctx context.Context) {
 			swap.Log(mm.log.Info()).Msg("swap's status changed")
 
 			current := mm.swaps.LoadOrStore(swap.HashedSecret, &swap)
+			wasRedeemed := current != &swap && current.Acceptor.Status == tools.StatusRedeemed
 			current.Status = swap.Status
 			current.Acceptor.Merge(swap.Acceptor)
 			current.Initiator.Merge(swap.Initiator)
@@ -33,6 +35,10 @@ func (mm *MarketMaker) listenTracker(ctx context.Context) {
 				current.Secret = swap.Secret
 			}
 
+			if !wasRedeemed && current.Acceptor.Status == tools.StatusRedeemed {
+				mm.onReceivingLegRedeemed(current.HashedSecret)
+			}
+
 			switch swap.Status {
 			case tools.StatusInitiated:
 				if swap.IsUnknown() {
@@ -59,7 +65,9 @@ func (mm *MarketMaker) listenTracker(ctx context.Context) {
 
 				if err := mm.tracker.Redeem(ctx, swap, swap.Acceptor); err != nil {
 					mm.log.Err(err).Msg("tracker.Redeem")
+					continue
 				}
+				mm.onRedeemSent(swap.HashedSecret, swap.Acceptor.ChainType)
 				continue
 
 			case tools.StatusRefundedOnce:
@@ -90,8 +98,10 @@ func (mm *MarketMaker) listenTracker(ctx context.Context) {
 				}
 
 			case tools.StatusRefunded, tools.StatusRedeemed:
+				mm.inventory.Release(current.HashedSecret.String())
 				mm.swaps.Delete(current.HashedSecret)
 				mm.secrets.Delete(current.HashedSecret)
+				mm.forgetFinishedSwap(current.HashedSecret)
 			}
 
 		case <-mm.tracker.Restored():
@@ -112,20 +122,36 @@ func (mm *MarketMaker) handleOperationFromChain(operation chain.Operation) error
 	switch operation.Status {
 	case chain.Pending:
 		mm.operations[id] = operation
+		mm.onRedeemOperation(operation.HashedSecret, operation)
 		mm.log.Info().Str("blockchain", operation.ChainType.String()).Str("hash", operation.Hash).Str("status", operation.Status.String()).Str("hashed_secret", operation.HashedSecret.String()).Msg("transaction")
 
 	case chain.Applied:
 		if old, ok := mm.operations[id]; ok {
 			mm.log.Info().Str("blockchain", operation.ChainType.String()).Str("hash", operation.Hash).Str("status", operation.Status.String()).Str("hashed_secret", old.HashedSecret.String()).Msg("transaction")
 			delete(mm.operations, id)
+
+			// sent amount is left the wallet, so it's not reserved anymore
+			mm.inventory.Release(old.HashedSecret.String())
+			mm.onRedeemOperation(old.HashedSecret, operation)
+			mm.recordOperationFee(old.HashedSecret, operation)
+			mm.forgetFinishedSwap(old.HashedSecret)
 		}
 	case chain.Failed:
-		if old, ok := mm.operations[id]; ok {
-			mm.log.Info().Str("blockchain", operation.ChainType.String()).Str("hash", operation.Hash).Str("status", operation.Status.String()).Str("hashed_secret", old.HashedSecret.String()).Msg("transaction")
+		hashedSecret := operation.HashedSecret
+		if !operation.IsRejected() {
+			old, ok := mm.operations[id]
+			if !ok {
+				return nil
+			}
+			hashedSecret = old.HashedSecret
 			delete(mm.operations, id)
-
-			// TODO: resend here if needed
 		}
+		mm.log.Info().Str("blockchain", operation.ChainType.String()).Str("hash", operation.Hash).Str("status", operation.Status.String()).Str("hashed_secret", hashedSecret.String()).Msg("transaction")
+		mm.recordOperationFee(hashedSecret, operation)
+		mm.onRedeemOperation(hashedSecret, operation)
+		mm.forgetFinishedSwap(hashedSecret)
+
+		// TODO: resend here if needed
 	default:
 		return errors.Errorf("unknown operation status: %s", operation.Status.String())
 	}
@@ -151,3 +177,85 @@ func (mm *MarketMaker) restoreSecretFromTrackerAtomex(ctx context.Context, swap
 
 	return nil
 }
+
+// forgetFinishedSwap - removes swap from ledger if it's finished and has no pending operations, so no more fees are expected
+func (mm *MarketMaker) forgetFinishedSwap(hashedSecret chain.Hex) {
+	if _, ok := mm.swaps.Load(hashedSecret); ok {
+		return
+	}
+	for _, operation := range mm.operations {
+		if operation.HashedSecret == hashedSecret {
+			return
+		}
+	}
+	mm.ledger.ForgetSwap(hashedSecret)
+}
+
+// redeemState - redeem of swap's receiving leg by market maker. Reward for redeem is received by someone else
+// if the leg is redeemed while no redeem of market maker is applied.
+type redeemState struct {
+	chain       chain.ChainType
+	sent        bool                // redeem is sent, but its operation isn't received yet
+	operations  map[string]struct{} // hashes of sent redeem operations which aren't finished yet
+	legRedeemed bool
+}
+
+func (mm *MarketMaker) redeemState(hashedSecret chain.Hex) *redeemState {
+	state, ok := mm.redeems[hashedSecret]
+	if !ok {
+		state = &redeemState{
+			operations: make(map[string]struct{}),
+		}
+		mm.redeems[hashedSecret] = state
+	}
+	return state
+}
+
+func (mm *MarketMaker) onRedeemSent(hashedSecret chain.Hex, chainType chain.ChainType) {
+	state := mm.redeemState(hashedSecret)
+	state.chain = chainType
+	state.sent = true
+}
+
+func (mm *MarketMaker) onReceivingLegRedeemed(hashedSecret chain.Hex) {
+	mm.redeemState(hashedSecret).legRedeemed = true
+	mm.settleRedeem(hashedSecret)
+}
+
+// onRedeemOperation - tracks operation statuses of sent redeem. Operations of other chains or before redeem is sent are ignored.
+func (mm *MarketMaker) onRedeemOperation(hashedSecret chain.Hex, operation chain.Operation) {
+	state, ok := mm.redeems[hashedSecret]
+	if !ok || state.chain != operation.ChainType {
+		return
+	}
+
+	switch operation.Status {
+	case chain.Pending:
+		if state.sent || len(state.operations) > 0 {
+			state.sent = false
+			state.operations[operation.Hash] = struct{}{}
+		}
+	case chain.Applied:
+		if _, ok := state.operations[operation.Hash]; ok {
+			// market maker's redeem is applied, so it receives reward itself
+			delete(mm.redeems, hashedSecret)
+		}
+	case chain.Failed:
+		if operation.IsRejected() {
+			state.sent = false
+		} else {
+			delete(state.operations, operation.Hash)
+		}
+		mm.settleRedeem(hashedSecret)
+	}
+}
+
+// settleRedeem - records payoff if receiving leg is redeemed and all sent redeems are failed
+func (mm *MarketMaker) settleRedeem(hashedSecret chain.Hex) {
+	state, ok := mm.redeems[hashedSecret]
+	if !ok || !state.legRedeemed || state.sent || len(state.operations) > 0 {
+		return
+	}
+	delete(mm.redeems, hashedSecret)
+	mm.ledger.SwapPayoff(hashedSecret, decimal.NewFromFloat(mm.atomexMeta.Settings.RewardForRedeem))
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/atomex-protocol/watch_tower/internal/chain"
+	"github.com/atomex-protocol/watch_tower/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarketMaker_redeemPayoff(t *testing.T) {
+	mm := &MarketMaker{
+		ledger:     NewLedger(LedgerConfig{Currency: "USDT"}),
+		atomexMeta: config.Atomex{Settings: config.AtomexSettings{RewardForRedeem: 1}},
+		redeems:    make(map[chain.Hex]*redeemState),
+	}
+
+	ours, theirs, rejected := chain.Hex("01"), chain.Hex("02"), chain.Hex("03")
+	for _, hashedSecret := range []chain.Hex{ours, theirs, rejected} {
+		mm.ledger.Swap(hashedSecret, "XTZ_ETH", "XTZ")
+		mm.onRedeemSent(hashedSecret, chain.ChainTypeTezos)
+	}
+
+	// market maker's redeem is applied after the leg is redeemed
+	mm.onRedeemOperation(ours, chain.Operation{Status: chain.Pending, Hash: "oo1", ChainType: chain.ChainTypeTezos, HashedSecret: ours})
+	mm.onReceivingLegRedeemed(ours)
+	mm.onRedeemOperation(ours, chain.Operation{Status: chain.Applied, Hash: "oo1", ChainType: chain.ChainTypeTezos})
+	assert.Empty(t, mm.ledger.payoffs)
+
+	// market maker's redeem is failed because the leg is redeemed by someone else
+	mm.onRedeemOperation(theirs, chain.Operation{Status: chain.Pending, Hash: "oo2", ChainType: chain.ChainTypeTezos, HashedSecret: theirs})
+	mm.onReceivingLegRedeemed(theirs)
+	assert.Empty(t, mm.ledger.payoffs, "redeem is pending")
+	mm.onRedeemOperation(theirs, chain.Operation{Status: chain.Failed, Hash: "oo2", ChainType: chain.ChainTypeTezos})
+	assert.Equal(t, "1", mm.ledger.payoffs["XTZ_ETH"]["XTZ"].String())
+
+	// rejected redeem doesn't book payoff until the leg is redeemed
+	mm.onRedeemOperation(rejected, chain.Operation{Status: chain.Failed, ChainType: chain.ChainTypeTezos, HashedSecret: rejected})
+	assert.Equal(t, "1", mm.ledger.payoffs["XTZ_ETH"]["XTZ"].String())
+	mm.onReceivingLegRedeemed(rejected)
+	assert.Equal(t, "2", mm.ledger.payoffs["XTZ_ETH"]["XTZ"].String())
+	assert.Empty(t, mm.redeems)
+}
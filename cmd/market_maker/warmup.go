@@ -0,0 +1,141 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	"github.com/atomex-protocol/watch_tower/cmd/market_maker/strategy"
+	"github.com/atomex-protocol/watch_tower/cmd/market_maker/synthetic"
+	"github.com/atomex-protocol/watch_tower/internal/exchange"
+	"github.com/atomex-protocol/watch_tower/internal/exchange/binance"
+	"github.com/pkg/errors"
+)
+
+// WarmupConfig - indicators of strategies are seeded by candles of the quote provider at startup.
+// Candles of `Interval` are requested if the provider supports it, otherwise candles of the provider's `OHLC` are used.
+// Windows are filled by tickers later, so the interval should be close to the interval of tickers:
+// deviation of closes of long candles is higher than deviation of tickers and early quotes of volatility are wider.
+// Avellaneda-Stoikov isn't affected since its variance is normalized by time of the window.
+type WarmupConfig struct {
+	Disabled bool             `yaml:"disabled"`
+	Interval binance.Interval `yaml:"interval"`
+}
+
+// warmUp - seeds windows of strategies by closes of the last candles. Prices of synthetics are combined from their legs by candle's time.
+func (mm *MarketMaker) warmUp() error {
+	if mm.warmup.Disabled || len(mm.warmupWindows) == 0 {
+		return nil
+	}
+
+	var count int
+	providerSymbols := make(map[string]struct{})
+	for symbol, window := range mm.warmupWindows {
+		synth, ok := mm.quoteProviderMeta.FromSymbols[symbol]
+		if !ok {
+			continue
+		}
+		for _, providerSymbol := range synth.Symbols {
+			providerSymbols[providerSymbol] = struct{}{}
+		}
+		if window > count {
+			count = window
+		}
+	}
+
+	candles := make(map[string][]exchange.OHLC)
+	for symbol := range providerSymbols {
+		ohlc, err := mm.candles(symbol, count)
+		if err != nil {
+			return errors.Wrapf(err, "candles %s", symbol)
+		}
+		candles[symbol] = ohlc
+	}
+
+	prices := historicalPrices(candles, mm.synthetics, mm.quoteProviderMeta.ToSymbols)
+	for symbol := range mm.warmupWindows {
+		for i := range mm.strategies {
+			if warmer, ok := mm.strategies[i].(strategy.Warmer); ok {
+				warmer.Warm(symbol, prices[symbol])
+			}
+		}
+		mm.log.Info().Str("symbol", symbol).Int("prices", len(prices[symbol])).Msg("strategies are warmed up")
+	}
+	return nil
+}
+
+// candles - returns the last `count` candles of provider's symbol
+func (mm *MarketMaker) candles(symbol string, count int) ([]exchange.OHLC, error) {
+	var ohlc []exchange.OHLC
+	if source, ok := mm.provider.(klinesSource); ok && mm.warmup.Interval != "" {
+		start := time.Now().Add(-mm.warmup.Interval.Duration() * time.Duration(count+1))
+		klines, err := source.Klines(symbol, mm.warmup.Interval, start, time.Time{})
+		if err != nil {
+			return nil, err
+		}
+		ohlc = klines
+	} else {
+		klines, err := mm.provider.OHLC(symbol)
+		if err != nil {
+			return nil, err
+		}
+		ohlc = klines
+	}
+
+	if len(ohlc) > count {
+		ohlc = ohlc[len(ohlc)-count:]
+	}
+	return ohlc, nil
+}
+
+// historicalPrices - converts closes of candles of provider symbols to prices of synthetics. Candle has no spread, so ask and bid are the close. Candles of the same time are applied to direct synthetics first,
+// so divided ones are computed from legs of that time.
+func historicalPrices(candles map[string][]exchange.OHLC, synthetics map[string]synthetic.Synthetic, toSymbols map[string]string) map[string][]strategy.HistoricalPrice {
+	ticks := make(map[int64][]exchange.Ticker)
+	times := make([]int64, 0)
+	for symbol, ohlc := range candles {
+		for i := range ohlc {
+			ts := ohlc[i].Time.UnixMilli()
+			if _, ok := ticks[ts]; !ok {
+				times = append(times, ts)
+			}
+			ticks[ts] = append(ticks[ts], exchange.Ticker{
+				Symbol: symbol,
+				Ask:    ohlc[i].Close,
+				Bid:    ohlc[i].Close,
+			})
+		}
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i] < times[j]
+	})
+
+	tickers := make(map[string]exchange.Ticker)
+	prices := make(map[string][]strategy.HistoricalPrice)
+	for _, ts := range times {
+		updated := make(map[string]struct{})
+		for _, typ := range []synthetic.Type{synthetic.DirectType, synthetic.DividedType} {
+			for _, tick := range ticks[ts] {
+				for _, synth := range synthetics {
+					if synth.Type() != typ {
+						continue
+					}
+					ticker, err := synth.Ticker(tick, tickers, toSymbols)
+					if err != nil {
+						continue
+					}
+					tickers[ticker.Symbol] = ticker
+					updated[ticker.Symbol] = struct{}{}
+				}
+			}
+		}
+
+		for symbol := range updated {
+			prices[symbol] = append(prices[symbol], strategy.HistoricalPrice{
+				Time: time.UnixMilli(ts).UTC(),
+				Ask:  tickers[symbol].Ask,
+				Bid:  tickers[symbol].Bid,
+			})
+		}
+	}
+	return prices
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/atomex-protocol/watch_tower/cmd/market_maker/synthetic"
+	"github.com/atomex-protocol/watch_tower/internal/exchange"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_historicalPrices(t *testing.T) {
+	d := decimal.RequireFromString
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	xtz, err := synthetic.New("XTZ_USDT", synthetic.Config{Symbols: []string{"XTZUSDT"}, Type: synthetic.DirectType})
+	require.NoError(t, err)
+	eth, err := synthetic.New("ETH_USDT", synthetic.Config{Symbols: []string{"ETHUSDT"}, Type: synthetic.DirectType})
+	require.NoError(t, err)
+	xtzEth, err := synthetic.New("XTZ_ETH", synthetic.Config{Symbols: []string{"XTZUSDT", "ETHUSDT"}, Type: synthetic.DividedType})
+	require.NoError(t, err)
+
+	prices := historicalPrices(
+		map[string][]exchange.OHLC{
+			"XTZUSDT": {
+				{Time: start, Close: d("3")},
+				{Time: start.Add(time.Minute), Close: d("4")},
+			},
+			"ETHUSDT": {
+				{Time: start, Close: d("3000")},
+				{Time: start.Add(time.Minute), Close: d("2000")},
+			},
+		},
+		map[string]synthetic.Synthetic{"XTZ_USDT": xtz, "ETH_USDT": eth, "XTZ_ETH": xtzEth},
+		map[string]string{"XTZUSDT": "XTZ_USDT", "ETHUSDT": "ETH_USDT"},
+	)
+
+	require.Len(t, prices["XTZ_USDT"], 2)
+	assert.Equal(t, "4", prices["XTZ_USDT"][1].Ask.String())
+
+	require.Len(t, prices["XTZ_ETH"], 2)
+	assert.Equal(t, start, prices["XTZ_ETH"][0].Time)
+	assert.Equal(t, "0.001", prices["XTZ_ETH"][0].Ask.String())
+	assert.Equal(t, "0.002", prices["XTZ_ETH"][1].Bid.String())
+}
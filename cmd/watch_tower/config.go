@@ -1,14 +1,29 @@
 package main
 
 import (
+	"time"
+
+	"github.com/atomex-protocol/watch_tower/internal/chain"
 	"github.com/atomex-protocol/watch_tower/internal/config"
+	"github.com/atomex-protocol/watch_tower/internal/storage"
 )
 
 // Config -
 type Config struct {
-	Restore              bool     `yaml:"restore"`
-	Types                []string `yaml:"types" validate:"dive,oneof=redeem refund"`
-	RetryCountOnFailedTx uint     `yaml:"retry_count_on_failed_tx"`
+	Restore              bool           `yaml:"restore"`
+	Types                []string       `yaml:"types" validate:"dive,oneof=redeem refund"`
+	RetryCountOnFailedTx uint           `yaml:"retry_count_on_failed_tx"`
+	Storage              storage.Config `yaml:"storage"`
+	Watches              []Watch        `yaml:"watches" validate:"dive"`
 
 	General config.General `yaml:"-" validate:"-"`
 }
+
+// Watch - parameters of HTLC in chain which can't find swaps by itself, e.g. bitcoin
+type Watch struct {
+	Chain        chain.ChainType `yaml:"chain" validate:"required"`
+	HashedSecret chain.Hex       `yaml:"hashed_secret" validate:"required,hexadecimal"`
+	Initiator    string          `yaml:"initiator" validate:"required"`
+	Participant  string          `yaml:"participant" validate:"required"`
+	RefundTime   time.Time       `yaml:"refund_time" validate:"required"`
+}
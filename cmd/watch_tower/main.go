@@ -44,7 +44,7 @@ func main() {
 		log.Panic().Err(err).Msg("NewWatchTower")
 	}
 
-	if err := watchTower.Run(ctx, cfg.Restore); err != nil {
+	if err := watchTower.Run(ctx); err != nil {
 		log.Panic().Err(err).Msg("Run")
 	}
 
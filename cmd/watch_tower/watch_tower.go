@@ -9,6 +9,8 @@ import (
 
 	"github.com/atomex-protocol/watch_tower/internal/chain"
 	"github.com/atomex-protocol/watch_tower/internal/chain/tools"
+	"github.com/atomex-protocol/watch_tower/internal/storage"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
@@ -16,6 +18,7 @@ import (
 // WatchTower -
 type WatchTower struct {
 	tracker    *tools.Tracker
+	storage    storage.Storage
 	operations map[tools.OperationID]chain.Operation
 	swaps      map[chain.Hex]*Swap
 
@@ -30,23 +33,53 @@ type WatchTower struct {
 
 // NewWatchTower -
 func NewWatchTower(cfg Config) (*WatchTower, error) {
+	store, err := storage.New(cfg.Storage)
+	if err != nil {
+		return nil, errors.Wrap(err, "storage.New")
+	}
+	wt := &WatchTower{
+		storage:    store,
+		retryCount: cfg.RetryCountOnFailedTx,
+		uptimeAPI:  cfg.General.Atomex.UptimeAPI,
+		operations: make(map[tools.OperationID]chain.Operation),
+		swaps:      make(map[chain.Hex]*Swap),
+	}
+	if err := wt.load(); err != nil {
+		return nil, errors.Wrap(err, "load")
+	}
+
 	opts := []tools.TrackerOption{
 		tools.WithLogLevel(zerolog.InfoLevel),
+		tools.WithCheckpoints(store),
+		tools.WithWatches(store),
 	}
 	if cfg.Restore {
 		opts = append(opts, tools.WithRestore())
 	}
+	if len(wt.swaps) > 0 {
+		swaps := make([]tools.Swap, 0, len(wt.swaps))
+		for _, swap := range wt.swaps {
+			swaps = append(swaps, swap.Swap)
+		}
+		opts = append(opts, tools.WithSwaps(swaps...))
+	}
 	track, err := tools.NewTracker(cfg.General.Chains, opts...)
 	if err != nil {
 		return nil, err
 	}
-	wt := &WatchTower{
-		tracker:    track,
-		retryCount: cfg.RetryCountOnFailedTx,
-		uptimeAPI:  cfg.General.Atomex.UptimeAPI,
-		operations: make(map[tools.OperationID]chain.Operation),
-		swaps:      make(map[chain.Hex]*Swap),
+	wt.tracker = track
+
+	for _, watch := range cfg.Watches {
+		if err := wt.tracker.Watch(watch.Chain, chain.WatchArgs{
+			HashedSecret: watch.HashedSecret,
+			Initiator:    watch.Initiator,
+			Participant:  watch.Participant,
+			RefundTime:   watch.RefundTime,
+		}); err != nil {
+			return nil, errors.Wrapf(err, "watch %s", watch.HashedSecret)
+		}
 	}
+
 	if wt.retryCount == 0 {
 		wt.retryCount = 3
 	}
@@ -66,7 +99,7 @@ func NewWatchTower(cfg Config) (*WatchTower, error) {
 const minus30Minutes = -30 * time.Minute
 
 // Run -
-func (wt *WatchTower) Run(ctx context.Context, restore bool) error {
+func (wt *WatchTower) Run(ctx context.Context) error {
 	wt.wg.Add(1)
 	go wt.listen(ctx)
 
@@ -86,9 +119,65 @@ func (wt *WatchTower) Close() error {
 		return err
 	}
 
+	if err := wt.storage.Close(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+func (wt *WatchTower) load() error {
+	swaps, err := wt.storage.Swaps()
+	if err != nil {
+		return err
+	}
+	for i := range swaps {
+		swap := Swap(swaps[i])
+		wt.swaps[swap.HashedSecret] = &swap
+	}
+
+	operations, err := wt.storage.Operations()
+	if err != nil {
+		return err
+	}
+	for i := range operations {
+		wt.operations[tools.OperationID{
+			Hash:  operations[i].Hash,
+			Chain: operations[i].ChainType,
+		}] = operations[i]
+	}
+
+	log.Info().Int("swaps", len(swaps)).Int("operations", len(operations)).Msg("state loaded from storage")
+	return nil
+}
+
+func (wt *WatchTower) saveSwap(swap *Swap) {
+	if err := wt.storage.SaveSwap(storage.Swap(*swap)); err != nil {
+		log.Err(err).Str("hashed_secret", swap.HashedSecret.String()).Msg("storage.SaveSwap")
+	}
+}
+
+func (wt *WatchTower) deleteSwap(hashedSecret chain.Hex) {
+	delete(wt.swaps, hashedSecret)
+	if err := wt.storage.DeleteSwap(hashedSecret); err != nil {
+		log.Err(err).Str("hashed_secret", hashedSecret.String()).Msg("storage.DeleteSwap")
+	}
+}
+
+func (wt *WatchTower) saveOperation(id tools.OperationID, operation chain.Operation) {
+	wt.operations[id] = operation
+	if err := wt.storage.SaveOperation(operation); err != nil {
+		log.Err(err).Str("hash", operation.Hash).Msg("storage.SaveOperation")
+	}
+}
+
+func (wt *WatchTower) deleteOperation(id tools.OperationID) {
+	delete(wt.operations, id)
+	if err := wt.storage.DeleteOperation(id); err != nil {
+		log.Err(err).Str("hash", id.Hash).Msg("storage.DeleteOperation")
+	}
+}
+
 func (wt *WatchTower) listen(ctx context.Context) {
 	defer wt.wg.Done()
 
@@ -118,6 +207,9 @@ func (wt *WatchTower) listen(ctx context.Context) {
 			if err := wt.onSwap(ctx, s); err != nil {
 				log.Err(err).Msg("onSwap")
 			}
+			if _, ok := wt.swaps[s.HashedSecret]; ok {
+				wt.saveSwap(s)
+			}
 		case operation := <-wt.tracker.Operations():
 			if err := wt.onOperation(ctx, operation); err != nil {
 				log.Err(err).Msg("onOperation")
@@ -135,7 +227,7 @@ func (wt *WatchTower) listen(ctx context.Context) {
 
 func (wt *WatchTower) onSwap(ctx context.Context, swap *Swap) error {
 	if swap.RetryCount >= wt.retryCount {
-		delete(wt.swaps, swap.HashedSecret)
+		wt.deleteSwap(swap.HashedSecret)
 		log.Info().Str("hashed_secret", swap.HashedSecret.String()).Msg("swap retry count transaction exceeded")
 		return nil
 	}
@@ -149,7 +241,7 @@ func (wt *WatchTower) onSwap(ctx context.Context, swap *Swap) error {
 		}
 	case tools.StatusRefundedOnce:
 	case tools.StatusRedeemed, tools.StatusRefunded:
-		delete(wt.swaps, swap.HashedSecret)
+		wt.deleteSwap(swap.HashedSecret)
 	default:
 	}
 	return nil
@@ -169,6 +261,7 @@ func (wt *WatchTower) checkNextActionTime(ctx context.Context) {
 			if err := wt.redeem(ctx, swap); err != nil {
 				log.Err(err).Msg("redeem")
 			}
+			wt.saveSwap(swap)
 		}
 
 		if wt.needRefund {
@@ -179,10 +272,11 @@ func (wt *WatchTower) checkNextActionTime(ctx context.Context) {
 			if swap.RefundTime.UTC().Before(time.Now().UTC()) {
 				if err := wt.refund(ctx, swap); err != nil {
 					log.Err(err).Msg("refund")
+					wt.saveSwap(swap)
 					continue
 				}
 
-				delete(wt.swaps, hashedSecret)
+				wt.deleteSwap(hashedSecret)
 			}
 		}
 	}
@@ -232,20 +326,31 @@ func (wt *WatchTower) onOperation(ctx context.Context, operation chain.Operation
 
 	switch operation.Status {
 	case chain.Pending:
-		wt.operations[id] = operation
+		wt.saveOperation(id, operation)
 		log.Info().Str("blockchain", operation.ChainType.String()).Str("hash", operation.Hash).Str("status", operation.Status.String()).Str("hashed_secret", operation.HashedSecret.String()).Msg("transaction")
 	case chain.Applied:
 		if old, ok := wt.operations[id]; ok {
 			log.Info().Str("blockchain", operation.ChainType.String()).Str("hash", operation.Hash).Str("status", operation.Status.String()).Str("hashed_secret", old.HashedSecret.String()).Msg("transaction")
-			delete(wt.operations, id)
+			wt.deleteOperation(id)
 		}
 	case chain.Failed:
-		if old, ok := wt.operations[id]; ok {
-			log.Info().Str("blockchain", operation.ChainType.String()).Str("hash", operation.Hash).Str("status", operation.Status.String()).Str("hashed_secret", old.HashedSecret.String()).Msg("transaction")
-			delete(wt.operations, id)
+		hashedSecret := operation.HashedSecret
+		if !operation.IsRejected() {
+			old, ok := wt.operations[id]
+			if !ok {
+				return nil
+			}
+			hashedSecret = old.HashedSecret
+			wt.deleteOperation(id)
+		}
+		log.Info().Str("blockchain", operation.ChainType.String()).Str("hash", operation.Hash).Str("status", operation.Status.String()).Str("hashed_secret", hashedSecret.String()).Msg("transaction")
 
-			if swap, ok := wt.swaps[old.HashedSecret]; ok {
-				return wt.onSwap(ctx, swap)
+		if swap, ok := wt.swaps[hashedSecret]; ok {
+			if err := wt.onSwap(ctx, swap); err != nil {
+				return err
+			}
+			if _, ok := wt.swaps[hashedSecret]; ok {
+				wt.saveSwap(swap)
 			}
 		}
 	}
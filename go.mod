@@ -3,6 +3,8 @@ module github.com/atomex-protocol/watch_tower
 go 1.18
 
 require (
+	github.com/btcsuite/btcd v0.22.0-beta
+	github.com/btcsuite/btcutil v1.0.3-0.20201208143702-a53e38424cce
 	github.com/dipdup-net/go-lib v0.2.12
 	github.com/ebellocchia/go-base58 v0.1.0
 	github.com/ethereum/go-ethereum v1.10.17
@@ -14,6 +16,7 @@ require (
 	github.com/rs/zerolog v1.26.0
 	github.com/shopspring/decimal v1.3.1
 	github.com/stretchr/testify v1.7.0
+	go.etcd.io/bbolt v1.3.6
 	golang.org/x/crypto v0.0.0-20211202192323-5770296d904e
 	golang.org/x/time v0.0.0-20210220033141-f8bda1e9f3ba
 	gopkg.in/yaml.v3 v3.0.0
@@ -21,9 +24,8 @@ require (
 
 require (
 	github.com/StackExchange/wmi v1.2.1 // indirect
-	github.com/btcsuite/btcd v0.22.0-beta // indirect
 	github.com/btcsuite/btcd/btcec/v2 v2.1.2 // indirect
-	github.com/btcsuite/btcutil v1.0.3-0.20201208143702-a53e38424cce // indirect
+	github.com/btcsuite/btclog v0.0.0-20170628155309-84c8d2346e9f // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/deckarep/golang-set v1.8.0 // indirect
 	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.0.1 // indirect
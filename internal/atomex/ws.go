@@ -45,6 +45,20 @@ type Market struct {
 	*Websocket
 }
 
+// MarketStream - market data stream of Atomex. It's implemented by `Market` and may be implemented by a replay of recorded data.
+type MarketStream interface {
+	Connect(token TokenResponse) error
+	Close() error
+	Listen() <-chan Message
+	Errors() <-chan error
+	SubscribeToOrderBook() error
+	UnsubscribeFromOrderBook() error
+	SubscribeToTopOfBook() error
+	UnsubscribeFromTopOfBook() error
+	GetTopOfBook(symbols ...string) error
+	GetSnapshot(symbol string) error
+}
+
 // NewMarket -
 func NewMarket(opts ...WebsocketOption) (*Market, error) {
 	ws, err := NewWebsocket(WebsocketTypeMarketData, opts...)
@@ -0,0 +1,1077 @@
+package bitcoin
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/atomex-protocol/watch_tower/internal/chain"
+	"github.com/atomex-protocol/watch_tower/internal/logger"
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+)
+
+// virtual sizes of transaction parts which are used for fee calculation
+const (
+	txOverheadSize   = 11
+	p2wpkhInputSize  = 68
+	p2wpkhOutputSize = 31
+	p2wshOutputSize  = 43
+	htlcInputSize    = 110
+	dustThreshold    = 546
+)
+
+// Bitcoin - chain which works with Atomex HTLC in P2WSH outputs
+type Bitcoin struct {
+	cfg    Config
+	rpc    *RPC
+	params *chaincfg.Params
+
+	key          *btcec.PrivateKey
+	address      *btcutil.AddressWitnessPubKeyHash
+	walletScript []byte
+
+	log zerolog.Logger
+
+	level      uint64
+	scanFrom   uint64               // the first level processed after start
+	unscanned  []string             // addresses of HTLCs which are watched after start and aren't looked up yet
+	contracts  map[string]*contract // by P2WSH address
+	byOutpoint map[string]*contract
+	unspents   map[string]int64 // wallet's outputs by outpoint in satoshi
+	pending    map[string]pendingTx
+	blocks     map[uint64]*blockChanges // changes of the last processed blocks by level
+	mx         sync.RWMutex
+	initiateMx sync.Mutex
+
+	events     chan chain.Event
+	operations chan chain.Operation
+	rescan     chan struct{}
+	wg         sync.WaitGroup
+}
+
+// Config -
+type Config struct {
+	Node          string
+	User          string
+	Password      string
+	Network       string
+	FeeRate       int64
+	StartLevel    uint64
+	PollInterval  time.Duration
+	Confirmations uint64
+	LogLevel      zerolog.Level
+}
+
+type contract struct {
+	HTLC
+
+	address     string
+	script      []byte
+	pkScript    []byte
+	initiator   string
+	participant string
+	outpoint    string
+	amount      int64
+	spent       bool
+}
+
+// New -
+func New(cfg Config) (*Bitcoin, error) {
+	if cfg.LogLevel == 0 {
+		cfg.LogLevel = zerolog.InfoLevel
+	}
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = 30 * time.Second
+	}
+	if cfg.FeeRate == 0 {
+		cfg.FeeRate = 1
+	}
+
+	params, err := networkParams(cfg.Network)
+	if err != nil {
+		return nil, err
+	}
+
+	btc := &Bitcoin{
+		cfg:        cfg,
+		rpc:        NewRPC(cfg.Node, cfg.User, cfg.Password),
+		params:     params,
+		log:        logger.New(logger.WithLogLevel(cfg.LogLevel), logger.WithModuleName("bitcoin")),
+		contracts:  make(map[string]*contract),
+		byOutpoint: make(map[string]*contract),
+		unspents:   make(map[string]int64),
+		pending:    make(map[string]pendingTx),
+		blocks:     make(map[uint64]*blockChanges),
+		events:     make(chan chain.Event, 1024),
+		operations: make(chan chain.Operation, 1024),
+		rescan:     make(chan struct{}, 1),
+	}
+
+	if err := initKey(btc); err != nil {
+		return nil, err
+	}
+
+	return btc, nil
+}
+
+func networkParams(network string) (*chaincfg.Params, error) {
+	switch network {
+	case "", "mainnet":
+		return &chaincfg.MainNetParams, nil
+	case "testnet":
+		return &chaincfg.TestNet3Params, nil
+	case "regtest":
+		return &chaincfg.RegressionNetParams, nil
+	default:
+		return nil, errors.Errorf("unknown bitcoin network: %s", network)
+	}
+}
+
+func initKey(b *Bitcoin) error {
+	secret, err := chain.LoadSecret("BITCOIN_PRIVATE")
+	if err != nil {
+		return err
+	}
+	wif, err := btcutil.DecodeWIF(secret)
+	if err != nil {
+		return err
+	}
+	if !wif.IsForNet(b.params) {
+		return errors.Errorf("private key is not for %s network", b.params.Name)
+	}
+	b.key = wif.PrivKey
+
+	address, err := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(b.publicKey()), b.params)
+	if err != nil {
+		return err
+	}
+	b.address = address
+
+	walletScript, err := txscript.PayToAddrScript(address)
+	if err != nil {
+		return err
+	}
+	b.walletScript = walletScript
+
+	b.log.Info().Str("address", address.EncodeAddress()).Msg("using address")
+	return nil
+}
+
+func (b *Bitcoin) publicKey() []byte {
+	return b.key.PubKey().SerializeCompressed()
+}
+
+// Wallet -
+func (b *Bitcoin) Wallet() chain.Wallet {
+	return chain.Wallet{
+		Address:   b.address.EncodeAddress(),
+		PublicKey: b.publicKey(),
+		Private:   b.key.Serialize(),
+	}
+}
+
+// Balance - returns sum of unspent outputs of the wallet in satoshi including change of sent transactions. Bitcoin has no tokens.
+func (b *Bitcoin) Balance(ctx context.Context, args chain.BalanceArgs) (decimal.Decimal, error) {
+	if args.Contract != "" {
+		return decimal.Zero, errors.Errorf("bitcoin doesn't support tokens: %s", args.Contract)
+	}
+
+	var total int64
+	for _, value := range b.walletUnspents() {
+		total += value
+	}
+	return decimal.NewFromInt(total), nil
+}
+
+// Init -
+func (b *Bitcoin) Init(ctx context.Context) error {
+	b.log.Info().Msg("initializing...")
+
+	count, err := b.rpc.BlockCount(ctx)
+	if err != nil {
+		return errors.Wrap(err, "BlockCount")
+	}
+	b.log.Info().Uint64("head", count).Msg("connected to node")
+
+	return b.loadUnspents(ctx)
+}
+
+// loadUnspents - initializes wallet's outputs. They are updated by processed blocks and sent transactions after that.
+func (b *Bitcoin) loadUnspents(ctx context.Context) error {
+	result, err := b.rpc.ScanTxOutSet(ctx, b.address.EncodeAddress())
+	if err != nil {
+		return errors.Wrap(err, "ScanTxOutSet")
+	}
+
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	for i := range result.Unspents {
+		value, err := toSatoshi(result.Unspents[i].Amount.String())
+		if err != nil {
+			return err
+		}
+		b.unspents[outpointKey(result.Unspents[i].TxID, result.Unspents[i].Vout)] = value
+	}
+	return nil
+}
+
+// Run -
+func (b *Bitcoin) Run(ctx context.Context) error {
+	b.log.Info().Msg("running...")
+
+	if b.level == 0 {
+		head, err := b.rpc.BlockCount(ctx)
+		if err != nil {
+			return errors.Wrap(err, "BlockCount")
+		}
+		b.level = b.confirmedLevel(head)
+	}
+
+	b.mx.Lock()
+	if b.scanFrom == 0 {
+		// HTLCs registered before start without restore are looked up in the UTXO set
+		b.scanFrom = b.level + 1
+		b.unscanned = append(b.unscanned, b.unfunded()...)
+		b.requestRescan()
+	}
+	b.mx.Unlock()
+
+	b.wg.Add(1)
+	go b.listen(ctx)
+
+	return nil
+}
+
+// Close -
+func (b *Bitcoin) Close() error {
+	b.log.Info().Msg("closing...")
+	b.wg.Wait()
+
+	close(b.events)
+	close(b.operations)
+	return nil
+}
+
+// Events -
+func (b *Bitcoin) Events() <-chan chain.Event {
+	return b.events
+}
+
+// Operations -
+func (b *Bitcoin) Operations() <-chan chain.Operation {
+	return b.operations
+}
+
+// Watch - registers HTLC which should be tracked. Bitcoin HTLC can't be found without its parameters because output contains only script hash.
+// HTLC which is watched after start may be funded or spent already, so it's looked up in the UTXO set and in blocks processed since start.
+func (b *Bitcoin) Watch(args chain.WatchArgs) error {
+	return b.watch(args, true)
+}
+
+// watch - `lookup` is unset for HTLC of own initiation because it can't be funded before.
+func (b *Bitcoin) watch(args chain.WatchArgs, lookup bool) error {
+	hashedSecret, err := args.HashedSecret.Bytes()
+	if err != nil {
+		return err
+	}
+	refundPubKeyHash, err := b.pubKeyHash(args.Initiator)
+	if err != nil {
+		return errors.Wrap(err, "initiator")
+	}
+	redeemPubKeyHash, err := b.pubKeyHash(args.Participant)
+	if err != nil {
+		return errors.Wrap(err, "participant")
+	}
+
+	htlc := HTLC{
+		HashedSecret:     hashedSecret,
+		RefundPubKeyHash: refundPubKeyHash,
+		RedeemPubKeyHash: redeemPubKeyHash,
+		RefundTime:       args.RefundTime,
+	}
+	script, err := htlc.Script()
+	if err != nil {
+		return err
+	}
+	address, err := htlc.Address(b.params)
+	if err != nil {
+		return err
+	}
+	pkScript, err := txscript.PayToAddrScript(address)
+	if err != nil {
+		return err
+	}
+
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	if _, ok := b.contracts[address.EncodeAddress()]; ok {
+		return nil
+	}
+	b.contracts[address.EncodeAddress()] = &contract{
+		HTLC:        htlc,
+		address:     address.EncodeAddress(),
+		script:      script,
+		pkScript:    pkScript,
+		initiator:   args.Initiator,
+		participant: args.Participant,
+	}
+	if lookup && b.scanFrom > 0 {
+		b.unscanned = append(b.unscanned, address.EncodeAddress())
+		b.requestRescan()
+	}
+	b.log.Info().Str("hashed_secret", args.HashedSecret.String()).Str("contract", address.EncodeAddress()).Msg("watch")
+	return nil
+}
+
+func (b *Bitcoin) pubKeyHash(address string) ([]byte, error) {
+	decoded, err := btcutil.DecodeAddress(address, b.params)
+	if err != nil {
+		return nil, err
+	}
+	switch typ := decoded.(type) {
+	case *btcutil.AddressPubKeyHash, *btcutil.AddressWitnessPubKeyHash:
+		return typ.ScriptAddress(), nil
+	default:
+		return nil, errors.Errorf("unsupported address type: %s", address)
+	}
+}
+
+// Initiate - sends HTLC output from wallet's unspent outputs. `Contract` of arguments is ignored because HTLC address is derived from swap parameters.
+// Initiations are sent one by one, so concurrent initiations don't select the same outputs.
+func (b *Bitcoin) Initiate(ctx context.Context, args chain.InitiateArgs) error {
+	b.log.Info().Str("hashed_secret", args.HashedSecret.String()).Msg("initiate")
+
+	b.initiateMx.Lock()
+	defer b.initiateMx.Unlock()
+
+	if err := b.watch(chain.WatchArgs{
+		HashedSecret: args.HashedSecret,
+		Initiator:    b.address.EncodeAddress(),
+		Participant:  args.Participant,
+		RefundTime:   args.RefundTime,
+	}, false); err != nil {
+		return err
+	}
+	c, err := b.contractByHashedSecret(args.HashedSecret)
+	if err != nil {
+		return err
+	}
+
+	feeRate, err := b.feeRate(ctx)
+	if err != nil {
+		return err
+	}
+
+	amount := args.Amount.IntPart()
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxOut(wire.NewTxOut(amount, c.pkScript))
+
+	var (
+		total   int64
+		amounts []int64
+		fee     int64
+	)
+	for key, value := range b.walletUnspents() {
+		outpoint, err := parseOutpoint(key)
+		if err != nil {
+			return err
+		}
+		// output may be spent by transaction which isn't processed yet
+		out, err := b.rpc.TxOut(ctx, outpoint.Hash.String(), outpoint.Index, true)
+		if err != nil {
+			return errors.Wrap(err, "TxOut")
+		}
+		if out == nil {
+			b.mx.Lock()
+			delete(b.unspents, key)
+			b.mx.Unlock()
+			continue
+		}
+		tx.AddTxIn(wire.NewTxIn(outpoint, nil, nil))
+		amounts = append(amounts, value)
+		total += value
+
+		fee = feeRate * int64(txOverheadSize+len(tx.TxIn)*p2wpkhInputSize+p2wshOutputSize+p2wpkhOutputSize)
+		if total >= amount+fee {
+			break
+		}
+	}
+	if total < amount+fee {
+		return errors.Errorf("insufficient funds: %d < %d", total, amount+fee)
+	}
+
+	if change := total - amount - fee; change > dustThreshold {
+		tx.AddTxOut(wire.NewTxOut(change, b.walletScript))
+	}
+
+	sigHashes := txscript.NewTxSigHashes(tx)
+	for i := range tx.TxIn {
+		witness, err := txscript.WitnessSignature(tx, sigHashes, i, amounts[i], b.walletScript, txscript.SigHashAll, b.key, true)
+		if err != nil {
+			return err
+		}
+		tx.TxIn[i].Witness = witness
+	}
+
+	return b.send(ctx, tx, args.HashedSecret, total-outputsValue(tx))
+}
+
+// Redeem -
+func (b *Bitcoin) Redeem(ctx context.Context, hashedSecret, secret chain.Hex, contract string) error {
+	b.log.Info().Str("hashed_secret", hashedSecret.String()).Str("contract", contract).Msg("redeem")
+
+	c, err := b.spendableContract(contract)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(c.RedeemPubKeyHash, b.address.ScriptAddress()) {
+		return errors.Errorf("HTLC %s can be redeemed only by participant", contract)
+	}
+	secretBytes, err := secret.Bytes()
+	if err != nil {
+		return err
+	}
+
+	tx, err := b.spendTx(ctx, c, 0)
+	if err != nil {
+		return err
+	}
+	signature, err := txscript.RawTxInWitnessSignature(tx, txscript.NewTxSigHashes(tx), 0, c.amount, c.script, txscript.SigHashAll, b.key)
+	if err != nil {
+		return err
+	}
+	tx.TxIn[0].Witness = RedeemWitness(signature, b.publicKey(), secretBytes, c.script)
+
+	return b.send(ctx, tx, hashedSecret, c.amount-outputsValue(tx))
+}
+
+// Refund -
+func (b *Bitcoin) Refund(ctx context.Context, hashedSecret chain.Hex, contract string) error {
+	b.log.Info().Str("hashed_secret", hashedSecret.String()).Str("contract", contract).Msg("refund")
+
+	c, err := b.spendableContract(contract)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(c.RefundPubKeyHash, b.address.ScriptAddress()) {
+		return errors.Errorf("HTLC %s can be refunded only by initiator", contract)
+	}
+
+	tx, err := b.spendTx(ctx, c, uint32(c.RefundTime.Unix()))
+	if err != nil {
+		return err
+	}
+	signature, err := txscript.RawTxInWitnessSignature(tx, txscript.NewTxSigHashes(tx), 0, c.amount, c.script, txscript.SigHashAll, b.key)
+	if err != nil {
+		return err
+	}
+	tx.TxIn[0].Witness = RefundWitness(signature, b.publicKey(), c.script)
+
+	return b.send(ctx, tx, hashedSecret, c.amount-outputsValue(tx))
+}
+
+// spendTx - builds unsigned transaction which sends HTLC output to wallet. Lock time is set for refund only.
+func (b *Bitcoin) spendTx(ctx context.Context, c *contract, lockTime uint32) (*wire.MsgTx, error) {
+	feeRate, err := b.feeRate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fee := feeRate * (txOverheadSize + htlcInputSize + p2wpkhOutputSize)
+	if c.amount-fee <= dustThreshold {
+		return nil, errors.Errorf("HTLC amount %d is too small to pay fee %d", c.amount, fee)
+	}
+
+	outpoint, err := parseOutpoint(c.outpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	input := wire.NewTxIn(outpoint, nil, nil)
+	if lockTime > 0 {
+		input.Sequence = wire.MaxTxInSequenceNum - 1
+		tx.LockTime = lockTime
+	}
+	tx.AddTxIn(input)
+	tx.AddTxOut(wire.NewTxOut(c.amount-fee, b.walletScript))
+	return tx, nil
+}
+
+func outputsValue(tx *wire.MsgTx) int64 {
+	var value int64
+	for i := range tx.TxOut {
+		value += tx.TxOut[i].Value
+	}
+	return value
+}
+
+func (b *Bitcoin) send(ctx context.Context, tx *wire.MsgTx, hashedSecret chain.Hex, fee int64) error {
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return err
+	}
+	hash, err := b.rpc.SendRawTransaction(ctx, hex.EncodeToString(buf.Bytes()))
+	if err != nil {
+		return errors.Wrap(err, "SendRawTransaction")
+	}
+
+	pending := pendingTx{
+		hashedSecret: hashedSecret,
+		fee:          fee,
+		inputs:       make([]string, 0, len(tx.TxIn)),
+		walletInputs: make(map[string]int64),
+		outputs:      make([]string, 0),
+	}
+
+	b.mx.Lock()
+	// outputs of sent transaction are spendable before inclusion
+	for i := range tx.TxIn {
+		key := outpointKey(tx.TxIn[i].PreviousOutPoint.Hash.String(), tx.TxIn[i].PreviousOutPoint.Index)
+		pending.inputs = append(pending.inputs, key)
+		if value, ok := b.unspents[key]; ok {
+			pending.walletInputs[key] = value
+			delete(b.unspents, key)
+		}
+	}
+	for i := range tx.TxOut {
+		if bytes.Equal(tx.TxOut[i].PkScript, b.walletScript) {
+			key := outpointKey(hash, uint32(i))
+			pending.outputs = append(pending.outputs, key)
+			b.unspents[key] = tx.TxOut[i].Value
+		}
+	}
+	b.pending[hash] = pending
+	b.mx.Unlock()
+
+	b.operations <- chain.Operation{
+		Status:       chain.Pending,
+		Hash:         hash,
+		ChainType:    chain.ChainTypeBitcoin,
+		HashedSecret: hashedSecret,
+	}
+	return nil
+}
+
+// feeRate - returns fee rate in satoshi per virtual byte
+func (b *Bitcoin) feeRate(ctx context.Context) (int64, error) {
+	estimation, err := b.rpc.EstimateSmartFee(ctx, 6)
+	if err != nil {
+		return 0, errors.Wrap(err, "EstimateSmartFee")
+	}
+	if len(estimation.Errors) > 0 || estimation.FeeRate <= 0 {
+		return b.cfg.FeeRate, nil
+	}
+	rate := decimal.NewFromFloat(estimation.FeeRate).Shift(8).Div(decimal.NewFromInt(1000)).Ceil().IntPart()
+	if rate < b.cfg.FeeRate {
+		return b.cfg.FeeRate, nil
+	}
+	return rate, nil
+}
+
+func (b *Bitcoin) walletUnspents() map[string]int64 {
+	b.mx.RLock()
+	defer b.mx.RUnlock()
+
+	unspents := make(map[string]int64, len(b.unspents))
+	for key, value := range b.unspents {
+		unspents[key] = value
+	}
+	return unspents
+}
+
+func (b *Bitcoin) contractByHashedSecret(hashedSecret chain.Hex) (*contract, error) {
+	data, err := hashedSecret.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	b.mx.RLock()
+	defer b.mx.RUnlock()
+
+	for _, c := range b.contracts {
+		if bytes.Equal(c.HashedSecret, data) {
+			return c, nil
+		}
+	}
+	return nil, errors.Errorf("unknown HTLC with hashed secret %s", hashedSecret)
+}
+
+func (b *Bitcoin) spendableContract(address string) (*contract, error) {
+	b.mx.RLock()
+	defer b.mx.RUnlock()
+
+	c, ok := b.contracts[address]
+	if !ok {
+		return nil, errors.Errorf("unknown HTLC: %s", address)
+	}
+	if c.outpoint == "" {
+		return nil, errors.Errorf("HTLC %s is not initiated yet", address)
+	}
+	if c.spent {
+		return nil, errors.Errorf("HTLC %s is spent already", address)
+	}
+	return c, nil
+}
+
+// Restore - scans blocks after `fromLevel` for watched HTLCs. If `fromLevel` is 0, scanning starts from `StartLevel` of config.
+// Unspent HTLCs which are funded before the start level are found in the UTXO set and spent ones are found by witness script.
+func (b *Bitcoin) Restore(ctx context.Context, fromLevel uint64) error {
+	b.log.Info().Uint64("from_level", fromLevel).Msg("restoring...")
+
+	head, err := b.rpc.BlockCount(ctx)
+	if err != nil {
+		return errors.Wrap(err, "BlockCount")
+	}
+
+	b.level = b.confirmedLevel(head)
+	start := fromLevel + 1
+	if fromLevel == 0 {
+		if b.cfg.StartLevel == 0 {
+			start = b.level + 1
+		} else {
+			start = b.cfg.StartLevel
+		}
+	}
+
+	b.mx.Lock()
+	b.scanFrom = start
+	unfunded := b.unfunded()
+	b.mx.Unlock()
+
+	if err := b.lookupFunding(ctx, unfunded); err != nil {
+		return err
+	}
+
+	for level := start; level <= b.level; level++ {
+		if err := b.processLevel(ctx, level); err != nil {
+			return err
+		}
+	}
+
+	b.events <- chain.RestoredEvent{Chain: chain.ChainTypeBitcoin, BlockNumber: b.level}
+	return nil
+}
+
+func (b *Bitcoin) listen(ctx context.Context) {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(b.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := b.sync(ctx); err != nil {
+				b.log.Err(err).Msg("sync")
+			}
+			if err := b.checkPending(ctx); err != nil {
+				b.log.Err(err).Msg("checkPending")
+			}
+			// retries failed rescan
+			if err := b.rescanWatched(ctx); err != nil {
+				b.log.Err(err).Msg("rescan")
+			}
+		case <-b.rescan:
+			if err := b.rescanWatched(ctx); err != nil {
+				b.log.Err(err).Msg("rescan")
+			}
+		}
+	}
+}
+
+func (b *Bitcoin) requestRescan() {
+	select {
+	case b.rescan <- struct{}{}:
+	default:
+	}
+}
+
+// unfunded - returns addresses of HTLCs whose funding isn't found yet. It should be called under lock.
+func (b *Bitcoin) unfunded() []string {
+	addresses := make([]string, 0)
+	for address, c := range b.contracts {
+		if c.outpoint == "" && !c.spent {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
+// rescanWatched - looks up HTLCs which are watched after start. Unspent HTLC is found in the UTXO set, spent one is found by scanning of blocks processed since start.
+func (b *Bitcoin) rescanWatched(ctx context.Context) error {
+	b.mx.Lock()
+	addresses := b.unscanned
+	b.unscanned = nil
+	scanFrom := b.scanFrom
+	b.mx.Unlock()
+
+	if len(addresses) == 0 {
+		return nil
+	}
+
+	if err := b.scanWatched(ctx, addresses, scanFrom); err != nil {
+		b.mx.Lock()
+		b.unscanned = append(b.unscanned, addresses...)
+		b.mx.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (b *Bitcoin) scanWatched(ctx context.Context, addresses []string, scanFrom uint64) error {
+	if err := b.lookupFunding(ctx, addresses); err != nil {
+		return err
+	}
+
+	b.mx.RLock()
+	var notFound bool
+	for i := range addresses {
+		if c, ok := b.contracts[addresses[i]]; ok && c.outpoint == "" && !c.spent {
+			notFound = true
+			break
+		}
+	}
+	b.mx.RUnlock()
+
+	if !notFound {
+		return nil
+	}
+
+	// blocks are processed again, but known HTLCs and transactions aren't reported twice
+	for level := scanFrom; level <= b.level; level++ {
+		if err := b.processLevel(ctx, level); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookupFunding - finds unspent HTLCs in the UTXO set
+func (b *Bitcoin) lookupFunding(ctx context.Context, addresses []string) error {
+	if len(addresses) == 0 {
+		return nil
+	}
+
+	result, err := b.rpc.ScanTxOutSet(ctx, addresses...)
+	if err != nil {
+		return errors.Wrap(err, "ScanTxOutSet")
+	}
+
+	events, err := b.applyUnspents(result.Unspents)
+	if err != nil {
+		return err
+	}
+	for i := range events {
+		b.events <- events[i]
+	}
+	return nil
+}
+
+// applyUnspents - registers funding of HTLCs which are found in the UTXO set. Outputs of unprocessed blocks are skipped because they are processed with their blocks.
+func (b *Bitcoin) applyUnspents(unspents []Unspent) ([]chain.Event, error) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	events := make([]chain.Event, 0)
+	for i := range unspents {
+		if unspents[i].Height > b.level {
+			continue
+		}
+		event, err := b.processOutput(nil, unspents[i].Height, unspents[i].TxID, unspents[i].Vout, unspents[i].ScriptPubKey, unspents[i].Amount)
+		if err != nil {
+			return nil, err
+		}
+		if event != nil {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// sync - processes blocks which have enough confirmations. If processed blocks are reorganized, their changes are rolled back and blocks of the new branch are processed.
+func (b *Bitcoin) sync(ctx context.Context) error {
+	head, err := b.rpc.BlockCount(ctx)
+	if err != nil {
+		return errors.Wrap(err, "BlockCount")
+	}
+	for level := b.level + 1; level <= b.confirmedLevel(head); level++ {
+		block, err := b.block(ctx, level)
+		if err != nil {
+			return err
+		}
+		if b.isReorged(block) {
+			fork, err := b.findFork(ctx)
+			if err != nil {
+				return errors.Wrap(err, "findFork")
+			}
+			b.log.Warn().Uint64("level", level).Uint64("fork", fork).Msg("reorg: processed blocks are rolled back")
+			b.rollback(fork)
+			level = fork
+			continue
+		}
+		if err := b.processBlock(block); err != nil {
+			return err
+		}
+		b.level = level
+	}
+	b.pruneBlocks()
+	return nil
+}
+
+func (b *Bitcoin) block(ctx context.Context, level uint64) (Block, error) {
+	hash, err := b.rpc.BlockHash(ctx, level)
+	if err != nil {
+		return Block{}, errors.Wrapf(err, "BlockHash %d", level)
+	}
+	block, err := b.rpc.Block(ctx, hash)
+	if err != nil {
+		return Block{}, errors.Wrapf(err, "Block %s", hash)
+	}
+	return block, nil
+}
+
+func (b *Bitcoin) processLevel(ctx context.Context, level uint64) error {
+	block, err := b.block(ctx, level)
+	if err != nil {
+		return err
+	}
+	return b.processBlock(block)
+}
+
+// processBlock - applies block to watched HTLCs, wallet outputs and sent transactions. Operations and events are sent after unlocking.
+func (b *Bitcoin) processBlock(block Block) error {
+	operations, events, err := b.applyBlock(block)
+	if err != nil {
+		return err
+	}
+	for i := range operations {
+		b.operations <- operations[i]
+	}
+	for i := range events {
+		b.events <- events[i]
+	}
+	return nil
+}
+
+func (b *Bitcoin) applyBlock(block Block) ([]chain.Operation, []chain.Event, error) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	changes := b.changesOf(block)
+	spenders := b.pendingSpenders()
+
+	operations := make([]chain.Operation, 0)
+	events := make([]chain.Event, 0)
+	for i := range block.Tx {
+		tx := block.Tx[i]
+
+		if pending, ok := b.pending[tx.TxID]; ok {
+			delete(b.pending, tx.TxID)
+			changes.applied[tx.TxID] = pending
+			operations = append(operations, chain.Operation{
+				Status:       chain.Applied,
+				Hash:         tx.TxID,
+				ChainType:    chain.ChainTypeBitcoin,
+				HashedSecret: pending.hashedSecret,
+				Fee:          decimal.NewFromInt(pending.fee),
+			})
+		}
+
+		for j := range tx.Vin {
+			// sent transaction which spends the same output can't be included anymore
+			if txID, ok := spenders[outpointKey(tx.Vin[j].TxID, tx.Vin[j].Vout)]; ok && txID != tx.TxID {
+				b.log.Warn().Str("tx", txID).Str("spender", tx.TxID).Msg("transaction is double-spent")
+				operations = append(operations, b.failPending(txID, false)...)
+			}
+
+			event, err := b.processInput(changes, block.Height, tx.Vin[j])
+			if err != nil {
+				return nil, nil, err
+			}
+			if event != nil {
+				events = append(events, event)
+			}
+		}
+
+		for j := range tx.Vout {
+			event, err := b.processOutput(changes, block.Height, tx.TxID, tx.Vout[j].N, tx.Vout[j].ScriptPubKey.Hex, tx.Vout[j].Value)
+			if err != nil {
+				return nil, nil, err
+			}
+			if event != nil {
+				events = append(events, event)
+			}
+		}
+	}
+	return operations, events, nil
+}
+
+// processOutput - returns init event if output funds watched HTLC. It should be called under lock.
+func (b *Bitcoin) processOutput(changes *blockChanges, level uint64, txID string, index uint32, script string, value json.Number) (chain.Event, error) {
+	pkScript, err := hex.DecodeString(script)
+	if err != nil {
+		return nil, err
+	}
+	_, addresses, _, err := txscript.ExtractPkScriptAddrs(pkScript, b.params)
+	if err != nil || len(addresses) != 1 {
+		return nil, nil
+	}
+
+	address := addresses[0].EncodeAddress()
+	if address == b.address.EncodeAddress() {
+		amount, err := toSatoshi(value.String())
+		if err != nil {
+			return nil, err
+		}
+		key := outpointKey(txID, index)
+		if _, ok := b.unspents[key]; !ok {
+			changes.addUnspent(key)
+		}
+		b.unspents[key] = amount
+		return nil, nil
+	}
+
+	c, ok := b.contracts[address]
+	if !ok || c.outpoint != "" || c.spent {
+		return nil, nil
+	}
+
+	amount, err := toSatoshi(value.String())
+	if err != nil {
+		return nil, err
+	}
+	c.outpoint = outpointKey(txID, index)
+	c.amount = amount
+	b.byOutpoint[c.outpoint] = c
+	changes.fund(c)
+
+	return chain.InitEvent{
+		HashedSecretHex: chain.NewHexFromBytes(c.HashedSecret),
+		ContractAddress: c.address,
+		Chain:           chain.ChainTypeBitcoin,
+		BlockNumber:     level,
+		Initiator:       c.initiator,
+		Participant:     c.participant,
+		Amount:          decimal.NewFromInt(amount),
+		PayOff:          decimal.Zero,
+		RefundTime:      c.RefundTime,
+	}, nil
+}
+
+// processInput - returns redeem or refund event if input spends watched HTLC. It should be called under lock.
+func (b *Bitcoin) processInput(changes *blockChanges, level uint64, input Vin) (chain.Event, error) {
+	key := outpointKey(input.TxID, input.Vout)
+	if value, ok := b.unspents[key]; ok {
+		delete(b.unspents, key)
+		changes.spendUnspent(key, value)
+	}
+
+	c, ok := b.byOutpoint[key]
+	if !ok && len(input.TxInWitness) != 4 && len(input.TxInWitness) != 5 {
+		return nil, nil
+	}
+
+	witness := make([][]byte, len(input.TxInWitness))
+	for i := range input.TxInWitness {
+		data, err := hex.DecodeString(input.TxInWitness[i])
+		if err != nil {
+			return nil, err
+		}
+		witness[i] = data
+	}
+
+	spend, secret, script := ParseWitness(witness)
+	if !ok {
+		// funding of HTLC may be before the first processed level, so HTLC is found by its script
+		if spend == SpendUnknown {
+			return nil, nil
+		}
+		c, ok = b.contractByScript(script)
+		if !ok || c.spent {
+			return nil, nil
+		}
+	}
+
+	delete(b.byOutpoint, c.outpoint)
+	c.spent = true
+	changes.spend(c)
+
+	switch spend {
+	case SpendRedeem:
+		return chain.RedeemEvent{
+			HashedSecretHex: chain.NewHexFromBytes(c.HashedSecret),
+			ContractAddress: c.address,
+			Chain:           chain.ChainTypeBitcoin,
+			BlockNumber:     level,
+			Secret:          chain.NewHexFromBytes(secret),
+		}, nil
+	case SpendRefund:
+		return chain.RefundEvent{
+			HashedSecretHex: chain.NewHexFromBytes(c.HashedSecret),
+			ContractAddress: c.address,
+			Chain:           chain.ChainTypeBitcoin,
+			BlockNumber:     level,
+		}, nil
+	default:
+		b.log.Warn().Str("contract", c.address).Msg("unknown HTLC spending")
+		return nil, nil
+	}
+}
+
+func (b *Bitcoin) contractByScript(script []byte) (*contract, bool) {
+	scriptHash := sha256.Sum256(script)
+	address, err := btcutil.NewAddressWitnessScriptHash(scriptHash[:], b.params)
+	if err != nil {
+		return nil, false
+	}
+	c, ok := b.contracts[address.EncodeAddress()]
+	return c, ok
+}
+
+func outpointKey(txID string, index uint32) string {
+	return fmt.Sprintf("%s:%d", txID, index)
+}
+
+func parseOutpoint(key string) (*wire.OutPoint, error) {
+	var (
+		txID  string
+		index uint32
+	)
+	if _, err := fmt.Sscanf(key, "%64s:%d", &txID, &index); err != nil {
+		return nil, errors.Wrapf(err, "invalid outpoint: %s", key)
+	}
+	return newOutpoint(txID, index)
+}
+
+func newOutpoint(txID string, index uint32) (*wire.OutPoint, error) {
+	hash, err := chainhash.NewHashFromStr(txID)
+	if err != nil {
+		return nil, err
+	}
+	return wire.NewOutPoint(hash, index), nil
+}
+
+func toSatoshi(btc string) (int64, error) {
+	value, err := decimal.NewFromString(btc)
+	if err != nil {
+		return 0, err
+	}
+	return value.Shift(8).IntPart(), nil
+}
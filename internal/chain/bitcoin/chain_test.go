@@ -0,0 +1,143 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/atomex-protocol/watch_tower/internal/chain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitcoin_processInput(t *testing.T) {
+	params := &chaincfg.RegressionNetParams
+	initiator, err := btcutil.NewAddressWitnessPubKeyHash(bytes.Repeat([]byte{0x22}, 20), params)
+	require.NoError(t, err)
+	participant, err := btcutil.NewAddressWitnessPubKeyHash(bytes.Repeat([]byte{0x33}, 20), params)
+	require.NoError(t, err)
+
+	b := &Bitcoin{
+		params:     params,
+		log:        zerolog.Nop(),
+		contracts:  make(map[string]*contract),
+		byOutpoint: make(map[string]*contract),
+		unspents:   make(map[string]int64),
+		events:     make(chan chain.Event, 16),
+	}
+
+	secret := bytes.Repeat([]byte{0x11}, SecretSize)
+	hashedSecret := chain.NewHexFromBytes(HashSecret(secret))
+	require.NoError(t, b.Watch(chain.WatchArgs{
+		HashedSecret: hashedSecret,
+		Initiator:    initiator.EncodeAddress(),
+		Participant:  participant.EncodeAddress(),
+		RefundTime:   time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC),
+	}))
+	c, err := b.contractByHashedSecret(hashedSecret)
+	require.NoError(t, err)
+
+	// funding of HTLC isn't processed, so redeem is found by witness script only
+	witness := RedeemWitness([]byte{0x30}, []byte{0x02}, secret, c.script)
+	input := Vin{TxID: "aa", Vout: 0}
+	for i := range witness {
+		input.TxInWitness = append(input.TxInWitness, hex.EncodeToString(witness[i]))
+	}
+
+	processed, err := b.processInput(nil, 100, input)
+	require.NoError(t, err)
+	event, ok := processed.(chain.RedeemEvent)
+	require.True(t, ok)
+	assert.Equal(t, hashedSecret, event.HashedSecretHex)
+	assert.Equal(t, chain.NewHexFromBytes(secret), event.Secret)
+	assert.Equal(t, c.address, event.ContractAddress)
+
+	// rescanned block doesn't report the redeem twice
+	processed, err = b.processInput(nil, 100, input)
+	require.NoError(t, err)
+	assert.Nil(t, processed)
+}
+
+func TestBitcoin_rollback(t *testing.T) {
+	params := &chaincfg.RegressionNetParams
+	wallet, err := btcutil.NewAddressWitnessPubKeyHash(bytes.Repeat([]byte{0x22}, 20), params)
+	require.NoError(t, err)
+	walletScript, err := txscript.PayToAddrScript(wallet)
+	require.NoError(t, err)
+	participant, err := btcutil.NewAddressWitnessPubKeyHash(bytes.Repeat([]byte{0x33}, 20), params)
+	require.NoError(t, err)
+
+	b := &Bitcoin{
+		params:     params,
+		log:        zerolog.Nop(),
+		address:    wallet,
+		contracts:  make(map[string]*contract),
+		byOutpoint: make(map[string]*contract),
+		unspents:   map[string]int64{outpointKey("aa", 0): 5000},
+		pending:    make(map[string]pendingTx),
+		blocks:     make(map[uint64]*blockChanges),
+		events:     make(chan chain.Event, 16),
+		operations: make(chan chain.Operation, 16),
+	}
+
+	hashedSecret := chain.NewHexFromBytes(HashSecret(bytes.Repeat([]byte{0x11}, SecretSize)))
+	require.NoError(t, b.Watch(chain.WatchArgs{
+		HashedSecret: hashedSecret,
+		Initiator:    wallet.EncodeAddress(),
+		Participant:  participant.EncodeAddress(),
+		RefundTime:   time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC),
+	}))
+	c, err := b.contractByHashedSecret(hashedSecret)
+	require.NoError(t, err)
+	b.pending["bb"] = pendingTx{hashedSecret: hashedSecret, fee: 200, inputs: []string{outpointKey("aa", 0)}}
+
+	// initiation spends wallet output and funds HTLC with change
+	require.NoError(t, b.processBlock(Block{
+		Hash:   "block101",
+		Height: 101,
+		Tx: []Tx{
+			{
+				TxID: "bb",
+				Vin:  []Vin{{TxID: "aa", Vout: 0}},
+				Vout: []Vout{
+					{Value: "0.00004", N: 0, ScriptPubKey: ScriptPubKey{Hex: hex.EncodeToString(c.pkScript)}},
+					{Value: "0.000008", N: 1, ScriptPubKey: ScriptPubKey{Hex: hex.EncodeToString(walletScript)}},
+				},
+			},
+		},
+	}))
+	b.level = 101
+	require.Len(t, b.operations, 1)
+	assert.Equal(t, chain.Applied, (<-b.operations).Status)
+	require.Len(t, b.events, 1)
+	assert.IsType(t, chain.InitEvent{}, <-b.events)
+
+	assert.True(t, b.isReorged(Block{Hash: "other102", PreviousBlockHash: "other101", Height: 102}))
+	assert.False(t, b.isReorged(Block{Hash: "block102", PreviousBlockHash: "block101", Height: 102}))
+
+	b.rollback(100)
+	assert.Equal(t, uint64(100), b.level)
+	assert.Empty(t, c.outpoint)
+	assert.Empty(t, b.byOutpoint)
+	assert.Equal(t, map[string]int64{outpointKey("aa", 0): 5000}, b.unspents)
+	assert.Contains(t, b.pending, "bb")
+	assert.Empty(t, b.blocks)
+
+	// the pending transaction is double-spent in the new branch
+	require.NoError(t, b.processBlock(Block{
+		Hash:   "other101",
+		Height: 101,
+		Tx:     []Tx{{TxID: "cc", Vin: []Vin{{TxID: "aa", Vout: 0}}}},
+	}))
+	require.Len(t, b.operations, 1)
+	operation := <-b.operations
+	assert.Equal(t, chain.Failed, operation.Status)
+	assert.Equal(t, "bb", operation.Hash)
+	assert.Empty(t, b.pending)
+	assert.Empty(t, b.unspents)
+}
@@ -0,0 +1,160 @@
+package bitcoin
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// count of the last processed blocks whose changes are kept for rollback on reorg
+const keptBlocks = 100
+
+// blockChanges - changes of watched HTLCs, wallet outputs and sent transactions made by processed block. They are rolled back if the block is reorganized.
+type blockChanges struct {
+	hash     string
+	funded   []*contract
+	spent    []*contract
+	unspents map[string]int64 // wallet outputs spent by the block
+	outputs  []string         // wallet outputs created by the block
+	applied  map[string]pendingTx
+}
+
+func newBlockChanges(hash string) *blockChanges {
+	return &blockChanges{
+		hash:     hash,
+		funded:   make([]*contract, 0),
+		spent:    make([]*contract, 0),
+		unspents: make(map[string]int64),
+		outputs:  make([]string, 0),
+		applied:  make(map[string]pendingTx),
+	}
+}
+
+// changes aren't recorded for outputs which are found in the UTXO set, so methods accept nil receiver
+
+func (changes *blockChanges) fund(c *contract) {
+	if changes != nil {
+		changes.funded = append(changes.funded, c)
+	}
+}
+
+func (changes *blockChanges) spend(c *contract) {
+	if changes != nil {
+		changes.spent = append(changes.spent, c)
+	}
+}
+
+func (changes *blockChanges) spendUnspent(key string, value int64) {
+	if changes != nil {
+		changes.unspents[key] = value
+	}
+}
+
+func (changes *blockChanges) addUnspent(key string) {
+	if changes != nil {
+		changes.outputs = append(changes.outputs, key)
+	}
+}
+
+// changesOf - returns changes of block. Block which is processed again (e.g. by rescan) extends its changes. It should be called under lock.
+func (b *Bitcoin) changesOf(block Block) *blockChanges {
+	changes, ok := b.blocks[block.Height]
+	if !ok || changes.hash != block.Hash {
+		changes = newBlockChanges(block.Hash)
+		b.blocks[block.Height] = changes
+	}
+	return changes
+}
+
+// isReorged - checks if block doesn't follow the processed block of previous level
+func (b *Bitcoin) isReorged(block Block) bool {
+	b.mx.RLock()
+	defer b.mx.RUnlock()
+
+	previous, ok := b.blocks[block.Height-1]
+	return ok && previous.hash != block.PreviousBlockHash
+}
+
+// findFork - returns the last processed level whose block is still in the main chain. Blocks older than kept ones are considered as final.
+func (b *Bitcoin) findFork(ctx context.Context) (uint64, error) {
+	for level := b.level; level > 0; level-- {
+		b.mx.RLock()
+		changes, ok := b.blocks[level]
+		b.mx.RUnlock()
+		if !ok {
+			return level, nil
+		}
+
+		hash, err := b.rpc.BlockHash(ctx, level)
+		if err != nil {
+			return 0, errors.Wrapf(err, "BlockHash %d", level)
+		}
+		if hash == changes.hash {
+			return level, nil
+		}
+	}
+	return 0, nil
+}
+
+// rollback - reverts changes of processed blocks after `fork` in reverse order. Sent events can't be retracted, so they are only logged.
+// Transactions which were applied in reverted blocks become pending again: they are applied by new blocks or reported as failed if they leave the mempool.
+func (b *Bitcoin) rollback(fork uint64) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	for level := b.level; level > fork; level-- {
+		changes, ok := b.blocks[level]
+		if !ok {
+			continue
+		}
+		delete(b.blocks, level)
+
+		for i := len(changes.spent) - 1; i >= 0; i-- {
+			c := changes.spent[i]
+			c.spent = false
+			if c.outpoint != "" {
+				b.byOutpoint[c.outpoint] = c
+			}
+			b.log.Warn().Str("contract", c.address).Uint64("level", level).Msg("HTLC spending was removed by reorg, but its event was already sent")
+		}
+		for i := len(changes.funded) - 1; i >= 0; i-- {
+			c := changes.funded[i]
+			delete(b.byOutpoint, c.outpoint)
+			c.outpoint = ""
+			c.amount = 0
+			b.log.Warn().Str("contract", c.address).Uint64("level", level).Msg("HTLC funding was removed by reorg, but its event was already sent")
+		}
+
+		for _, key := range changes.outputs {
+			delete(b.unspents, key)
+		}
+		for key, value := range changes.unspents {
+			b.unspents[key] = value
+		}
+		for txID, pending := range changes.applied {
+			b.pending[txID] = pending
+			b.log.Warn().Str("tx", txID).Uint64("level", level).Msg("applied transaction was removed by reorg, it's pending again")
+		}
+	}
+	b.level = fork
+}
+
+// pruneBlocks - forgets changes of blocks which are too deep to be reorganized
+func (b *Bitcoin) pruneBlocks() {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	for level := range b.blocks {
+		if level+keptBlocks <= b.level {
+			delete(b.blocks, level)
+		}
+	}
+}
+
+// confirmedLevel - returns the last level which has enough confirmations at `head`
+func (b *Bitcoin) confirmedLevel(head uint64) uint64 {
+	if head < b.cfg.Confirmations {
+		return 0
+	}
+	return head - b.cfg.Confirmations
+}
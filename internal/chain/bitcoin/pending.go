@@ -0,0 +1,131 @@
+package bitcoin
+
+import (
+	"context"
+	"strings"
+
+	"github.com/atomex-protocol/watch_tower/internal/chain"
+	"github.com/pkg/errors"
+)
+
+// pendingTx - transaction sent by the wallet which is waiting for inclusion. `fee` is in satoshi.
+// `walletInputs` are values of spent wallet outputs which are restored if transaction is dropped. `outputs` are wallet outputs created by transaction.
+type pendingTx struct {
+	hashedSecret chain.Hex
+	fee          int64
+	inputs       []string
+	walletInputs map[string]int64
+	outputs      []string
+}
+
+// checkPending - reports sent transactions which left the mempool without inclusion as failed. Transaction is dropped
+// if it isn't in the mempool and one of its inputs is unspent in the chain, so it can't be included in a block which isn't processed yet.
+func (b *Bitcoin) checkPending(ctx context.Context) error {
+	b.mx.RLock()
+	pending := make(map[string][]string, len(b.pending))
+	for txID, tx := range b.pending {
+		pending[txID] = tx.inputs
+	}
+	b.mx.RUnlock()
+
+	dropped := make([]string, 0)
+	for txID, inputs := range pending {
+		inMempool, err := b.rpc.InMempool(ctx, txID)
+		if err != nil {
+			return errors.Wrap(err, "InMempool")
+		}
+		if inMempool {
+			continue
+		}
+		unspent, err := b.hasUnspentInput(ctx, inputs)
+		if err != nil {
+			return err
+		}
+		if unspent {
+			dropped = append(dropped, txID)
+		}
+	}
+	if len(dropped) == 0 {
+		return nil
+	}
+
+	b.mx.Lock()
+	operations := make([]chain.Operation, 0)
+	for _, txID := range dropped {
+		b.log.Warn().Str("tx", txID).Msg("transaction left the mempool without inclusion")
+		operations = append(operations, b.failPending(txID, true)...)
+	}
+	b.mx.Unlock()
+
+	for i := range operations {
+		b.operations <- operations[i]
+	}
+	return nil
+}
+
+func (b *Bitcoin) hasUnspentInput(ctx context.Context, inputs []string) (bool, error) {
+	for _, key := range inputs {
+		outpoint, err := parseOutpoint(key)
+		if err != nil {
+			return false, err
+		}
+		out, err := b.rpc.TxOut(ctx, outpoint.Hash.String(), outpoint.Index, false)
+		if err != nil {
+			return false, errors.Wrap(err, "TxOut")
+		}
+		if out != nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// pendingSpenders - returns sent transactions by outpoints which they spend. It should be called under lock.
+func (b *Bitcoin) pendingSpenders() map[string]string {
+	spenders := make(map[string]string)
+	for txID, tx := range b.pending {
+		for _, key := range tx.inputs {
+			spenders[key] = txID
+		}
+	}
+	return spenders
+}
+
+// failPending - forgets dropped or double-spent transaction and returns failed operations of it and pending transactions which spend its outputs.
+// Spent wallet outputs are restored if `restoreInputs` is set. It should be called under lock.
+func (b *Bitcoin) failPending(txID string, restoreInputs bool) []chain.Operation {
+	tx, ok := b.pending[txID]
+	if !ok {
+		return nil
+	}
+	delete(b.pending, txID)
+
+	operations := []chain.Operation{
+		{
+			Status:       chain.Failed,
+			Hash:         txID,
+			ChainType:    chain.ChainTypeBitcoin,
+			HashedSecret: tx.hashedSecret,
+		},
+	}
+
+	// descendants can't be included without the transaction. They are failed first, so they don't restore outputs of the transaction.
+	for childID, child := range b.pending {
+		for _, key := range child.inputs {
+			if strings.HasPrefix(key, txID+":") {
+				operations = append(operations, b.failPending(childID, restoreInputs)...)
+				break
+			}
+		}
+	}
+
+	for _, key := range tx.outputs {
+		delete(b.unspents, key)
+	}
+	if restoreInputs {
+		for key, value := range tx.walletInputs {
+			b.unspents[key] = value
+		}
+	}
+	return operations
+}
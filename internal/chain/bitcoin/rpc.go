@@ -0,0 +1,224 @@
+package bitcoin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RPC - client of bitcoind-compatible JSON RPC
+type RPC struct {
+	url      string
+	user     string
+	password string
+	client   *http.Client
+	id       uint64
+}
+
+// NewRPC -
+func NewRPC(url, user, password string) *RPC {
+	return &RPC{
+		url:      url,
+		user:     user,
+		password: password,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      uint64        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+}
+
+// code of RPC error which is returned if requested transaction is unknown
+const errCodeInvalidAddressOrKey = -5
+
+// RPCError -
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error -
+func (e RPCError) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+func (rpc *RPC) call(ctx context.Context, method string, output interface{}, params ...interface{}) error {
+	if params == nil {
+		params = make([]interface{}, 0)
+	}
+	body, err := json.Marshal(rpcRequest{
+		JSONRPC: "1.0",
+		ID:      atomic.AddUint64(&rpc.id, 1),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rpc.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if rpc.user != "" {
+		req.SetBasicAuth(rpc.user, rpc.password)
+	}
+
+	resp, err := rpc.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var response rpcResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return errors.Wrapf(err, "%s: invalid response with status %s", method, resp.Status)
+	}
+	if response.Error != nil {
+		return errors.Wrap(*response.Error, method)
+	}
+	if output == nil {
+		return nil
+	}
+	return json.Unmarshal(response.Result, output)
+}
+
+// BlockCount -
+func (rpc *RPC) BlockCount(ctx context.Context) (count uint64, err error) {
+	err = rpc.call(ctx, "getblockcount", &count)
+	return
+}
+
+// BlockHash -
+func (rpc *RPC) BlockHash(ctx context.Context, height uint64) (hash string, err error) {
+	err = rpc.call(ctx, "getblockhash", &hash, height)
+	return
+}
+
+// Block - returns block with decoded transactions
+func (rpc *RPC) Block(ctx context.Context, hash string) (block Block, err error) {
+	err = rpc.call(ctx, "getblock", &block, hash, 2)
+	return
+}
+
+// SendRawTransaction - returns hash of sent transaction
+func (rpc *RPC) SendRawTransaction(ctx context.Context, tx string) (hash string, err error) {
+	err = rpc.call(ctx, "sendrawtransaction", &hash, tx)
+	return
+}
+
+// EstimateSmartFee - returns fee rate in BTC/kvB which is needed to confirm transaction during `blocks`
+func (rpc *RPC) EstimateSmartFee(ctx context.Context, blocks int) (fee FeeEstimation, err error) {
+	err = rpc.call(ctx, "estimatesmartfee", &fee, blocks)
+	return
+}
+
+// ScanTxOutSet - returns unspent outputs of addresses
+func (rpc *RPC) ScanTxOutSet(ctx context.Context, addresses ...string) (result ScanResult, err error) {
+	descriptors := make([]string, len(addresses))
+	for i := range addresses {
+		descriptors[i] = fmt.Sprintf("addr(%s)", addresses[i])
+	}
+	err = rpc.call(ctx, "scantxoutset", &result, "start", descriptors)
+	return
+}
+
+// TxOut - returns unspent output. If `includeMempool` is set, output which is spent by mempool transaction is nil.
+func (rpc *RPC) TxOut(ctx context.Context, txID string, vout uint32, includeMempool bool) (out *TxOut, err error) {
+	err = rpc.call(ctx, "gettxout", &out, txID, vout, includeMempool)
+	return
+}
+
+// InMempool - checks if transaction is in the mempool of node
+func (rpc *RPC) InMempool(ctx context.Context, txID string) (bool, error) {
+	err := rpc.call(ctx, "getmempoolentry", nil, txID)
+	var rpcErr RPCError
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.As(err, &rpcErr) && rpcErr.Code == errCodeInvalidAddressOrKey:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// Block -
+type Block struct {
+	Hash              string `json:"hash"`
+	PreviousBlockHash string `json:"previousblockhash"`
+	Height            uint64 `json:"height"`
+	Time              int64  `json:"time"`
+	Tx                []Tx   `json:"tx"`
+}
+
+// Tx -
+type Tx struct {
+	TxID string `json:"txid"`
+	Vin  []Vin  `json:"vin"`
+	Vout []Vout `json:"vout"`
+}
+
+// Vin -
+type Vin struct {
+	TxID        string   `json:"txid"`
+	Vout        uint32   `json:"vout"`
+	TxInWitness []string `json:"txinwitness"`
+}
+
+// Vout -
+type Vout struct {
+	Value        json.Number  `json:"value"`
+	N            uint32       `json:"n"`
+	ScriptPubKey ScriptPubKey `json:"scriptPubKey"`
+}
+
+// ScriptPubKey -
+type ScriptPubKey struct {
+	Hex string `json:"hex"`
+}
+
+// FeeEstimation -
+type FeeEstimation struct {
+	FeeRate float64  `json:"feerate"`
+	Errors  []string `json:"errors"`
+}
+
+// ScanResult -
+type ScanResult struct {
+	Success  bool      `json:"success"`
+	Unspents []Unspent `json:"unspents"`
+}
+
+// Unspent -
+type Unspent struct {
+	TxID         string      `json:"txid"`
+	Vout         uint32      `json:"vout"`
+	ScriptPubKey string      `json:"scriptPubKey"`
+	Amount       json.Number `json:"amount"`
+	Height       uint64      `json:"height"`
+}
+
+// TxOut -
+type TxOut struct {
+	Value         json.Number `json:"value"`
+	Confirmations uint64      `json:"confirmations"`
+}
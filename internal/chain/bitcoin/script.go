@@ -0,0 +1,171 @@
+package bitcoin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcutil"
+	"github.com/pkg/errors"
+)
+
+// SecretSize - size of Atomex secret in bytes
+const SecretSize = 32
+
+// errors
+var (
+	ErrInvalidScript = errors.New("script is not Atomex HTLC")
+)
+
+// HTLC - parameters of Atomex hashed time locked contract. The script is:
+//
+//	OP_IF
+//		<refund time> OP_CHECKLOCKTIMEVERIFY OP_DROP OP_DUP OP_HASH160 <refund pubkey hash> OP_EQUALVERIFY OP_CHECKSIG
+//	OP_ELSE
+//		OP_SIZE 32 OP_EQUALVERIFY OP_HASH256 <hashed secret> OP_EQUALVERIFY OP_DUP OP_HASH160 <redeem pubkey hash> OP_EQUALVERIFY OP_CHECKSIG
+//	OP_ENDIF
+type HTLC struct {
+	HashedSecret     []byte
+	RefundPubKeyHash []byte
+	RedeemPubKeyHash []byte
+	RefundTime       time.Time
+}
+
+// Script - builds redeem script of HTLC
+func (htlc HTLC) Script() ([]byte, error) {
+	if len(htlc.HashedSecret) != sha256.Size {
+		return nil, errors.Errorf("invalid hashed secret length: %d", len(htlc.HashedSecret))
+	}
+	if len(htlc.RefundPubKeyHash) != 20 || len(htlc.RedeemPubKeyHash) != 20 {
+		return nil, errors.New("invalid public key hash length")
+	}
+
+	return txscript.NewScriptBuilder().
+		AddOp(txscript.OP_IF).
+		AddInt64(htlc.RefundTime.Unix()).
+		AddOp(txscript.OP_CHECKLOCKTIMEVERIFY).
+		AddOp(txscript.OP_DROP).
+		AddOp(txscript.OP_DUP).
+		AddOp(txscript.OP_HASH160).
+		AddData(htlc.RefundPubKeyHash).
+		AddOp(txscript.OP_EQUALVERIFY).
+		AddOp(txscript.OP_CHECKSIG).
+		AddOp(txscript.OP_ELSE).
+		AddOp(txscript.OP_SIZE).
+		AddInt64(SecretSize).
+		AddOp(txscript.OP_EQUALVERIFY).
+		AddOp(txscript.OP_HASH256).
+		AddData(htlc.HashedSecret).
+		AddOp(txscript.OP_EQUALVERIFY).
+		AddOp(txscript.OP_DUP).
+		AddOp(txscript.OP_HASH160).
+		AddData(htlc.RedeemPubKeyHash).
+		AddOp(txscript.OP_EQUALVERIFY).
+		AddOp(txscript.OP_CHECKSIG).
+		AddOp(txscript.OP_ENDIF).
+		Script()
+}
+
+// Address - returns P2WSH address of HTLC
+func (htlc HTLC) Address(params *chaincfg.Params) (*btcutil.AddressWitnessScriptHash, error) {
+	script, err := htlc.Script()
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(script)
+	return btcutil.NewAddressWitnessScriptHash(hash[:], params)
+}
+
+// ParseScript - parses HTLC parameters from redeem script. Returns `ErrInvalidScript` if script is not Atomex HTLC.
+func ParseScript(script []byte) (HTLC, error) {
+	var htlc HTLC
+
+	pushes, err := txscript.PushedData(script)
+	if err != nil {
+		return htlc, err
+	}
+	// refund time, refund pubkey hash, secret size, hashed secret, redeem pubkey hash
+	if len(pushes) != 5 {
+		return htlc, ErrInvalidScript
+	}
+	refundTime, err := scriptNum(pushes[0])
+	if err != nil {
+		return htlc, err
+	}
+
+	htlc = HTLC{
+		RefundTime:       time.Unix(refundTime, 0).UTC(),
+		RefundPubKeyHash: pushes[1],
+		HashedSecret:     pushes[3],
+		RedeemPubKeyHash: pushes[4],
+	}
+
+	expected, err := htlc.Script()
+	if err != nil {
+		return htlc, ErrInvalidScript
+	}
+	if !bytes.Equal(expected, script) {
+		return htlc, ErrInvalidScript
+	}
+	return htlc, nil
+}
+
+// Spend - kind of HTLC spending
+type Spend int
+
+// spend kinds
+const (
+	SpendUnknown Spend = iota
+	SpendRedeem
+	SpendRefund
+)
+
+// ParseWitness - parses witness of input which spends HTLC. Returns kind of spending, secret in case of redeem and redeem script.
+func ParseWitness(witness [][]byte) (Spend, []byte, []byte) {
+	switch len(witness) {
+	case 5: // <signature> <public key> <secret> <empty> <script>
+		if len(witness[3]) == 0 && len(witness[2]) == SecretSize {
+			return SpendRedeem, witness[2], witness[4]
+		}
+	case 4: // <signature> <public key> <1> <script>
+		if bytes.Equal(witness[2], []byte{1}) {
+			return SpendRefund, nil, witness[3]
+		}
+	}
+	return SpendUnknown, nil, nil
+}
+
+// RedeemWitness -
+func RedeemWitness(signature, publicKey, secret, script []byte) [][]byte {
+	return [][]byte{signature, publicKey, secret, {}, script}
+}
+
+// RefundWitness -
+func RefundWitness(signature, publicKey, script []byte) [][]byte {
+	return [][]byte{signature, publicKey, {1}, script}
+}
+
+// HashSecret - returns double SHA256 of secret as OP_HASH256 does
+func HashSecret(secret []byte) []byte {
+	first := sha256.Sum256(secret)
+	second := sha256.Sum256(first[:])
+	return second[:]
+}
+
+// scriptNum - decodes minimally encoded little-endian script number with sign bit
+func scriptNum(data []byte) (int64, error) {
+	if len(data) == 0 || len(data) > 5 {
+		return 0, errors.Errorf("invalid script number length: %d", len(data))
+	}
+	var result int64
+	for i, b := range data {
+		result |= int64(b) << uint8(8*i)
+	}
+	if data[len(data)-1]&0x80 != 0 {
+		result &= ^(int64(0x80) << uint8(8*(len(data)-1)))
+		return -result, nil
+	}
+	return result, nil
+}
@@ -0,0 +1,66 @@
+package bitcoin
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTLC_Script(t *testing.T) {
+	secret := bytes.Repeat([]byte{0x11}, SecretSize)
+	htlc := HTLC{
+		HashedSecret:     HashSecret(secret),
+		RefundPubKeyHash: bytes.Repeat([]byte{0x22}, 20),
+		RedeemPubKeyHash: bytes.Repeat([]byte{0x33}, 20),
+		RefundTime:       time.Date(2022, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	script, err := htlc.Script()
+	require.NoError(t, err)
+
+	parsed, err := ParseScript(script)
+	require.NoError(t, err)
+	assert.Equal(t, htlc, parsed)
+
+	address, err := htlc.Address(&chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+	assert.Contains(t, address.EncodeAddress(), "bcrt1")
+
+	tests := []struct {
+		name       string
+		witness    [][]byte
+		wantSpend  Spend
+		wantSecret []byte
+	}{
+		{
+			name:       "redeem",
+			witness:    RedeemWitness([]byte{0x30}, []byte{0x02}, secret, script),
+			wantSpend:  SpendRedeem,
+			wantSecret: secret,
+		}, {
+			name:      "refund",
+			witness:   RefundWitness([]byte{0x30}, []byte{0x02}, script),
+			wantSpend: SpendRefund,
+		}, {
+			name:      "p2wpkh",
+			witness:   [][]byte{{0x30}, {0x02}},
+			wantSpend: SpendUnknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spend, gotSecret, _ := ParseWitness(tt.witness)
+			assert.Equal(t, tt.wantSpend, spend)
+			assert.Equal(t, tt.wantSecret, gotSecret)
+		})
+	}
+}
+
+func TestParseScript_Invalid(t *testing.T) {
+	_, err := ParseScript([]byte{0x51})
+	assert.ErrorIs(t, err, ErrInvalidScript)
+}
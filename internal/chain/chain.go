@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"math/big"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -23,19 +24,40 @@ type Chain interface {
 	Initiate(ctx context.Context, args InitiateArgs) error
 	Redeem(ctx context.Context, hashedSecret, secret Hex, contract string) error
 	Refund(ctx context.Context, hashedSecret Hex, contract string) error
-	Restore(ctx context.Context) error
+	Restore(ctx context.Context, fromLevel uint64) error
 	Wallet() Wallet
+	Balance(ctx context.Context, args BalanceArgs) (decimal.Decimal, error)
 
 	Events() <-chan Event
 	Operations() <-chan Operation
 }
 
+// Watcher - chain which can't find swaps by itself and has to know their parameters, e.g. Bitcoin where HTLC is hidden behind script hash.
+type Watcher interface {
+	Watch(args WatchArgs) error
+}
+
+// WatchArgs -
+type WatchArgs struct {
+	HashedSecret Hex
+	Initiator    string
+	Participant  string
+	RefundTime   time.Time
+}
+
+// BalanceArgs - `Contract` is a token address and empty `Contract` means native currency of chain. Balance is returned in minimal units of the currency.
+type BalanceArgs struct {
+	Contract string
+	TokenID  uint64
+}
+
 // InitiateArgs -
 type InitiateArgs struct {
 	HashedSecret Hex
 	Participant  string
 	Contract     string
 	TokenAddress string
+	TokenID      uint64
 	Amount       decimal.Decimal
 	PayOff       decimal.Decimal
 	RefundTime   time.Time
@@ -161,14 +183,15 @@ func (e *InitEvent) SetPayOff(payoff *big.Int, minPayoff decimal.Decimal) error
 	return nil
 }
 
-// RestoredEvent -
+// RestoredEvent - is sent when chain is restored. `BlockNumber` is the head level at restore start. All events up to it are already sent.
 type RestoredEvent struct {
-	Chain ChainType
+	Chain       ChainType
+	BlockNumber uint64
 }
 
 // Level -
 func (e RestoredEvent) Level() uint64 {
-	return 0
+	return e.BlockNumber
 }
 
 // Contract -
@@ -194,18 +217,68 @@ const (
 	ChainTypeUnknown ChainType = iota
 	ChainTypeTezos
 	ChainTypeEthereum
+	ChainTypeBitcoin
+)
+
+var (
+	chainTypes = map[string]ChainType{
+		"tezos":    ChainTypeTezos,
+		"ethereum": ChainTypeEthereum,
+		"bitcoin":  ChainTypeBitcoin,
+	}
+	chainTypeNames = map[ChainType]string{
+		ChainTypeTezos:    "tezos",
+		ChainTypeEthereum: "ethereum",
+		ChainTypeBitcoin:  "bitcoin",
+	}
+	chainTypesMx sync.RWMutex
 )
 
+// RegisterChainType - registers chain type with `name` if it's not registered yet and returns it. It's used for chains which are set in config, e.g. EVM networks.
+func RegisterChainType(name string) ChainType {
+	chainTypesMx.Lock()
+	defer chainTypesMx.Unlock()
+
+	if typ, ok := chainTypes[name]; ok {
+		return typ
+	}
+	typ := ChainType(len(chainTypeNames) + 1)
+	chainTypes[name] = typ
+	chainTypeNames[typ] = name
+	return typ
+}
+
+// ChainTypeFromString - returns registered chain type by its name. If it's not registered returns `ChainTypeUnknown`.
+func ChainTypeFromString(name string) ChainType {
+	chainTypesMx.RLock()
+	defer chainTypesMx.RUnlock()
+	return chainTypes[name]
+}
+
 // String -
 func (c ChainType) String() string {
-	switch c {
-	case ChainTypeTezos:
-		return "tezos"
-	case ChainTypeEthereum:
-		return "ethereum"
-	default:
-		return "unknown"
+	chainTypesMx.RLock()
+	defer chainTypesMx.RUnlock()
+
+	if name, ok := chainTypeNames[c]; ok {
+		return name
 	}
+	return "unknown"
+}
+
+// MarshalText - chain type is serialized by name because values of registered types depend on registration order.
+func (c ChainType) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText -
+func (c *ChainType) UnmarshalText(data []byte) error {
+	if name := string(data); name != "unknown" {
+		*c = RegisterChainType(name)
+	} else {
+		*c = ChainTypeUnknown
+	}
+	return nil
 }
 
 // ByLevel -
@@ -17,6 +17,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
@@ -26,6 +27,7 @@ import (
 // Ethereum -
 type Ethereum struct {
 	cfg     Config
+	rpc     *rpc.Client
 	client  *ethclient.Client
 	wss     *ethclient.Client
 	subLogs ethereum.Subscription
@@ -34,17 +36,28 @@ type Ethereum struct {
 	address    common.Address
 	privateKey *ecdsa.PrivateKey
 
-	ethContract   common.Address
-	erc20Contract common.Address
+	ethContract common.Address
 
 	eth       *AtomexEth
-	erc20     *AtomexErc20
+	erc20     map[string]*AtomexErc20
 	chainID   *big.Int
 	minPayoff *big.Int
 
 	log zerolog.Logger
 
-	latest int64
+	latest        int64
+	restoredLevel uint64
+	pending       map[logKey]chain.Event
+	sent          *sentLogs
+	parsedLevel   uint64 // the last level whose operation statuses are sent
+
+	nonces *nonceManager
+
+	pendingTxsMutex sync.Mutex
+	pendingTxs      map[pendingKey]*pendingTx
+
+	// allowance of vault is checked and consumed by initiations one by one
+	initiateErc20Mutex sync.Mutex
 
 	logs       chan types.Log
 	head       chan *types.Header
@@ -53,14 +66,22 @@ type Ethereum struct {
 	wg         sync.WaitGroup
 }
 
-// Config -
+// Config - settings of EVM network. Empty `Name`, `ChainType` and `SecretName` mean Ethereum mainnet settings.
 type Config struct {
-	NodeURL       string
-	WssURL        string
-	EthContract   string
-	Erc20Contract string
-	MinPayOff     string
-	LogLevel      zerolog.Level
+	Name           string
+	ChainType      chain.ChainType
+	ChainID        int64
+	Confirmations  uint64
+	SecretName     string
+	NodeURL        string
+	WssURL         string
+	EthContract    string
+	Erc20Contracts []string
+	MinPayOff      string
+	FeePolicy      FeePolicy
+	ApprovePolicy  string
+	ReplaceTimeout time.Duration
+	LogLevel       zerolog.Level
 }
 
 // New -
@@ -68,11 +89,29 @@ func New(cfg Config) (*Ethereum, error) {
 	if cfg.LogLevel == 0 {
 		cfg.LogLevel = zerolog.InfoLevel
 	}
+	if cfg.Name == "" {
+		cfg.Name = chain.ChainTypeEthereum.String()
+	}
+	if cfg.ChainType == chain.ChainTypeUnknown {
+		cfg.ChainType = chain.ChainTypeEthereum
+	}
+	if cfg.SecretName == "" {
+		cfg.SecretName = "ETHEREUM_PRIVATE"
+	}
 
-	client, err := ethclient.Dial(cfg.NodeURL)
+	cfg.FeePolicy = cfg.FeePolicy.withDefaults()
+	if cfg.ReplaceTimeout == 0 {
+		cfg.ReplaceTimeout = defaultReplaceTimeout
+	}
+	if cfg.ApprovePolicy == "" {
+		cfg.ApprovePolicy = ApprovePolicyUnlimited
+	}
+
+	rpcClient, err := rpc.Dial(cfg.NodeURL)
 	if err != nil {
 		return nil, err
 	}
+	client := ethclient.NewClient(rpcClient)
 
 	wss, err := ethclient.Dial(cfg.WssURL)
 	if err != nil {
@@ -84,10 +123,14 @@ func New(cfg Config) (*Ethereum, error) {
 	if err != nil {
 		return nil, err
 	}
-	erc20Contract := common.HexToAddress(cfg.Erc20Contract)
-	atomexErc20, err := NewAtomexErc20(erc20Contract, client)
-	if err != nil {
-		return nil, err
+	erc20 := make(map[string]*AtomexErc20)
+	for i := range cfg.Erc20Contracts {
+		address := common.HexToAddress(cfg.Erc20Contracts[i])
+		atomexErc20, err := NewAtomexErc20(address, client)
+		if err != nil {
+			return nil, err
+		}
+		erc20[address.Hex()] = atomexErc20
 	}
 
 	minPayoff := big.NewInt(0)
@@ -96,30 +139,40 @@ func New(cfg Config) (*Ethereum, error) {
 	}
 
 	eth := Ethereum{
-		cfg:           cfg,
-		minPayoff:     minPayoff,
-		client:        client,
-		eth:           atomexEth,
-		ethContract:   ethContract,
-		erc20:         atomexErc20,
-		erc20Contract: erc20Contract,
-		log:           logger.New(logger.WithLogLevel(cfg.LogLevel), logger.WithModuleName("ethereum")),
-		wss:           wss,
-		logs:          make(chan types.Log, 1024),
-		head:          make(chan *types.Header, 16),
-		events:        make(chan chain.Event, 1024),
-		operations:    make(chan chain.Operation, 1024),
+		cfg:         cfg,
+		minPayoff:   minPayoff,
+		rpc:         rpcClient,
+		client:      client,
+		eth:         atomexEth,
+		ethContract: ethContract,
+		erc20:       erc20,
+		log:         logger.New(logger.WithLogLevel(cfg.LogLevel), logger.WithModuleName(cfg.Name)),
+		wss:         wss,
+		logs:        make(chan types.Log, 1024),
+		head:        make(chan *types.Header, 16),
+		events:      make(chan chain.Event, 1024),
+		operations:  make(chan chain.Operation, 1024),
+		pending:     make(map[logKey]chain.Event),
+		sent:        newSentLogs(sentLogsLimit),
+		pendingTxs:  make(map[pendingKey]*pendingTx),
 	}
 
 	if err := initKeystore(&eth); err != nil {
 		return nil, err
 	}
+	eth.nonces = newNonceManager(eth.pendingNonce, eth.log)
 
 	return &eth, nil
 }
 
+func (e *Ethereum) pendingNonce(ctx context.Context) (uint64, error) {
+	nonceCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	return e.client.PendingNonceAt(nonceCtx, e.address)
+}
+
 func initKeystore(e *Ethereum) error {
-	secret, err := chain.LoadSecret("ETHEREUM_PRIVATE")
+	secret, err := chain.LoadSecret(e.cfg.SecretName)
 	if err != nil {
 		return err
 	}
@@ -144,6 +197,33 @@ func (e *Ethereum) Wallet() chain.Wallet {
 	}
 }
 
+// Balance -
+func (e *Ethereum) Balance(ctx context.Context, args chain.BalanceArgs) (decimal.Decimal, error) {
+	requestCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if args.Contract == "" {
+		balance, err := e.client.BalanceAt(requestCtx, e.address, nil)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		return decimal.NewFromBigInt(balance, 0), nil
+	}
+
+	if !common.IsHexAddress(args.Contract) {
+		return decimal.Zero, errors.Errorf("invalid token address: %s", args.Contract)
+	}
+	token, err := newErc20Token(common.HexToAddress(args.Contract), e.client)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	balance, err := token.balanceOf(requestCtx, e.address)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return decimal.NewFromBigInt(balance, 0), nil
+}
+
 // Init -
 func (e *Ethereum) Init(ctx context.Context) error {
 	e.log.Info().Msg("initializing...")
@@ -153,6 +233,9 @@ func (e *Ethereum) Init(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	if e.cfg.ChainID != 0 && chainID.Int64() != e.cfg.ChainID {
+		return errors.Errorf("node of %s has chain id %d, but %d is expected", e.cfg.Name, chainID.Int64(), e.cfg.ChainID)
+	}
 	e.chainID = chainID
 
 	return LoadAbi()
@@ -174,18 +257,22 @@ func (e *Ethereum) Run(ctx context.Context) error {
 		return errors.Wrap(err, "subscribe")
 	}
 
+	if err := e.receiveUnconfirmed(ctx); err != nil {
+		return errors.Wrap(err, "receiveUnconfirmed")
+	}
+
 	e.wg.Add(1)
 	go e.listen(ctx)
 
+	e.wg.Add(1)
+	go e.listenPending(ctx)
+
 	return nil
 }
 
 func (e *Ethereum) subscribe(ctx context.Context) error {
 	query := ethereum.FilterQuery{
-		Addresses: []common.Address{
-			e.ethContract,
-			e.erc20Contract,
-		},
+		Addresses: e.contracts(),
 		FromBlock: big.NewInt(e.latest),
 	}
 	filterCtx, filterCancel := context.WithTimeout(ctx, 10*time.Second)
@@ -253,53 +340,85 @@ func (e *Ethereum) Operations() <-chan chain.Operation {
 	return e.operations
 }
 
-// Initiate -
+// Initiate - sends initiation of ETH swap synchronously. ERC20 initiation may wait for approves of the token, so it's sent in background
+// and its result is reported via `Operations` only. Rejected initiation is reported as failed operation without hash.
 func (e *Ethereum) Initiate(ctx context.Context, args chain.InitiateArgs) error {
 	e.log.Info().Str("hashed_secret", args.HashedSecret.String()).Msg("initiate")
 
-	opts, err := e.buildTxOpts(ctx)
-	if err != nil {
-		return err
-	}
-
 	hashedSecretBytes, err := args.HashedSecret.Bytes32()
 	if err != nil {
 		return err
 	}
-	var tx *types.Transaction
 
 	refundTime := big.NewInt(args.RefundTime.Unix())
 	participant := common.HexToAddress(args.Participant)
 
-	switch args.Contract {
-	case e.cfg.EthContract:
-		tx, err = e.eth.Initiate(opts, hashedSecretBytes, participant, refundTime, args.PayOff.BigInt())
-	case e.cfg.Erc20Contract:
-		address := common.HexToAddress(args.Contract)
-		tx, err = e.erc20.Initiate(opts, hashedSecretBytes, address, participant, refundTime, big.NewInt(0), args.Amount.BigInt(), args.PayOff.BigInt(), true)
+	if args.Contract == e.cfg.EthContract {
+		tx, err := e.transact(ctx, e.cfg.FeePolicy.Initiate, args.Amount.BigInt(), func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return e.eth.Initiate(opts, hashedSecretBytes, participant, refundTime, args.PayOff.BigInt())
+		})
+		if err != nil {
+			return err
+		}
+		e.sendPendingInitiate(args.HashedSecret, tx)
+		return nil
+	}
+
+	erc20, ok := e.erc20Contract(args.Contract)
+	if !ok {
+		return errors.Errorf("unknown contract: %s", args.Contract)
 	}
-	if err != nil {
-		return err
+	if !common.IsHexAddress(args.TokenAddress) {
+		return errors.Errorf("invalid token address: %s", args.TokenAddress)
 	}
+	tokenAddress := common.HexToAddress(args.TokenAddress)
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+
+		tx, err := e.initiateErc20(ctx, erc20, tokenAddress, args, hashedSecretBytes, participant, refundTime)
+		if err != nil {
+			e.log.Err(err).Str("hashed_secret", args.HashedSecret.String()).Msg("initiate")
+			e.operations <- chain.Operation{
+				Status:       chain.Failed,
+				ChainType:    e.cfg.ChainType,
+				HashedSecret: args.HashedSecret,
+			}
+			return
+		}
+		e.sendPendingInitiate(args.HashedSecret, tx)
+	}()
+	return nil
+}
 
+// initiateErc20 - sends approves of the token if allowance of vault is not enough and initiation after them
+func (e *Ethereum) initiateErc20(ctx context.Context, erc20 *AtomexErc20, tokenAddress common.Address, args chain.InitiateArgs, hashedSecret [32]byte, participant common.Address, refundTime *big.Int) (*types.Transaction, error) {
+	e.initiateErc20Mutex.Lock()
+	defer e.initiateErc20Mutex.Unlock()
+
+	if err := e.ensureAllowance(ctx, tokenAddress, common.HexToAddress(args.Contract), args.Amount.BigInt()); err != nil {
+		return nil, errors.Wrap(err, "ensureAllowance")
+	}
+
+	return e.transact(ctx, e.cfg.FeePolicy.Initiate, nil, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return erc20.Initiate(opts, hashedSecret, tokenAddress, participant, refundTime, big.NewInt(0), args.Amount.BigInt(), args.PayOff.BigInt(), true)
+	})
+}
+
+func (e *Ethereum) sendPendingInitiate(hashedSecret chain.Hex, tx *types.Transaction) {
 	e.operations <- chain.Operation{
 		Status:       chain.Pending,
 		Hash:         tx.Hash().Hex(),
-		ChainType:    chain.ChainTypeEthereum,
-		HashedSecret: args.HashedSecret,
+		ChainType:    e.cfg.ChainType,
+		HashedSecret: hashedSecret,
 	}
-	return nil
 }
 
 // Redeem -
 func (e *Ethereum) Redeem(ctx context.Context, hashedSecret, secret chain.Hex, contract string) error {
 	e.log.Info().Str("hashed_secret", hashedSecret.String()).Str("contract", contract).Msg("redeem")
 
-	opts, err := e.buildTxOpts(ctx)
-	if err != nil {
-		return err
-	}
-
 	hashedSecretBytes, err := hashedSecret.Bytes32()
 	if err != nil {
 		return err
@@ -309,120 +428,126 @@ func (e *Ethereum) Redeem(ctx context.Context, hashedSecret, secret chain.Hex, c
 		return err
 	}
 
-	var tx *types.Transaction
+	var call func(opts *bind.TransactOpts) (*types.Transaction, error)
 
-	switch contract {
-	case e.cfg.EthContract:
-		tx, err = e.eth.Redeem(opts, hashedSecretBytes, secretBytes)
-		if err != nil {
-			return err
+	if contract == e.cfg.EthContract {
+		call = func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return e.eth.Redeem(opts, hashedSecretBytes, secretBytes)
 		}
-	case e.cfg.Erc20Contract:
-		tx, err = e.erc20.Redeem(opts, hashedSecretBytes, secretBytes)
-		if err != nil {
-			return err
+	} else {
+		erc20, ok := e.erc20Contract(contract)
+		if !ok {
+			return errors.Errorf("unknown contract: %s", contract)
+		}
+		call = func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return erc20.Redeem(opts, hashedSecretBytes, secretBytes)
 		}
 	}
 
-	e.operations <- chain.Operation{
-		Status:       chain.Pending,
-		Hash:         tx.Hash().Hex(),
-		ChainType:    chain.ChainTypeEthereum,
-		HashedSecret: hashedSecret,
+	// redeem can't be applied after refund time, so it's not replaced after it
+	deadline, err := e.refundTime(ctx, contract, hashedSecretBytes)
+	if err != nil {
+		e.log.Warn().Err(err).Str("hashed_secret", hashedSecret.String()).Msg("can't receive refund time. redeem replacement is not limited by time")
 	}
-	return nil
+
+	return e.sendTracked(ctx, newPendingKey(hashedSecret, contract, methodRedeem), e.cfg.FeePolicy.Redeem, deadline, call)
 }
 
 // Refund -
 func (e *Ethereum) Refund(ctx context.Context, hashedSecret chain.Hex, contract string) error {
 	e.log.Info().Str("hashed_secret", hashedSecret.String()).Str("contract", contract).Msg("refund")
 
-	opts, err := e.buildTxOpts(ctx)
-	if err != nil {
-		return err
-	}
-
 	hashedSecretBytes, err := hashedSecret.Bytes32()
 	if err != nil {
 		return err
 	}
-	var tx *types.Transaction
+	var call func(opts *bind.TransactOpts) (*types.Transaction, error)
 
-	switch contract {
-	case e.cfg.EthContract:
-		tx, err = e.eth.Refund(opts, hashedSecretBytes)
-		if err != nil {
-			return err
+	if contract == e.cfg.EthContract {
+		call = func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return e.eth.Refund(opts, hashedSecretBytes)
 		}
-	case e.cfg.Erc20Contract:
-		tx, err = e.erc20.Refund(opts, hashedSecretBytes)
-		if err != nil {
-			return err
+	} else {
+		erc20, ok := e.erc20Contract(contract)
+		if !ok {
+			return errors.Errorf("unknown contract: %s", contract)
+		}
+		call = func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return erc20.Refund(opts, hashedSecretBytes)
 		}
 	}
 
-	e.operations <- chain.Operation{
-		Status:       chain.Pending,
-		Hash:         tx.Hash().Hex(),
-		ChainType:    chain.ChainTypeEthereum,
-		HashedSecret: hashedSecret,
-	}
-	return nil
+	return e.sendTracked(ctx, newPendingKey(hashedSecret, contract, methodRefund), e.cfg.FeePolicy.Refund, time.Time{}, call)
 }
 
-func (e *Ethereum) buildTxOpts(ctx context.Context) (*bind.TransactOpts, error) {
-	auth, err := bind.NewKeyedTransactorWithChainID(e.privateKey, e.chainID)
-	if err != nil {
-		return nil, err
+func (e *Ethereum) contracts() []common.Address {
+	addresses := []common.Address{e.ethContract}
+	for address := range e.erc20 {
+		addresses = append(addresses, common.HexToAddress(address))
 	}
+	return addresses
+}
 
-	gasCtx, gasCancel := context.WithTimeout(ctx, 10*time.Second)
-	defer gasCancel()
-	gasPrice, err := e.client.SuggestGasPrice(gasCtx)
-	if err != nil {
-		return nil, err
-	}
+func (e *Ethereum) erc20Contract(address string) (*AtomexErc20, bool) {
+	contract, ok := e.erc20[common.HexToAddress(address).Hex()]
+	return contract, ok
+}
 
-	nonceCtx, nonceCancel := context.WithTimeout(ctx, 10*time.Second)
-	defer nonceCancel()
-	nonce, err := e.client.PendingNonceAt(nonceCtx, e.address)
-	if err != nil {
-		return nil, err
+func (e *Ethereum) isAtomexContract(address string) bool {
+	if address == e.cfg.EthContract {
+		return true
 	}
-
-	auth.Nonce = big.NewInt(int64(nonce))
-
-	auth.Value = big.NewInt(0)
-	auth.GasLimit = uint64(400000)
-	auth.GasPrice = gasPrice
-	auth.Context = ctx
-	return auth, nil
+	_, ok := e.erc20Contract(address)
+	return ok
 }
 
-// Restore -
-func (e *Ethereum) Restore() error {
-	e.log.Info().Msg("restoring...")
-	ethEvents, err := e.restoreEth()
+// Restore - sends events of swaps which were changed after `fromLevel`. If `fromLevel` is 0 all swaps are restored.
+func (e *Ethereum) Restore(ctx context.Context, fromLevel uint64) error {
+	e.log.Info().Uint64("from_level", fromLevel).Msg("restoring...")
+
+	blockCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	head, err := e.client.BlockNumber(blockCtx)
 	if err != nil {
 		return err
 	}
-	erc20Events, err := e.restoreErc20()
+	// only confirmed events are restored. Others are received after `Run`.
+	if head > e.cfg.Confirmations {
+		head -= e.cfg.Confirmations
+	}
+	e.restoredLevel = head
+
+	opts := &bind.FilterOpts{
+		Context: ctx,
+		End:     &head,
+	}
+	if fromLevel > 0 {
+		opts.Start = fromLevel + 1
+	}
+
+	ethEvents, err := e.restoreEth(opts)
 	if err != nil {
 		return err
 	}
-	ethEvents = append(ethEvents, erc20Events...)
+	for address, contract := range e.erc20 {
+		erc20Events, err := e.restoreErc20(opts, address, contract)
+		if err != nil {
+			return errors.Wrap(err, address)
+		}
+		ethEvents = append(ethEvents, erc20Events...)
+	}
 	sort.Sort(chain.ByLevel(ethEvents))
 
 	for i := range ethEvents {
 		e.events <- ethEvents[i]
 	}
-	e.events <- chain.RestoredEvent{Chain: chain.ChainTypeEthereum}
+	e.events <- chain.RestoredEvent{Chain: e.cfg.ChainType, BlockNumber: head}
 	return nil
 }
 
-func (e *Ethereum) restoreEth() ([]chain.Event, error) {
+func (e *Ethereum) restoreEth(opts *bind.FilterOpts) ([]chain.Event, error) {
 	events := make([]chain.Event, 0)
-	iterInit, err := e.eth.FilterInitiated(nil, nil, nil)
+	iterInit, err := e.eth.FilterInitiated(opts, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -430,7 +555,7 @@ func (e *Ethereum) restoreEth() ([]chain.Event, error) {
 		events = append(events, chain.InitEvent{
 			HashedSecretHex: chain.NewHexFromBytes32(iterInit.Event.HashedSecret),
 			ContractAddress: e.cfg.EthContract,
-			Chain:           chain.ChainTypeEthereum,
+			Chain:           e.cfg.ChainType,
 			BlockNumber:     iterInit.Event.Raw.BlockNumber,
 			Participant:     iterInit.Event.Participant.Hex(),
 			Initiator:       iterInit.Event.Initiator.Hex(),
@@ -443,7 +568,7 @@ func (e *Ethereum) restoreEth() ([]chain.Event, error) {
 		return nil, err
 	}
 
-	iterRedeemed, err := e.eth.FilterRedeemed(nil, nil)
+	iterRedeemed, err := e.eth.FilterRedeemed(opts, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -451,7 +576,7 @@ func (e *Ethereum) restoreEth() ([]chain.Event, error) {
 		events = append(events, chain.RedeemEvent{
 			HashedSecretHex: chain.NewHexFromBytes32(iterRedeemed.Event.HashedSecret),
 			ContractAddress: e.cfg.EthContract,
-			Chain:           chain.ChainTypeEthereum,
+			Chain:           e.cfg.ChainType,
 			BlockNumber:     iterRedeemed.Event.Raw.BlockNumber,
 			Secret:          chain.NewHexFromBytes32(iterRedeemed.Event.Secret),
 		})
@@ -460,7 +585,7 @@ func (e *Ethereum) restoreEth() ([]chain.Event, error) {
 		return nil, err
 	}
 
-	iterRefunded, err := e.eth.FilterRefunded(nil, nil)
+	iterRefunded, err := e.eth.FilterRefunded(opts, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -468,7 +593,7 @@ func (e *Ethereum) restoreEth() ([]chain.Event, error) {
 		events = append(events, chain.RefundEvent{
 			HashedSecretHex: chain.NewHexFromBytes32(iterRefunded.Event.HashedSecret),
 			ContractAddress: e.cfg.EthContract,
-			Chain:           chain.ChainTypeEthereum,
+			Chain:           e.cfg.ChainType,
 			BlockNumber:     iterRefunded.Event.Raw.BlockNumber,
 		})
 	}
@@ -479,18 +604,18 @@ func (e *Ethereum) restoreEth() ([]chain.Event, error) {
 	return events, nil
 }
 
-func (e *Ethereum) restoreErc20() ([]chain.Event, error) {
+func (e *Ethereum) restoreErc20(opts *bind.FilterOpts, address string, contract *AtomexErc20) ([]chain.Event, error) {
 	events := make([]chain.Event, 0)
 
-	iterInit, err := e.erc20.FilterInitiated(nil, nil, nil, nil)
+	iterInit, err := contract.FilterInitiated(opts, nil, nil, nil)
 	if err != nil {
 		return nil, err
 	}
 	for iterInit.Next() {
 		events = append(events, chain.InitEvent{
 			HashedSecretHex: chain.NewHexFromBytes32(iterInit.Event.HashedSecret),
-			ContractAddress: e.cfg.Erc20Contract,
-			Chain:           chain.ChainTypeEthereum,
+			ContractAddress: address,
+			Chain:           e.cfg.ChainType,
 			BlockNumber:     iterInit.Event.Raw.BlockNumber,
 			Participant:     iterInit.Event.Participant.Hex(),
 			Initiator:       iterInit.Event.Initiator.Hex(),
@@ -503,15 +628,15 @@ func (e *Ethereum) restoreErc20() ([]chain.Event, error) {
 		return nil, err
 	}
 
-	iterRedeemed, err := e.erc20.FilterRedeemed(nil, nil)
+	iterRedeemed, err := contract.FilterRedeemed(opts, nil)
 	if err != nil {
 		return nil, err
 	}
 	for iterRedeemed.Next() {
 		events = append(events, chain.RedeemEvent{
 			HashedSecretHex: chain.NewHexFromBytes32(iterRedeemed.Event.HashedSecret),
-			ContractAddress: e.cfg.Erc20Contract,
-			Chain:           chain.ChainTypeEthereum,
+			ContractAddress: address,
+			Chain:           e.cfg.ChainType,
 			BlockNumber:     iterRedeemed.Event.Raw.BlockNumber,
 			Secret:          chain.NewHexFromBytes32(iterRedeemed.Event.Secret),
 		})
@@ -520,15 +645,15 @@ func (e *Ethereum) restoreErc20() ([]chain.Event, error) {
 		return nil, err
 	}
 
-	iterRefunded, err := e.erc20.FilterRefunded(nil, nil)
+	iterRefunded, err := contract.FilterRefunded(opts, nil)
 	if err != nil {
 		return nil, err
 	}
 	for iterRefunded.Next() {
 		events = append(events, chain.RefundEvent{
 			HashedSecretHex: chain.NewHexFromBytes32(iterRefunded.Event.HashedSecret),
-			ContractAddress: e.cfg.Erc20Contract,
-			Chain:           chain.ChainTypeEthereum,
+			ContractAddress: address,
+			Chain:           e.cfg.ChainType,
 			BlockNumber:     iterRefunded.Event.Raw.BlockNumber,
 		})
 	}
@@ -540,13 +665,18 @@ func (e *Ethereum) restoreErc20() ([]chain.Event, error) {
 }
 
 func (e *Ethereum) parseLog(l types.Log) error {
-	switch l.Address.Hex() {
-	case e.cfg.EthContract:
+	if l.Removed {
+		e.retract(l)
+		return nil
+	}
+
+	address := l.Address.Hex()
+	if address == e.cfg.EthContract {
 		return e.parseLogForContract(abiAtomexEth, ContractTypeEth, l)
-	case e.cfg.Erc20Contract:
+	}
+	if _, ok := e.erc20[address]; ok {
 		return e.parseLogForContract(abiAtomexErc20, ContractTypeErc20, l)
 	}
-
 	return nil
 }
 
@@ -587,18 +717,19 @@ func (e *Ethereum) listen(ctx context.Context) {
 				e.log.Error().Err(err).Msg("parseLog")
 			}
 		case head := <-e.head:
+			e.sendConfirmed(head.Number.Uint64())
 			if err := e.parseHead(ctx, head); err != nil {
 				e.log.Error().Err(err).Msg("parseHead")
 			}
 		case err := <-e.subLogs.Err():
-			e.log.Error().Err(err).Msg("ethereum subscription error")
+			e.log.Error().Err(err).Msg("subscription error")
 			if websocket.IsCloseError(err, websocket.CloseAbnormalClosure) {
 				if err := e.reconnect(ctx); err != nil {
 					e.log.Error().Err(err).Msg("reconnect")
 				}
 			}
 		case err := <-e.subHead.Err():
-			e.log.Error().Err(err).Msg("ethereum subscription error")
+			e.log.Error().Err(err).Msg("subscription error")
 			if websocket.IsCloseError(err, websocket.CloseAbnormalClosure) {
 				if err := e.reconnect(ctx); err != nil {
 					e.log.Error().Err(err).Msg("reconnect")
@@ -624,17 +755,17 @@ func (e *Ethereum) handleInitiated(abi *abi.ABI, l types.Log, event *abi.Event,
 			return nil
 		}
 
-		e.events <- chain.InitEvent{
+		e.emit(l, chain.InitEvent{
 			HashedSecretHex: hashedSecret,
 			ContractAddress: e.cfg.EthContract,
-			Chain:           chain.ChainTypeEthereum,
+			Chain:           e.cfg.ChainType,
 			BlockNumber:     l.BlockNumber,
 			Participant:     common.BytesToAddress(l.Topics[2].Bytes()).Hex(),
 			Initiator:       args.Initiator.Hex(),
 			Amount:          decimal.NewFromBigInt(args.Value, 0),
 			PayOff:          decimal.NewFromBigInt(args.PayOff, 0),
 			RefundTime:      time.Unix(args.RefundTimestamp.Int64(), 0),
-		}
+		})
 	case ContractTypeErc20:
 		if len(l.Topics) != 4 {
 			return nil
@@ -650,28 +781,28 @@ func (e *Ethereum) handleInitiated(abi *abi.ABI, l types.Log, event *abi.Event,
 			return nil
 		}
 
-		e.events <- chain.InitEvent{
+		e.emit(l, chain.InitEvent{
 			HashedSecretHex: chain.Hex(l.Topics[1].Hex()[2:]),
-			ContractAddress: e.cfg.Erc20Contract,
-			Chain:           chain.ChainTypeEthereum,
+			ContractAddress: l.Address.Hex(),
+			Chain:           e.cfg.ChainType,
 			BlockNumber:     l.BlockNumber,
 			Participant:     l.Topics[3].Hex(),
 			Initiator:       args.Initiator.Hex(),
 			Amount:          decimal.NewFromBigInt(args.Value, 0),
 			PayOff:          decimal.NewFromBigInt(args.PayOff, 0),
 			RefundTime:      time.Unix(args.RefundTimestamp.Int64(), 0),
-		}
+		})
 	}
 	return nil
 }
 
 func (e *Ethereum) handleRefunded(l types.Log) error {
-	e.events <- chain.RefundEvent{
+	e.emit(l, chain.RefundEvent{
 		HashedSecretHex: chain.Hex(l.Topics[1].Hex()[2:]),
-		Chain:           chain.ChainTypeEthereum,
+		Chain:           e.cfg.ChainType,
 		ContractAddress: l.Address.Hex(),
 		BlockNumber:     l.BlockNumber,
-	}
+	})
 	return nil
 }
 
@@ -681,40 +812,54 @@ func (e *Ethereum) handleRedeemed(abi *abi.ABI, l types.Log, event *abi.Event) e
 		return err
 	}
 
-	e.events <- chain.RedeemEvent{
+	e.emit(l, chain.RedeemEvent{
 		HashedSecretHex: chain.Hex(l.Topics[1].Hex()[2:]),
-		Chain:           chain.ChainTypeEthereum,
+		Chain:           e.cfg.ChainType,
 		ContractAddress: l.Address.Hex(),
 		BlockNumber:     l.BlockNumber,
 		Secret:          chain.NewHexFromBytes32(args.Secret),
-	}
+	})
 	return nil
 }
 
+// parseHead - sends operation statuses of every level from the last parsed one up to the level which has enough confirmations at `head`.
+// Levels which are parsed already aren't parsed again, so a reorg doesn't report the same level twice.
 func (e *Ethereum) parseHead(ctx context.Context, head *types.Header) error {
+	if head.Number.Uint64() < e.cfg.Confirmations {
+		return nil
+	}
+	confirmed := head.Number.Uint64() - e.cfg.Confirmations
+
+	level := e.parsedLevel + 1
+	if e.parsedLevel == 0 {
+		level = confirmed
+	}
+	for ; level <= confirmed; level++ {
+		if err := e.parseLevel(ctx, level); err != nil {
+			return err
+		}
+		e.parsedLevel = level
+	}
+	return nil
+}
+
+func (e *Ethereum) parseLevel(ctx context.Context, level uint64) error {
 	blockCtx, blockCancel := context.WithTimeout(ctx, 5*time.Second)
 	defer blockCancel()
 
-	block, err := e.client.BlockByHash(blockCtx, head.Hash())
+	block, err := e.client.BlockByNumber(blockCtx, new(big.Int).SetUint64(level))
 	if err != nil {
-		if errors.Is(err, ethereum.NotFound) {
-			return nil
-		}
-		return err
+		return errors.Wrapf(err, "BlockByNumber %d", level)
 	}
 
 	txs := block.Transactions()
-	if len(txs) == 0 {
-		return nil
-	}
-
 	for i := range txs {
 		to := txs[i].To()
 		if to == nil {
 			continue
 		}
 		address := to.Hex()
-		if address != e.cfg.EthContract && address != e.cfg.Erc20Contract {
+		if !e.isAtomexContract(address) {
 			continue
 		}
 
@@ -726,15 +871,29 @@ func (e *Ethereum) parseHead(ctx context.Context, head *types.Header) error {
 			return err
 		}
 
+		fee := new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), effectiveGasPrice(txs[i], block.BaseFee()))
 		e.operations <- chain.Operation{
-			ChainType: chain.ChainTypeEthereum,
+			ChainType: e.cfg.ChainType,
 			Hash:      txs[i].Hash().Hex(),
 			Status:    toOperationStatus(receipt.Status),
+			Fee:       decimal.NewFromBigInt(fee, 0),
 		}
 	}
 	return nil
 }
 
+// effectiveGasPrice - returns price of gas paid by transaction in block with `baseFee`. Base fee is nil before London fork.
+func effectiveGasPrice(tx *types.Transaction, baseFee *big.Int) *big.Int {
+	if baseFee == nil {
+		return tx.GasPrice()
+	}
+	price := new(big.Int).Add(tx.GasTipCap(), baseFee)
+	if price.Cmp(tx.GasFeeCap()) > 0 {
+		return tx.GasFeeCap()
+	}
+	return price
+}
+
 func toOperationStatus(status uint64) chain.OperationStatus {
 	switch status {
 	case 0:
@@ -0,0 +1,139 @@
+package ethereum
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/atomex-protocol/watch_tower/internal/chain"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// logKey - identifies log in block. Removed log has the same key as the log which was received before reorg.
+type logKey struct {
+	TxHash common.Hash
+	Index  uint
+}
+
+func newLogKey(l types.Log) logKey {
+	return logKey{
+		TxHash: l.TxHash,
+		Index:  l.Index,
+	}
+}
+
+// count of the last sent logs which are remembered to skip their duplicates
+const sentLogsLimit = 4096
+
+// sentLogs - bounded set of sent logs. Logs may be received twice: by filter of unconfirmed logs and by subscription, or after resubscription.
+type sentLogs struct {
+	keys  map[logKey]struct{}
+	order []logKey
+	limit int
+}
+
+func newSentLogs(limit int) *sentLogs {
+	return &sentLogs{
+		keys:  make(map[logKey]struct{}),
+		order: make([]logKey, 0),
+		limit: limit,
+	}
+}
+
+func (s *sentLogs) has(key logKey) bool {
+	_, ok := s.keys[key]
+	return ok
+}
+
+// add - remembers key and forgets the oldest one if limit is reached
+func (s *sentLogs) add(key logKey) {
+	if s.has(key) {
+		return
+	}
+	if len(s.order) == s.limit {
+		delete(s.keys, s.order[0])
+		s.order = s.order[1:]
+	}
+	s.keys[key] = struct{}{}
+	s.order = append(s.order, key)
+}
+
+// emit - sends event of log once. If confirmations are set, event waits until its block becomes deep enough.
+func (e *Ethereum) emit(l types.Log, event chain.Event) {
+	key := newLogKey(l)
+	if e.sent.has(key) {
+		return
+	}
+	if e.cfg.Confirmations == 0 {
+		e.sent.add(key)
+		e.events <- event
+		return
+	}
+	e.pending[key] = event
+}
+
+// retract - drops pending event of log which was removed by chain reorganization
+func (e *Ethereum) retract(l types.Log) {
+	key := newLogKey(l)
+	event, ok := e.pending[key]
+	if !ok {
+		e.log.Warn().Str("tx", l.TxHash.Hex()).Uint64("level", l.BlockNumber).Msg("log was removed by reorg, but its event was already sent")
+		return
+	}
+	delete(e.pending, key)
+	e.log.Info().Str("tx", l.TxHash.Hex()).Str("hashed_secret", event.HashedSecret().String()).Uint64("level", l.BlockNumber).Msg("event retracted by reorg")
+}
+
+// sendConfirmed - sends pending events which have enough confirmations at `head` in order of their levels
+func (e *Ethereum) sendConfirmed(head uint64) {
+	if e.cfg.Confirmations == 0 || len(e.pending) == 0 {
+		return
+	}
+
+	keys := make([]logKey, 0)
+	for key, event := range e.pending {
+		if event.Level()+e.cfg.Confirmations <= head {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		left, right := e.pending[keys[i]].Level(), e.pending[keys[j]].Level()
+		if left == right {
+			return keys[i].Index < keys[j].Index
+		}
+		return left < right
+	})
+
+	for i := range keys {
+		e.sent.add(keys[i])
+		e.events <- e.pending[keys[i]]
+		delete(e.pending, keys[i])
+	}
+}
+
+// receiveUnconfirmed - receives logs which were emitted after restored level, because subscription sends only new logs
+func (e *Ethereum) receiveUnconfirmed(ctx context.Context) error {
+	if e.restoredLevel == 0 {
+		return nil
+	}
+
+	filterCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	logs, err := e.client.FilterLogs(filterCtx, ethereum.FilterQuery{
+		Addresses: e.contracts(),
+		FromBlock: new(big.Int).SetUint64(e.restoredLevel + 1),
+	})
+	if err != nil {
+		return err
+	}
+	for i := range logs {
+		if err := e.parseLog(logs[i]); err != nil {
+			e.log.Error().Err(err).Msg("parseLog")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package ethereum
+
+import (
+	"testing"
+
+	"github.com/atomex-protocol/watch_tower/internal/chain"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEthereum_Confirmations(t *testing.T) {
+	e := &Ethereum{
+		cfg:     Config{Confirmations: 2},
+		log:     zerolog.Nop(),
+		events:  make(chan chain.Event, 16),
+		pending: make(map[logKey]chain.Event),
+		sent:    newSentLogs(sentLogsLimit),
+	}
+
+	initLog := types.Log{TxHash: common.HexToHash("0x01"), BlockNumber: 10, Index: 1}
+	redeemLog := types.Log{TxHash: common.HexToHash("0x02"), BlockNumber: 11, Index: 0}
+	phantomLog := types.Log{TxHash: common.HexToHash("0x03"), BlockNumber: 11, Index: 1}
+
+	e.emit(initLog, chain.InitEvent{HashedSecretHex: "01", BlockNumber: 10})
+	e.emit(redeemLog, chain.RedeemEvent{HashedSecretHex: "01", BlockNumber: 11})
+	e.emit(phantomLog, chain.InitEvent{HashedSecretHex: "02", BlockNumber: 11})
+
+	e.sendConfirmed(11)
+	assert.Len(t, e.events, 0, "events are not confirmed yet")
+
+	phantomLog.Removed = true
+	e.retract(phantomLog)
+
+	e.sendConfirmed(12)
+	assert.Len(t, e.events, 1)
+	assert.Equal(t, uint64(10), (<-e.events).Level())
+
+	e.sendConfirmed(13)
+	assert.Len(t, e.events, 1)
+	event := <-e.events
+	assert.IsType(t, chain.RedeemEvent{}, event)
+	assert.Empty(t, e.pending)
+
+	// log received by subscription after filter isn't sent twice
+	e.emit(initLog, chain.InitEvent{HashedSecretHex: "01", BlockNumber: 10})
+	e.sendConfirmed(13)
+	assert.Empty(t, e.events)
+	assert.Empty(t, e.pending)
+
+	e.cfg.Confirmations = 0
+	e.emit(redeemLog, chain.RedeemEvent{HashedSecretHex: "01", BlockNumber: 11})
+	assert.Empty(t, e.events)
+}
+
+func TestSentLogs(t *testing.T) {
+	sent := newSentLogs(2)
+	first, second, third := logKey{Index: 1}, logKey{Index: 2}, logKey{Index: 3}
+
+	sent.add(first)
+	sent.add(second)
+	sent.add(second)
+	assert.True(t, sent.has(first))
+
+	sent.add(third)
+	assert.False(t, sent.has(first), "the oldest key is forgotten")
+	assert.True(t, sent.has(second))
+	assert.True(t, sent.has(third))
+}
@@ -0,0 +1,223 @@
+package ethereum
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// count of blocks which are used for tip estimation
+const feeHistoryBlocks = 20
+
+// percent which is added to estimated gas limit
+const gasLimitMargin = 20
+
+// FeePolicy - fee settings of sent transactions by contract methods
+type FeePolicy struct {
+	Initiate MethodFeePolicy `yaml:"initiate"`
+	Redeem   MethodFeePolicy `yaml:"redeem"`
+	Refund   MethodFeePolicy `yaml:"refund"`
+}
+
+// MethodFeePolicy - `MaxFee` is a cap of fee per gas in gwei. Empty value means no cap. `TipPercentile` is a percentile of priority fees of recent blocks which is used as tip. `Multiplier` is applied to base fee of head (or to gas price for legacy networks) for computing fee cap.
+type MethodFeePolicy struct {
+	MaxFee        string  `yaml:"max_fee" validate:"omitempty,numeric"`
+	TipPercentile float64 `yaml:"tip_percentile" validate:"omitempty,gt=0,lte=100"`
+	Multiplier    float64 `yaml:"multiplier" validate:"omitempty,gt=0"`
+}
+
+// DefaultFeePolicy - redeems and refunds are time-critical, so they pay more than initiations
+func DefaultFeePolicy() FeePolicy {
+	return FeePolicy{
+		Initiate: MethodFeePolicy{
+			TipPercentile: 50,
+			Multiplier:    2,
+		},
+		Redeem: MethodFeePolicy{
+			TipPercentile: 90,
+			Multiplier:    3,
+		},
+		Refund: MethodFeePolicy{
+			TipPercentile: 90,
+			Multiplier:    3,
+		},
+	}
+}
+
+func (p FeePolicy) withDefaults() FeePolicy {
+	defaults := DefaultFeePolicy()
+	return FeePolicy{
+		Initiate: p.Initiate.withDefaults(defaults.Initiate),
+		Redeem:   p.Redeem.withDefaults(defaults.Redeem),
+		Refund:   p.Refund.withDefaults(defaults.Refund),
+	}
+}
+
+func (p MethodFeePolicy) withDefaults(defaults MethodFeePolicy) MethodFeePolicy {
+	if p.TipPercentile == 0 {
+		p.TipPercentile = defaults.TipPercentile
+	}
+	if p.Multiplier == 0 {
+		p.Multiplier = defaults.Multiplier
+	}
+	return p
+}
+
+// maxFee - returns cap of fee per gas in wei or nil if it's not set
+func (p MethodFeePolicy) maxFee() (*big.Int, error) {
+	if p.MaxFee == "" {
+		return nil, nil
+	}
+	gwei, err := decimal.NewFromString(p.MaxFee)
+	if err != nil {
+		return nil, errors.Wrap(err, "max_fee")
+	}
+	return gwei.Shift(9).BigInt(), nil
+}
+
+// dynamicFees - computes EIP-1559 fee cap and tip cap: fee cap = base fee * multiplier + tip. If fee cap exceeds max fee, both are limited by max fee.
+func (p MethodFeePolicy) dynamicFees(baseFee, tip *big.Int) (feeCap *big.Int, tipCap *big.Int, err error) {
+	feeCap = multiply(baseFee, p.Multiplier)
+	feeCap.Add(feeCap, tip)
+	tipCap = new(big.Int).Set(tip)
+
+	maxFee, err := p.maxFee()
+	if err != nil {
+		return nil, nil, err
+	}
+	if maxFee != nil && feeCap.Cmp(maxFee) > 0 {
+		feeCap.Set(maxFee)
+		if tipCap.Cmp(feeCap) > 0 {
+			tipCap.Set(feeCap)
+		}
+	}
+	return feeCap, tipCap, nil
+}
+
+// legacyGasPrice - computes gas price for networks without EIP-1559
+func (p MethodFeePolicy) legacyGasPrice(suggested *big.Int) (*big.Int, error) {
+	gasPrice := multiply(suggested, p.Multiplier)
+
+	maxFee, err := p.maxFee()
+	if err != nil {
+		return nil, err
+	}
+	if maxFee != nil && gasPrice.Cmp(maxFee) > 0 {
+		gasPrice.Set(maxFee)
+	}
+	return gasPrice, nil
+}
+
+func multiply(value *big.Int, multiplier float64) *big.Int {
+	return decimal.NewFromBigInt(value, 0).Mul(decimal.NewFromFloat(multiplier)).Ceil().BigInt()
+}
+
+type feeHistory struct {
+	Reward [][]*hexutil.Big `json:"reward"`
+}
+
+// tip - returns average of `percentile` priority fees of recent blocks. If node doesn't support `eth_feeHistory`, tip is suggested by node.
+func (e *Ethereum) tip(ctx context.Context, percentile float64) (*big.Int, error) {
+	historyCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var history feeHistory
+	if err := e.rpc.CallContext(historyCtx, &history, "eth_feeHistory", hexutil.Uint64(feeHistoryBlocks), "latest", []float64{percentile}); err != nil {
+		e.log.Warn().Err(err).Msg("eth_feeHistory")
+		return e.client.SuggestGasTipCap(ctx)
+	}
+
+	sum := big.NewInt(0)
+	var count int64
+	for i := range history.Reward {
+		if len(history.Reward[i]) == 0 || history.Reward[i][0] == nil {
+			continue
+		}
+		sum.Add(sum, history.Reward[i][0].ToInt())
+		count++
+	}
+	if count == 0 {
+		return e.client.SuggestGasTipCap(ctx)
+	}
+	return sum.Div(sum, big.NewInt(count)), nil
+}
+
+func (e *Ethereum) buildTxOpts(ctx context.Context, policy MethodFeePolicy) (*bind.TransactOpts, error) {
+	auth, err := bind.NewKeyedTransactorWithChainID(e.privateKey, e.chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	headCtx, headCancel := context.WithTimeout(ctx, 10*time.Second)
+	defer headCancel()
+	head, err := e.client.HeaderByNumber(headCtx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if head.BaseFee != nil {
+		tip, err := e.tip(ctx, policy.TipPercentile)
+		if err != nil {
+			return nil, err
+		}
+		auth.GasFeeCap, auth.GasTipCap, err = policy.dynamicFees(head.BaseFee, tip)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		gasCtx, gasCancel := context.WithTimeout(ctx, 10*time.Second)
+		defer gasCancel()
+		suggested, err := e.client.SuggestGasPrice(gasCtx)
+		if err != nil {
+			return nil, err
+		}
+		auth.GasPrice, err = policy.legacyGasPrice(suggested)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	auth.Value = big.NewInt(0)
+	auth.Context = ctx
+	return auth, nil
+}
+
+// transact - builds transaction options by policy, estimates gas of the call via `EstimateGas` and sends transaction with gas limit margin and the next local nonce
+func (e *Ethereum) transact(ctx context.Context, policy MethodFeePolicy, value *big.Int, call func(opts *bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, error) {
+	opts, err := e.buildTxOpts(ctx, policy)
+	if err != nil {
+		return nil, err
+	}
+	if value != nil {
+		opts.Value = value
+	}
+
+	nonce, err := e.nonces.acquire(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "nonce")
+	}
+	opts.Nonce = new(big.Int).SetUint64(nonce)
+
+	tx, err := e.estimateAndSend(opts, call)
+	e.nonces.release(nonce, err == nil)
+	return tx, err
+}
+
+func (e *Ethereum) estimateAndSend(opts *bind.TransactOpts, call func(opts *bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, error) {
+	// bound contract estimates gas if gas limit is not set
+	opts.NoSend = true
+	estimated, err := call(opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "gas estimation")
+	}
+
+	opts.NoSend = false
+	opts.GasLimit = estimated.Gas() + estimated.Gas()*gasLimitMargin/100
+	return call(opts)
+}
@@ -0,0 +1,76 @@
+package ethereum
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gwei(value int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(value), big.NewInt(1e9))
+}
+
+func TestMethodFeePolicy_dynamicFees(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     MethodFeePolicy
+		baseFee    *big.Int
+		tip        *big.Int
+		wantFeeCap *big.Int
+		wantTipCap *big.Int
+	}{
+		{
+			name:       "without cap",
+			policy:     MethodFeePolicy{Multiplier: 2},
+			baseFee:    gwei(30),
+			tip:        gwei(2),
+			wantFeeCap: gwei(62),
+			wantTipCap: gwei(2),
+		}, {
+			name:       "fractional multiplier",
+			policy:     MethodFeePolicy{Multiplier: 1.5},
+			baseFee:    gwei(30),
+			tip:        gwei(1),
+			wantFeeCap: gwei(46),
+			wantTipCap: gwei(1),
+		}, {
+			name:       "fee cap is limited",
+			policy:     MethodFeePolicy{Multiplier: 3, MaxFee: "50"},
+			baseFee:    gwei(30),
+			tip:        gwei(2),
+			wantFeeCap: gwei(50),
+			wantTipCap: gwei(2),
+		}, {
+			name:       "tip is limited",
+			policy:     MethodFeePolicy{Multiplier: 1, MaxFee: "0.5"},
+			baseFee:    big.NewInt(1),
+			tip:        gwei(1),
+			wantFeeCap: big.NewInt(5e8),
+			wantTipCap: big.NewInt(5e8),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feeCap, tipCap, err := tt.policy.dynamicFees(tt.baseFee, tt.tip)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFeeCap.String(), feeCap.String())
+			assert.Equal(t, tt.wantTipCap.String(), tipCap.String())
+		})
+	}
+}
+
+func TestFeePolicy_withDefaults(t *testing.T) {
+	policy := FeePolicy{
+		Redeem: MethodFeePolicy{MaxFee: "100", TipPercentile: 99},
+	}.withDefaults()
+
+	assert.Equal(t, DefaultFeePolicy().Initiate, policy.Initiate)
+	assert.Equal(t, DefaultFeePolicy().Refund, policy.Refund)
+	assert.Equal(t, MethodFeePolicy{MaxFee: "100", TipPercentile: 99, Multiplier: 3}, policy.Redeem)
+
+	gasPrice, err := policy.Redeem.legacyGasPrice(gwei(40))
+	require.NoError(t, err)
+	assert.Equal(t, gwei(100).String(), gasPrice.String())
+}
@@ -0,0 +1,120 @@
+package ethereum
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// nonceManager - allocates sequential nonces of sent transactions locally, so concurrent sends don't receive the same nonce from node.
+// Allocated nonces are reused only after failed sends: node's pending nonce may lag behind sent transactions, so it never lowers the local one.
+type nonceManager struct {
+	fetch func(ctx context.Context) (uint64, error)
+	log   zerolog.Logger
+
+	mx          sync.Mutex
+	next        uint64
+	failed      []uint64 // nonces of failed sends below `next` in ascending order. They are allocated first.
+	initialized bool
+	resync      bool
+}
+
+func newNonceManager(fetch func(ctx context.Context) (uint64, error), log zerolog.Logger) *nonceManager {
+	return &nonceManager{
+		fetch: fetch,
+		log:   log,
+	}
+}
+
+// acquire - returns next nonce. Nonces are synchronized with node's pending nonce on the first call and after failed sends.
+func (m *nonceManager) acquire(ctx context.Context) (uint64, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	if !m.initialized || m.resync {
+		if err := m.sync(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(m.failed) > 0 {
+		nonce := m.failed[0]
+		m.failed = m.failed[1:]
+		return nonce, nil
+	}
+
+	nonce := m.next
+	m.next++
+	return nonce, nil
+}
+
+// release - must be called after sending of transaction with acquired nonce. Nonce of the failed transaction is reused by the next allocation.
+func (m *nonceManager) release(nonce uint64, sent bool) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	if sent {
+		return
+	}
+
+	// send may be failed because nonce is used by another client, so node's pending nonce is checked before the next allocation
+	m.resync = true
+	if nonce+1 == m.next {
+		m.next = nonce
+		return
+	}
+	m.failed = append(m.failed, nonce)
+	sort.Slice(m.failed, func(i, j int) bool { return m.failed[i] < m.failed[j] })
+}
+
+// check - compares allocated nonces with node's pending nonce and reports gaps. Local nonces aren't changed.
+func (m *nonceManager) check(ctx context.Context) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	if !m.initialized {
+		return nil
+	}
+
+	pending, err := m.fetch(ctx)
+	if err != nil {
+		return err
+	}
+	switch {
+	case pending < m.next:
+		m.log.Warn().Uint64("from", pending).Uint64("to", m.next-1).Msg("nonce gap: allocated nonces are absent in node's pending pool")
+	case pending > m.next:
+		m.log.Warn().Uint64("local", m.next).Uint64("node", pending).Msg("node's pending nonce is ahead of local one: transactions were sent by another client")
+	}
+	return nil
+}
+
+// sync - initializes local nonce by node's pending nonce. After that it's only moved forward if node is ahead, e.g. because of another client.
+func (m *nonceManager) sync(ctx context.Context) error {
+	pending, err := m.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	if m.initialized && pending > m.next {
+		m.log.Warn().Uint64("local", m.next).Uint64("node", pending).Msg("node's pending nonce is ahead of local one: transactions were sent by another client")
+	}
+	if !m.initialized || pending > m.next {
+		m.next = pending
+	}
+
+	// failed nonces which are used by node already can't be reused
+	failed := m.failed[:0]
+	for _, nonce := range m.failed {
+		if nonce >= pending && nonce < m.next {
+			failed = append(failed, nonce)
+		}
+	}
+	m.failed = failed
+
+	m.initialized = true
+	m.resync = false
+	return nil
+}
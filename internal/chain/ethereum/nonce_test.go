@@ -0,0 +1,51 @@
+package ethereum
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNonceManager(t *testing.T) {
+	ctx := context.Background()
+	pending := uint64(5)
+	var fetches int
+	manager := newNonceManager(func(ctx context.Context) (uint64, error) {
+		fetches++
+		return pending, nil
+	}, zerolog.Nop())
+
+	acquire := func() uint64 {
+		nonce, err := manager.acquire(ctx)
+		require.NoError(t, err)
+		return nonce
+	}
+
+	first, second, third := acquire(), acquire(), acquire()
+	assert.Equal(t, []uint64{5, 6, 7}, []uint64{first, second, third})
+	assert.Equal(t, 1, fetches, "nonces are allocated locally")
+
+	// the last nonce is reused
+	manager.release(third, false)
+	assert.Equal(t, uint64(7), acquire())
+	assert.Equal(t, 2, fetches, "node is checked after failed send")
+
+	// failed nonce in the middle is reused before new ones
+	manager.release(first, false)
+	manager.release(second, true)
+	assert.Equal(t, uint64(5), acquire())
+	assert.Equal(t, uint64(8), acquire())
+
+	// lagging node doesn't lower local nonce
+	pending = 5
+	require.NoError(t, manager.check(ctx))
+	assert.Equal(t, uint64(9), acquire())
+
+	// node is ahead because of another client. Failed nonce used by node isn't reused.
+	manager.release(6, false)
+	pending = 12
+	assert.Equal(t, uint64(12), acquire())
+}
@@ -0,0 +1,285 @@
+package ethereum
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/atomex-protocol/watch_tower/internal/chain"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// default timeout after which pending redeem or refund is replaced by transaction with bumped fees
+const defaultReplaceTimeout = 3 * time.Minute
+
+// percent of fees bump of replacement transaction. Nodes accept replacement if its fees are at least 10% higher.
+const replacementFeeBump = 15
+
+// contract methods which are tracked until they are mined
+const (
+	methodRedeem = "redeem"
+	methodRefund = "refund"
+)
+
+// pendingKey - pending transaction is identified by method and contract besides hashed secret, so legs of the same swap on the chain and refund after redeem are separate transactions
+type pendingKey struct {
+	hashedSecret chain.Hex
+	contract     string
+	method       string
+}
+
+func newPendingKey(hashedSecret chain.Hex, contract, method string) pendingKey {
+	return pendingKey{
+		hashedSecret: hashedSecret,
+		contract:     common.HexToAddress(contract).Hex(),
+		method:       method,
+	}
+}
+
+// pendingTx - redeem or refund which was sent and isn't mined yet. `hashes` are hashes of all sent versions of the transaction.
+type pendingTx struct {
+	hashedSecret chain.Hex
+	policy       MethodFeePolicy
+	call         func(opts *bind.TransactOpts) (*types.Transaction, error)
+	tx           *types.Transaction
+	hashes       []common.Hash
+	sentAt       time.Time
+	deadline     time.Time
+}
+
+// sendTracked - sends transaction, emits its pending operation and remembers its nonce by `key`. If transaction of the key is pending already,
+// it's kept until replace timeout and replaced with the same nonce after that instead of sending a new one. Zero `deadline` means that replacement isn't limited by time.
+func (e *Ethereum) sendTracked(ctx context.Context, key pendingKey, policy MethodFeePolicy, deadline time.Time, call func(opts *bind.TransactOpts) (*types.Transaction, error)) error {
+	e.pendingTxsMutex.Lock()
+	defer e.pendingTxsMutex.Unlock()
+
+	if p, ok := e.pendingTxs[key]; ok {
+		if time.Since(p.sentAt) < e.cfg.ReplaceTimeout {
+			e.log.Info().Str("hashed_secret", key.hashedSecret.String()).Str("hash", p.tx.Hash().Hex()).Msg("transaction is pending already")
+			return nil
+		}
+		if p.isExpired() {
+			return errors.Errorf("refund time of pending transaction %s is passed", p.tx.Hash().Hex())
+		}
+		e.log.Info().Str("hashed_secret", key.hashedSecret.String()).Uint64("nonce", p.tx.Nonce()).Msg("transaction is pending. replacing...")
+		return e.replacePending(ctx, p)
+	}
+
+	tx, err := e.transact(ctx, policy, nil, call)
+	if err != nil {
+		return err
+	}
+
+	e.pendingTxs[key] = &pendingTx{
+		hashedSecret: key.hashedSecret,
+		policy:       policy,
+		call:         call,
+		tx:           tx,
+		hashes:       []common.Hash{tx.Hash()},
+		sentAt:       time.Now(),
+		deadline:     deadline,
+	}
+
+	e.operations <- chain.Operation{
+		Status:       chain.Pending,
+		Hash:         tx.Hash().Hex(),
+		ChainType:    e.cfg.ChainType,
+		HashedSecret: key.hashedSecret,
+	}
+	return nil
+}
+
+// isExpired - redeem can't be applied after refund time, so it isn't replaced after it
+func (p *pendingTx) isExpired() bool {
+	return !p.deadline.IsZero() && time.Now().After(p.deadline)
+}
+
+// replacePending - replaces pending transaction and emits pending operation of the new version
+func (e *Ethereum) replacePending(ctx context.Context, p *pendingTx) error {
+	tx, err := e.replace(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	e.operations <- chain.Operation{
+		Status:       chain.Pending,
+		Hash:         tx.Hash().Hex(),
+		ChainType:    e.cfg.ChainType,
+		HashedSecret: p.hashedSecret,
+	}
+	return nil
+}
+
+// failReplaced - emits failed operations for versions of transaction which aren't mined. Only one version may be mined since they have the same nonce,
+// its status is sent by block processing.
+func (e *Ethereum) failReplaced(ctx context.Context, p *pendingTx) error {
+	for _, hash := range p.hashes {
+		receiptCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		_, err := e.client.TransactionReceipt(receiptCtx, hash)
+		cancel()
+
+		switch {
+		case err == nil:
+			continue
+		case errors.Is(err, ethereum.NotFound):
+			e.operations <- chain.Operation{
+				Status:       chain.Failed,
+				Hash:         hash.Hex(),
+				ChainType:    e.cfg.ChainType,
+				HashedSecret: p.hashedSecret,
+			}
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// replace - re-signs call of pending transaction with the same nonce and gas limit and bumped fees
+func (e *Ethereum) replace(ctx context.Context, p *pendingTx) (*types.Transaction, error) {
+	opts, err := e.buildTxOpts(ctx, p.policy)
+	if err != nil {
+		return nil, err
+	}
+	opts.Nonce = new(big.Int).SetUint64(p.tx.Nonce())
+	opts.GasLimit = p.tx.Gas()
+
+	maxFee, err := p.policy.maxFee()
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.GasPrice != nil {
+		opts.GasPrice = bumpFee(opts.GasPrice, p.tx.GasPrice())
+		if maxFee != nil && opts.GasPrice.Cmp(maxFee) > 0 {
+			return nil, errors.Errorf("replacement gas price %s exceeds max fee", opts.GasPrice)
+		}
+	} else {
+		opts.GasFeeCap = bumpFee(opts.GasFeeCap, p.tx.GasFeeCap())
+		opts.GasTipCap = bumpFee(opts.GasTipCap, p.tx.GasTipCap())
+		if maxFee != nil && opts.GasFeeCap.Cmp(maxFee) > 0 {
+			return nil, errors.Errorf("replacement fee cap %s exceeds max fee", opts.GasFeeCap)
+		}
+		if opts.GasTipCap.Cmp(opts.GasFeeCap) > 0 {
+			opts.GasTipCap.Set(opts.GasFeeCap)
+		}
+	}
+
+	tx, err := p.call(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	e.log.Info().
+		Str("hashed_secret", p.hashedSecret.String()).
+		Str("replaced", p.tx.Hash().Hex()).
+		Str("hash", tx.Hash().Hex()).
+		Uint64("nonce", tx.Nonce()).
+		Msg("transaction is replaced")
+
+	p.tx = tx
+	p.hashes = append(p.hashes, tx.Hash())
+	p.sentAt = time.Now()
+	return tx, nil
+}
+
+// bumpFee - returns current fee if it's enough for replacement or previous fee increased by `replacementFeeBump` percent
+func bumpFee(current, previous *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(previous, big.NewInt(100+replacementFeeBump))
+	bumped.Div(bumped, big.NewInt(100))
+	if current != nil && current.Cmp(bumped) > 0 {
+		return new(big.Int).Set(current)
+	}
+	return bumped
+}
+
+func (e *Ethereum) listenPending(ctx context.Context) {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := e.nonces.check(ctx); err != nil {
+				e.log.Err(err).Msg("nonces.check")
+			}
+			if err := e.checkPending(ctx); err != nil {
+				e.log.Err(err).Msg("checkPending")
+			}
+		}
+	}
+}
+
+// checkPending - forgets mined transactions and replaces transactions which are pending longer than replace timeout.
+// Versions of mined transaction which aren't mined are failed.
+func (e *Ethereum) checkPending(ctx context.Context) error {
+	e.pendingTxsMutex.Lock()
+	defer e.pendingTxsMutex.Unlock()
+
+	if len(e.pendingTxs) == 0 {
+		return nil
+	}
+
+	nonceCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// transaction with nonce less than account nonce of the latest block is mined: it's the last sent version or one of replaced ones
+	nonce, err := e.client.NonceAt(nonceCtx, e.address, nil)
+	if err != nil {
+		return err
+	}
+
+	for key, p := range e.pendingTxs {
+		if p.tx.Nonce() < nonce {
+			e.log.Info().Str("hashed_secret", key.hashedSecret.String()).Uint64("nonce", p.tx.Nonce()).Int("versions", len(p.hashes)).Msg("pending transaction is mined")
+			if err := e.failReplaced(ctx, p); err != nil {
+				e.log.Err(err).Str("hashed_secret", key.hashedSecret.String()).Msg("failReplaced")
+				continue
+			}
+			delete(e.pendingTxs, key)
+			continue
+		}
+		// expired transaction is kept until its nonce is used, so its versions are failed then
+		if p.isExpired() || time.Since(p.sentAt) < e.cfg.ReplaceTimeout {
+			continue
+		}
+
+		if err := e.replacePending(ctx, p); err != nil {
+			e.log.Err(err).Str("hashed_secret", key.hashedSecret.String()).Msg("replace")
+		}
+	}
+	return nil
+}
+
+// refundTime - receives refund time of swap from contract
+func (e *Ethereum) refundTime(ctx context.Context, contract string, hashedSecret [32]byte) (time.Time, error) {
+	callCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	opts := &bind.CallOpts{Context: callCtx}
+
+	if contract == e.cfg.EthContract {
+		swap, err := e.eth.Swaps(opts, hashedSecret)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(swap.RefundTimestamp.Int64(), 0), nil
+	}
+
+	erc20, ok := e.erc20Contract(contract)
+	if !ok {
+		return time.Time{}, errors.Errorf("unknown contract: %s", contract)
+	}
+	swap, err := erc20.Swaps(opts, hashedSecret)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(swap.RefundTimestamp.Int64(), 0), nil
+}
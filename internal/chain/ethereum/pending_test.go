@@ -0,0 +1,49 @@
+package ethereum
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/atomex-protocol/watch_tower/internal/chain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBumpFee(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  *big.Int
+		previous *big.Int
+		want     *big.Int
+	}{
+		{
+			name:     "previous is bumped",
+			current:  gwei(30),
+			previous: gwei(40),
+			want:     gwei(46),
+		}, {
+			name:     "current is enough",
+			current:  gwei(60),
+			previous: gwei(40),
+			want:     gwei(60),
+		}, {
+			name:     "current is unknown",
+			previous: gwei(20),
+			want:     gwei(23),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want.String(), bumpFee(tt.current, tt.previous).String())
+		})
+	}
+}
+
+func TestNewPendingKey(t *testing.T) {
+	hashedSecret := chain.Hex("0102030405060708091011121314151617181920212223242526272829303132")
+	contract := "0xac5881d77db9340c94f53300736a9cf9e61fa25e"
+
+	redeem := newPendingKey(hashedSecret, contract, methodRedeem)
+	assert.Equal(t, redeem, newPendingKey(hashedSecret, "0xAc5881D77Db9340c94F53300736a9cf9e61fA25E", methodRedeem), "address case doesn't matter")
+	assert.NotEqual(t, redeem, newPendingKey(hashedSecret, contract, methodRefund), "refund after redeem")
+	assert.NotEqual(t, redeem, newPendingKey(hashedSecret, "0xdac17f958d2ee523a2206206994597c13d831ec7", methodRedeem), "other leg of swap")
+}
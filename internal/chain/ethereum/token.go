@@ -0,0 +1,142 @@
+package ethereum
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/pkg/errors"
+)
+
+// approve policies of ERC20 tokens
+const (
+	// ApprovePolicyUnlimited - vault is approved to transfer any amount of tokens, so approve is sent only once
+	ApprovePolicyUnlimited = "unlimited"
+	// ApprovePolicyExact - vault is approved to transfer exact amount of initiation
+	ApprovePolicyExact = "exact"
+)
+
+// timeout of waiting for approve inclusion
+const approveTimeout = 5 * time.Minute
+
+// erc20TokenMetaData - part of ERC20 ABI which is used for allowance management
+var erc20TokenMetaData = &bind.MetaData{
+	ABI: `[
+		{"constant":true,"inputs":[{"name":"owner","type":"address"},{"name":"spender","type":"address"}],"name":"allowance","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+		{"constant":true,"inputs":[{"name":"account","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+		{"constant":false,"inputs":[{"name":"spender","type":"address"},{"name":"amount","type":"uint256"}],"name":"approve","outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable","type":"function"}
+	]`,
+}
+
+// erc20Token - binding of ERC20 token
+type erc20Token struct {
+	contract *bind.BoundContract
+}
+
+func newErc20Token(address common.Address, backend bind.ContractBackend) (*erc20Token, error) {
+	parsed, err := erc20TokenMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return &erc20Token{
+		contract: bind.NewBoundContract(address, *parsed, backend, backend, backend),
+	}, nil
+}
+
+// call - calls view method which returns uint256. Pending state is used, so transactions of watch tower which are not included yet are taken into account.
+func (t *erc20Token) call(ctx context.Context, method string, args ...interface{}) (*big.Int, error) {
+	var out []interface{}
+	if err := t.contract.Call(&bind.CallOpts{Pending: true, Context: ctx}, &out, method, args...); err != nil {
+		return nil, errors.Wrap(err, method)
+	}
+	if len(out) == 0 {
+		return nil, errors.Errorf("%s: empty response", method)
+	}
+	return abi.ConvertType(out[0], new(big.Int)).(*big.Int), nil
+}
+
+func (t *erc20Token) allowance(ctx context.Context, owner, spender common.Address) (*big.Int, error) {
+	return t.call(ctx, "allowance", owner, spender)
+}
+
+func (t *erc20Token) balanceOf(ctx context.Context, owner common.Address) (*big.Int, error) {
+	return t.call(ctx, "balanceOf", owner)
+}
+
+func (t *erc20Token) approve(opts *bind.TransactOpts, spender common.Address, amount *big.Int) (*types.Transaction, error) {
+	return t.contract.Transact(opts, "approve", spender, amount)
+}
+
+// approveValues - returns values of approves which have to be sent so `required` amount can be transferred. Non-zero allowance is reset to zero first because some tokens (e.g. USDT) forbid change of non-zero allowance.
+func approveValues(allowance, required *big.Int, policy string) []*big.Int {
+	if allowance.Cmp(required) >= 0 {
+		return nil
+	}
+
+	value := new(big.Int).Set(required)
+	if policy != ApprovePolicyExact {
+		value = abi.MaxUint256
+	}
+
+	if allowance.Sign() > 0 {
+		return []*big.Int{big.NewInt(0), value}
+	}
+	return []*big.Int{value}
+}
+
+// ensureAllowance - checks balance of watch tower's address and sends approves to `spender` if its allowance is not enough for transfer of `amount` tokens
+func (e *Ethereum) ensureAllowance(ctx context.Context, tokenAddress, spender common.Address, amount *big.Int) error {
+	token, err := newErc20Token(tokenAddress, e.client)
+	if err != nil {
+		return err
+	}
+
+	balance, err := token.balanceOf(ctx, e.address)
+	if err != nil {
+		return err
+	}
+	if balance.Cmp(amount) < 0 {
+		return errors.Errorf("insufficient balance of token %s: %s < %s", tokenAddress.Hex(), balance.String(), amount.String())
+	}
+
+	allowance, err := token.allowance(ctx, e.address, spender)
+	if err != nil {
+		return err
+	}
+
+	for _, value := range approveValues(allowance, amount, e.cfg.ApprovePolicy) {
+		e.log.Info().Str("token", tokenAddress.Hex()).Str("spender", spender.Hex()).Str("allowance", allowance.String()).Str("value", value.String()).Msg("approve")
+
+		value := value
+		tx, err := e.transact(ctx, e.cfg.FeePolicy.Initiate, nil, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return token.approve(opts, spender, value)
+		})
+		if err != nil {
+			return errors.Wrap(err, "approve")
+		}
+
+		// the next transaction is estimated against the state with the approve
+		if err := e.waitMined(ctx, tx); err != nil {
+			return errors.Wrap(err, "approve")
+		}
+	}
+	return nil
+}
+
+func (e *Ethereum) waitMined(ctx context.Context, tx *types.Transaction) error {
+	waitCtx, cancel := context.WithTimeout(ctx, approveTimeout)
+	defer cancel()
+
+	receipt, err := bind.WaitMined(waitCtx, e.client, tx)
+	if err != nil {
+		return err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return errors.Errorf("transaction %s is failed", tx.Hash().Hex())
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package ethereum
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_approveValues(t *testing.T) {
+	tests := []struct {
+		name      string
+		allowance *big.Int
+		required  *big.Int
+		policy    string
+		want      []*big.Int
+	}{
+		{
+			name:      "enough allowance",
+			allowance: big.NewInt(100),
+			required:  big.NewInt(100),
+			policy:    ApprovePolicyExact,
+		}, {
+			name:      "exact from zero",
+			allowance: big.NewInt(0),
+			required:  big.NewInt(100),
+			policy:    ApprovePolicyExact,
+			want:      []*big.Int{big.NewInt(100)},
+		}, {
+			name:      "exact with reset",
+			allowance: big.NewInt(10),
+			required:  big.NewInt(100),
+			policy:    ApprovePolicyExact,
+			want:      []*big.Int{big.NewInt(0), big.NewInt(100)},
+		}, {
+			name:      "unlimited from zero",
+			allowance: big.NewInt(0),
+			required:  big.NewInt(100),
+			policy:    ApprovePolicyUnlimited,
+			want:      []*big.Int{abi.MaxUint256},
+		}, {
+			name:      "unlimited with reset",
+			allowance: big.NewInt(10),
+			required:  big.NewInt(100),
+			policy:    ApprovePolicyUnlimited,
+			want:      []*big.Int{big.NewInt(0), abi.MaxUint256},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, approveValues(tt.allowance, tt.required, tt.policy))
+		})
+	}
+}
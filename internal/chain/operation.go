@@ -1,11 +1,19 @@
 package chain
 
-// Operation -
+import "github.com/shopspring/decimal"
+
+// Operation - `Fee` is paid by the operation in minimal units of chain's native currency. It's zero if it's unknown, e.g. for pending operations.
 type Operation struct {
 	Hash         string
 	ChainType    ChainType
 	Status       OperationStatus
 	HashedSecret Hex
+	Fee          decimal.Decimal `json:"-"` // only pending operations are stored, so fee isn't persisted
+}
+
+// IsRejected - failed operation without hash is rejected before sending, e.g. by simulation. It's identified by hashed secret only.
+func (operation Operation) IsRejected() bool {
+	return operation.Status == Failed && operation.Hash == ""
 }
 
 // OperationStatus -
@@ -0,0 +1,106 @@
+package tezos
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/atomex-protocol/watch_tower/internal/chain"
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/pkg/errors"
+)
+
+// default max count of transactions in operation group
+const defaultBatchSize = 10
+
+// queuedTransaction - transaction which is waiting for sending. Its gas limit, storage limit and fee are caps from tezos.yml.
+type queuedTransaction struct {
+	hashedSecret chain.Hex
+	transaction  node.Transaction
+	// tokens which have to be approved before the transaction. It's nil if the transaction doesn't transfer tokens.
+	allowance *tokenAllowance
+	// approvals which are sent in the same operation group before the transaction
+	approvals []node.Transaction
+}
+
+// size - returns count of operation contents of the transaction
+func (q queuedTransaction) size() int {
+	return len(q.approvals) + 1
+}
+
+// nextBatch - returns at most `BatchSize` queued transactions ordered by hashed secrets
+func (t *Tezos) nextBatch() []queuedTransaction {
+	batch := make([]queuedTransaction, 0, len(t.transactions))
+	for _, tx := range t.transactions {
+		batch = append(batch, tx)
+	}
+	sort.Slice(batch, func(i, j int) bool {
+		return batch[i].hashedSecret < batch[j].hashedSecret
+	})
+	if len(batch) > t.cfg.BatchSize {
+		batch = batch[:t.cfg.BatchSize]
+	}
+	return batch
+}
+
+// withCounters - returns transactions of the batch preceded by their approvals with sequential counters starting after `counter`
+func withCounters(batch []queuedTransaction, counter uint64) []node.Transaction {
+	transactions := make([]node.Transaction, 0, len(batch))
+	for i := range batch {
+		transactions = append(transactions, batch[i].approvals...)
+		transactions = append(transactions, batch[i].transaction)
+	}
+	for i := range transactions {
+		transactions[i].Counter = fmt.Sprintf("%d", counter+uint64(i)+1)
+	}
+	return transactions
+}
+
+// bisect - splits the batch which was rejected by simulation until failing transactions are found. It returns transactions accepted by simulation and rejected ones.
+func (t *Tezos) bisect(ctx context.Context, branch, chainID string, counter uint64, batch []queuedTransaction) (accepted []queuedTransaction, rejected []queuedTransaction, err error) {
+	if len(batch) == 1 {
+		return nil, batch, nil
+	}
+
+	middle := len(batch) / 2
+	for _, part := range [][]queuedTransaction{batch[:middle], batch[middle:]} {
+		prepared, err := t.withApprovals(ctx, chainID, part)
+		if err != nil {
+			return nil, nil, err
+		}
+		_, err = t.estimate(ctx, branch, chainID, withCounters(prepared, counter))
+		switch {
+		case err == nil:
+			accepted = append(accepted, part...)
+		case errors.Is(err, ErrSimulationRejected):
+			partAccepted, partRejected, err := t.bisect(ctx, branch, chainID, counter, part)
+			if err != nil {
+				return nil, nil, err
+			}
+			accepted = append(accepted, partAccepted...)
+			rejected = append(rejected, partRejected...)
+		default:
+			return nil, nil, err
+		}
+	}
+	return accepted, rejected, nil
+}
+
+// reject - removes transactions which are rejected by simulation from queue
+func (t *Tezos) reject(rejected []queuedTransaction) {
+	for i := range rejected {
+		t.log.Error().Str("hashed_secret", rejected[i].hashedSecret.String()).Str("contract", rejected[i].transaction.Destination).Str("entrypoint", rejected[i].transaction.Parameters.Entrypoint).Msg("transaction is rejected by simulation and removed from queue")
+		t.dequeueRejected(rejected[i])
+	}
+}
+
+// dequeueRejected - removes transaction from queue and reports failed operation without hash, so the transaction is identified by its hashed secret
+func (t *Tezos) dequeueRejected(queued queuedTransaction) {
+	delete(t.transactions, queued.hashedSecret)
+
+	t.operations <- chain.Operation{
+		Status:       chain.Failed,
+		ChainType:    chain.ChainTypeTezos,
+		HashedSecret: queued.hashedSecret,
+	}
+}
@@ -0,0 +1,112 @@
+package tezos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/atomex-protocol/watch_tower/internal/chain"
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSimulationServer - emulates `run_operation`: transactions with amount `failingAmount` fail and the rest of the group is backtracked
+func newSimulationServer(t *testing.T, failingAmount string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request runOperationRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+
+		var failed bool
+		for _, content := range request.Operation.Contents {
+			if content.Amount == failingAmount {
+				failed = true
+			}
+		}
+
+		contents := make([]map[string]interface{}, len(request.Operation.Contents))
+		for i, content := range request.Operation.Contents {
+			result := map[string]interface{}{"status": "applied", "consumed_milligas": "1500000"}
+			switch {
+			case content.Amount == failingAmount:
+				result = map[string]interface{}{"status": "failed"}
+			case failed:
+				result = map[string]interface{}{"status": "backtracked"}
+			}
+			contents[i] = map[string]interface{}{
+				"kind":     "transaction",
+				"metadata": map[string]interface{}{"operation_result": result},
+			}
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{"contents": contents}))
+	}))
+}
+
+func TestTezos_bisect(t *testing.T) {
+	server := newSimulationServer(t, "13")
+	defer server.Close()
+
+	tz := &Tezos{
+		cfg:                          Config{Node: server.URL, BatchSize: 10},
+		client:                       server.Client(),
+		log:                          zerolog.Nop(),
+		transactions:                 make(map[chain.Hex]queuedTransaction),
+		operations:                   make(chan chain.Operation, 16),
+		hardGasLimitPerOperation:     defaultHardGasLimitPerOperation,
+		hardGasLimitPerBlock:         defaultHardGasLimitPerBlock,
+		hardStorageLimitPerOperation: defaultHardStorageLimitPerOperation,
+	}
+
+	value := json.RawMessage(`{"bytes":"00"}`)
+	amounts := []string{"0", "13", "0", "13", "0", "0", "0"}
+	for i, amount := range amounts {
+		tz.addToQueue(queuedTransaction{
+			hashedSecret: chain.Hex(fmt.Sprintf("%02d", i)),
+			transaction: node.Transaction{
+				Source:      "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK6",
+				Destination: "KT1VG2WtYdSWz5E7chTeAdDPZNy2MpP8pTfL",
+				Amount:      amount,
+				Parameters:  &node.Parameters{Entrypoint: "redeem", Value: &value},
+			},
+		})
+	}
+
+	batch := tz.nextBatch()
+	require.Len(t, batch, len(amounts))
+
+	_, err := tz.estimate(context.Background(), "", "", withCounters(batch, 100))
+	require.ErrorIs(t, err, ErrSimulationRejected)
+
+	accepted, rejected, err := tz.bisect(context.Background(), "", "", 100, batch)
+	require.NoError(t, err)
+
+	hashedSecrets := func(batch []queuedTransaction) []chain.Hex {
+		result := make([]chain.Hex, len(batch))
+		for i := range batch {
+			result[i] = batch[i].hashedSecret
+		}
+		return result
+	}
+	assert.Equal(t, []chain.Hex{"00", "02", "04", "05", "06"}, hashedSecrets(accepted))
+	assert.Equal(t, []chain.Hex{"01", "03"}, hashedSecrets(rejected))
+
+	tz.reject(rejected)
+	assert.Len(t, tz.transactions, 5)
+	assert.Len(t, tz.operations, 2)
+	operation := <-tz.operations
+	assert.True(t, operation.IsRejected())
+	assert.Equal(t, chain.Hex("01"), operation.HashedSecret)
+
+	estimated, err := tz.estimate(context.Background(), "", "", withCounters(accepted, 100))
+	require.NoError(t, err)
+	require.Len(t, estimated, 5)
+	assert.Equal(t, "101", estimated[0].Counter)
+	assert.Equal(t, "1600", estimated[0].GasLimit)
+
+	tz.cfg.BatchSize = 2
+	assert.Equal(t, []chain.Hex{"00", "02"}, hashedSecrets(tz.nextBatch()))
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 	"sync"
 	"time"
@@ -29,6 +30,7 @@ type Tezos struct {
 	cfg       Config
 	rpc       node.API
 	api       *api.API
+	client    *http.Client
 	key       *keys.Key
 	ttl       string
 	minPayoff decimal.Decimal
@@ -42,9 +44,20 @@ type Tezos struct {
 	operations chan chain.Operation
 
 	transactionsMutex sync.Mutex
-	transactions      map[chain.Hex]node.Transaction
+	transactions      map[chain.Hex]queuedTransaction
 	lastCounter       uint64
 
+	tokenStandardsMutex sync.Mutex
+	tokenStandards      map[string]string
+
+	injectedMutex    sync.RWMutex
+	injected         map[string]injectedOperation
+	maxOperationsTTL uint64
+
+	hardGasLimitPerOperation     int64
+	hardGasLimitPerBlock         int64
+	hardStorageLimitPerOperation int64
+
 	wg sync.WaitGroup
 }
 
@@ -57,6 +70,8 @@ type Config struct {
 	Tokens          []string
 	LogLevel        zerolog.Level
 	TTL             int64
+	Confirmations   uint64
+	BatchSize       int
 	OperaitonParams OperationParamsByContracts
 }
 
@@ -65,10 +80,6 @@ func New(cfg Config) (*Tezos, error) {
 	if cfg.LogLevel == 0 {
 		cfg.LogLevel = zerolog.InfoLevel
 	}
-	if len(cfg.OperaitonParams) == 0 {
-		return nil, errors.New("empty operations params for tezos. you have to create tezos.yml")
-	}
-
 	secret, err := chain.LoadSecret("TEZOS_PRIVATE")
 	if err != nil {
 		return nil, err
@@ -82,6 +93,9 @@ func New(cfg Config) (*Tezos, error) {
 	if cfg.TTL < 1 {
 		cfg.TTL = 5
 	}
+	if cfg.BatchSize < 1 {
+		cfg.BatchSize = defaultBatchSize
+	}
 
 	tokens := make(map[string]*atomexteztoken.Atomexteztoken)
 	for i := range cfg.Tokens {
@@ -91,17 +105,20 @@ func New(cfg Config) (*Tezos, error) {
 	}
 
 	tez := &Tezos{
-		cfg:           cfg,
-		rpc:           node.NewMainRPC(cfg.Node),
-		key:           key,
-		api:           api.New(cfg.TzKT),
-		tezContract:   atomextez.New(cfg.TzKT),
-		tokenContract: tokens,
-		ttl:           fmt.Sprintf("%d", cfg.TTL),
-		log:           logger.New(logger.WithLogLevel(cfg.LogLevel), logger.WithModuleName("tezos")),
-		events:        make(chan chain.Event, 1024*16),
-		operations:    make(chan chain.Operation, 1024),
-		transactions:  make(map[chain.Hex]node.Transaction),
+		cfg:            cfg,
+		rpc:            node.NewMainRPC(cfg.Node),
+		key:            key,
+		api:            api.New(cfg.TzKT),
+		client:         &http.Client{Timeout: time.Minute},
+		tezContract:    atomextez.New(cfg.TzKT),
+		tokenContract:  tokens,
+		ttl:            fmt.Sprintf("%d", cfg.TTL),
+		log:            logger.New(logger.WithLogLevel(cfg.LogLevel), logger.WithModuleName("tezos")),
+		events:         make(chan chain.Event, 1024*16),
+		operations:     make(chan chain.Operation, 1024),
+		transactions:   make(map[chain.Hex]queuedTransaction),
+		injected:       make(map[string]injectedOperation),
+		tokenStandards: make(map[string]string),
 	}
 
 	tez.tezContract.ChangeAddress(cfg.Contract)
@@ -126,9 +143,50 @@ func (t *Tezos) Wallet() chain.Wallet {
 	}
 }
 
+// Balance -
+func (t *Tezos) Balance(ctx context.Context, args chain.BalanceArgs) (decimal.Decimal, error) {
+	requestCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if args.Contract == "" {
+		balance, err := t.rpc.ContractBalance(requestCtx, "head", t.key.PubKey.GetAddress())
+		if err != nil {
+			return decimal.Zero, err
+		}
+		return decimal.NewFromString(balance)
+	}
+	return t.tokenBalance(requestCtx, args.Contract, args.TokenID)
+}
+
 // Init -
 func (t *Tezos) Init(ctx context.Context) error {
 	t.log.Info().Msg("initializing...")
+
+	metadataCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	metadata, err := t.rpc.Metadata(metadataCtx, "head")
+	if err != nil || metadata.MaxOperationsTTL == 0 {
+		t.log.Warn().Err(err).Msg("can't receive max operations TTL. using default.")
+		t.maxOperationsTTL = defaultMaxOperationsTTL
+	} else {
+		t.maxOperationsTTL = metadata.MaxOperationsTTL
+	}
+
+	constantsCtx, constantsCancel := context.WithTimeout(ctx, 10*time.Second)
+	defer constantsCancel()
+
+	constants, err := t.rpc.Constants(constantsCtx, "head")
+	if err != nil {
+		t.log.Warn().Err(err).Msg("can't receive protocol constants. using default limits.")
+		t.hardGasLimitPerOperation = defaultHardGasLimitPerOperation
+		t.hardGasLimitPerBlock = defaultHardGasLimitPerBlock
+		t.hardStorageLimitPerOperation = defaultHardStorageLimitPerOperation
+	} else {
+		t.hardGasLimitPerOperation = constants.HardGasLimitPerOperation
+		t.hardGasLimitPerBlock = constants.HardGasLimitPerBlock
+		t.hardStorageLimitPerOperation = constants.HardStorageLimitPerOperation
+	}
 	return nil
 }
 
@@ -139,6 +197,9 @@ func (t *Tezos) Run(ctx context.Context) error {
 	t.wg.Add(1)
 	go t.sendTransactions(ctx)
 
+	t.wg.Add(1)
+	go t.listenOperations(ctx)
+
 	t.wg.Add(1)
 	go t.listenTezosContract(ctx)
 
@@ -207,29 +268,13 @@ func (t *Tezos) listenTezosContract(ctx context.Context) {
 			}
 
 		case initiate := <-t.tezContract.InitiateEvents():
-			t.operations <- chain.Operation{
-				Hash:      initiate.Hash,
-				ChainType: chain.ChainTypeTezos,
-				Status:    toOperationStatus(initiate.Status),
-			}
+			t.contractOperation(initiate.Hash, initiate.Status)
 		case add := <-t.tezContract.AddEvents():
-			t.operations <- chain.Operation{
-				Hash:      add.Hash,
-				ChainType: chain.ChainTypeTezos,
-				Status:    toOperationStatus(add.Status),
-			}
+			t.contractOperation(add.Hash, add.Status)
 		case redeem := <-t.tezContract.RedeemEvents():
-			t.operations <- chain.Operation{
-				Hash:      redeem.Hash,
-				ChainType: chain.ChainTypeTezos,
-				Status:    toOperationStatus(redeem.Status),
-			}
+			t.contractOperation(redeem.Hash, redeem.Status)
 		case refund := <-t.tezContract.RefundEvents():
-			t.operations <- chain.Operation{
-				Hash:      refund.Hash,
-				ChainType: chain.ChainTypeTezos,
-				Status:    toOperationStatus(refund.Status),
-			}
+			t.contractOperation(refund.Hash, refund.Status)
 		}
 	}
 }
@@ -248,23 +293,11 @@ func (t *Tezos) listenTezosTokenContract(ctx context.Context, token *atomextezto
 				continue
 			}
 		case initiate := <-token.InitiateEvents():
-			t.operations <- chain.Operation{
-				Hash:      initiate.Hash,
-				ChainType: chain.ChainTypeTezos,
-				Status:    toOperationStatus(initiate.Status),
-			}
+			t.contractOperation(initiate.Hash, initiate.Status)
 		case redeem := <-token.RedeemEvents():
-			t.operations <- chain.Operation{
-				Hash:      redeem.Hash,
-				ChainType: chain.ChainTypeTezos,
-				Status:    toOperationStatus(redeem.Status),
-			}
+			t.contractOperation(redeem.Hash, redeem.Status)
 		case refund := <-token.RefundEvents():
-			t.operations <- chain.Operation{
-				Hash:      refund.Hash,
-				ChainType: chain.ChainTypeTezos,
-				Status:    toOperationStatus(refund.Status),
-			}
+			t.contractOperation(refund.Hash, refund.Status)
 		}
 	}
 }
@@ -273,10 +306,8 @@ func (t *Tezos) listenTezosTokenContract(ctx context.Context, token *atomextezto
 func (t *Tezos) Initiate(ctx context.Context, args chain.InitiateArgs) error {
 	t.log.Info().Str("hashed_secret", args.HashedSecret.String()).Msg("initiate")
 
-	operationParams, ok := t.cfg.OperaitonParams[args.Contract]
-	if !ok {
-		return errors.Errorf("can't find operation parameters for %s", args.Contract)
-	}
+	// operation parameters from tezos.yml are optional caps of estimated values
+	operationParams := t.cfg.OperaitonParams[args.Contract]
 
 	tx := node.Transaction{
 		Source:       t.key.PubKey.GetAddress(),
@@ -294,6 +325,10 @@ func (t *Tezos) Initiate(ctx context.Context, args chain.InitiateArgs) error {
 		return err
 	}
 
+	queued := queuedTransaction{
+		hashedSecret: args.HashedSecret,
+	}
+
 	var value []byte
 	switch args.Contract {
 	case t.cfg.Contract:
@@ -321,6 +356,12 @@ func (t *Tezos) Initiate(ctx context.Context, args chain.InitiateArgs) error {
 			PayoffAmount: tezgen.NewInt(args.PayOff.BigInt().Int64()),
 			TotalAmount:  tezgen.NewInt(args.Amount.BigInt().Int64()),
 		})
+		queued.allowance = &tokenAllowance{
+			token:   args.TokenAddress,
+			tokenID: args.TokenID,
+			spender: args.Contract,
+			amount:  args.Amount.BigInt(),
+		}
 	}
 	if err != nil {
 		return err
@@ -330,8 +371,9 @@ func (t *Tezos) Initiate(ctx context.Context, args chain.InitiateArgs) error {
 	}
 	params := json.RawMessage(value)
 	tx.Parameters.Value = &params
+	queued.transaction = tx
 
-	t.addToQueue(tx, args.HashedSecret)
+	t.addToQueue(queued)
 	return nil
 }
 
@@ -346,24 +388,24 @@ func (t *Tezos) Redeem(ctx context.Context, hashedSecret, secret chain.Hex, cont
 		return err
 	}
 
-	operationParams, ok := t.cfg.OperaitonParams[contract]
-	if !ok {
-		return errors.Errorf("can't find operation parameters for %s", contract)
-	}
+	operationParams := t.cfg.OperaitonParams[contract]
 
 	params := json.RawMessage(value)
-	t.addToQueue(node.Transaction{
-		Source:       t.key.PubKey.GetAddress(),
-		Amount:       "0",
-		StorageLimit: operationParams.StorageLimit.Redeem,
-		GasLimit:     operationParams.GasLimit.Redeem,
-		Fee:          operationParams.Fee.Redeem,
-		Destination:  contract,
-		Parameters: &node.Parameters{
-			Entrypoint: "redeem",
-			Value:      &params,
+	t.addToQueue(queuedTransaction{
+		hashedSecret: hashedSecret,
+		transaction: node.Transaction{
+			Source:       t.key.PubKey.GetAddress(),
+			Amount:       "0",
+			StorageLimit: operationParams.StorageLimit.Redeem,
+			GasLimit:     operationParams.GasLimit.Redeem,
+			Fee:          operationParams.Fee.Redeem,
+			Destination:  contract,
+			Parameters: &node.Parameters{
+				Entrypoint: "redeem",
+				Value:      &params,
+			},
 		},
-	}, hashedSecret)
+	})
 	return nil
 }
 
@@ -378,30 +420,38 @@ func (t *Tezos) Refund(ctx context.Context, hashedSecret chain.Hex, contract str
 		return err
 	}
 
-	operationParams, ok := t.cfg.OperaitonParams[contract]
-	if !ok {
-		return errors.Errorf("can't find operation parameters for %s", contract)
-	}
+	operationParams := t.cfg.OperaitonParams[contract]
 
 	params := json.RawMessage(value)
-	t.addToQueue(node.Transaction{
-		Source:       t.key.PubKey.GetAddress(),
-		Amount:       "0",
-		StorageLimit: operationParams.StorageLimit.Refund,
-		GasLimit:     operationParams.GasLimit.Refund,
-		Fee:          operationParams.Fee.Refund,
-		Destination:  contract,
-		Parameters: &node.Parameters{
-			Entrypoint: "refund",
-			Value:      &params,
+	t.addToQueue(queuedTransaction{
+		hashedSecret: hashedSecret,
+		transaction: node.Transaction{
+			Source:       t.key.PubKey.GetAddress(),
+			Amount:       "0",
+			StorageLimit: operationParams.StorageLimit.Refund,
+			GasLimit:     operationParams.GasLimit.Refund,
+			Fee:          operationParams.Fee.Refund,
+			Destination:  contract,
+			Parameters: &node.Parameters{
+				Entrypoint: "refund",
+				Value:      &params,
+			},
 		},
-	}, hashedSecret)
+	})
 	return nil
 }
 
-// Restore -
-func (t *Tezos) Restore(ctx context.Context) error {
-	t.log.Info().Msg("restoring...")
+// Restore - sends events of swaps which were changed after `fromLevel`. If `fromLevel` is 0 all swaps are restored.
+func (t *Tezos) Restore(ctx context.Context, fromLevel uint64) error {
+	t.log.Info().Uint64("from_level", fromLevel).Msg("restoring...")
+
+	headCtx, headCancel := context.WithTimeout(ctx, 10*time.Second)
+	defer headCancel()
+
+	head, err := t.api.GetHead(headCtx)
+	if err != nil {
+		return err
+	}
 
 	getBigmapsCtx, getBigmapsCancel := context.WithTimeout(ctx, 10*time.Second)
 	defer getBigmapsCancel()
@@ -414,12 +464,18 @@ func (t *Tezos) Restore(ctx context.Context) error {
 	}
 
 	for _, bm := range bigMaps {
+		if fromLevel > 0 {
+			if err := t.restoreFromBigMapUpdates(ctx, bm, fromLevel); err != nil {
+				return err
+			}
+			continue
+		}
 		if err := t.restoreFromBigMap(ctx, bm); err != nil {
 			return err
 		}
 	}
 
-	t.events <- chain.RestoredEvent{Chain: chain.ChainTypeTezos}
+	t.events <- chain.RestoredEvent{Chain: chain.ChainTypeTezos, BlockNumber: head.Level}
 	return nil
 }
 
@@ -451,6 +507,48 @@ func (t *Tezos) restoreFromBigMap(ctx context.Context, bm api.BigMap) error {
 	return nil
 }
 
+func (t *Tezos) restoreFromBigMapUpdates(ctx context.Context, bm api.BigMap, fromLevel uint64) error {
+	limit := 100
+	var end bool
+	var offset int
+	for !end {
+		getUpdatesCtx, getUpdatesCancel := context.WithTimeout(ctx, 10*time.Second)
+		defer getUpdatesCancel()
+
+		updates, err := t.api.GetBigmapUpdates(getUpdatesCtx, map[string]string{
+			"bigmap":   fmt.Sprintf("%d", bm.Ptr),
+			"level.gt": fmt.Sprintf("%d", fromLevel),
+			"sort.asc": "id",
+			"limit":    fmt.Sprintf("%d", limit),
+			"offset":   fmt.Sprintf("%d", offset),
+		})
+		if err != nil {
+			return err
+		}
+
+		for i := range updates {
+			if updates[i].Content == nil {
+				continue
+			}
+			var key string
+			if err := json.Unmarshal(updates[i].Content.Key, &key); err != nil {
+				return err
+			}
+			decodedKey, err := hex.DecodeString(key)
+			if err != nil {
+				return err
+			}
+			if err := t.restoreUpdate(ctx, bm, decodedKey, updates[i].Action, updates[i].Level, updates[i].Content.Value); err != nil {
+				return err
+			}
+		}
+
+		end = len(updates) != limit
+		offset += len(updates)
+	}
+	return nil
+}
+
 func (t *Tezos) restoreFinilizationSwap(ctx context.Context, bm api.BigMap, key api.BigMapKey) error {
 	updates, err := t.api.GetBigmapKeyUpdates(ctx, uint64(bm.Ptr), key.Key, nil)
 	if err != nil {
@@ -463,37 +561,39 @@ func (t *Tezos) restoreFinilizationSwap(ctx context.Context, bm api.BigMap, key
 	}
 
 	for i := range updates {
-		if t.cfg.Contract == bm.Contract.Address {
-			var bmUpdate atomextez.BigMapUpdate
-			if err := json.Unmarshal(updates[i].Value, &bmUpdate.BigMap.Value); err != nil {
-				return err
-			}
-			bmUpdate.BigMap.Key = atomextez.KeyBigMap(decodedKey)
-			bmUpdate.BigMap.Ptr = &bm.Ptr
-			bmUpdate.Action = updates[i].Action
-			bmUpdate.Contract = bm.Contract.Address
-			bmUpdate.Level = updates[i].Level
-			if err := t.parseTezosContractUpdate(ctx, bmUpdate); err != nil {
-				return err
-			}
-		} else {
-			var bmUpdate atomexteztoken.BigMap0Update
-			if err := json.Unmarshal(updates[i].Value, &bmUpdate.BigMap0.Value); err != nil {
-				return err
-			}
-			bmUpdate.BigMap0.Key = atomexteztoken.Key0(decodedKey)
-			bmUpdate.BigMap0.Ptr = &bm.Ptr
-			bmUpdate.Action = updates[i].Action
-			bmUpdate.Contract = bm.Contract.Address
-			bmUpdate.Level = updates[i].Level
-			if err := t.parseTokenContractUpdate(ctx, bmUpdate); err != nil {
-				return err
-			}
+		if err := t.restoreUpdate(ctx, bm, decodedKey, updates[i].Action, updates[i].Level, updates[i].Value); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+func (t *Tezos) restoreUpdate(ctx context.Context, bm api.BigMap, key []byte, action string, level uint64, value json.RawMessage) error {
+	if t.cfg.Contract == bm.Contract.Address {
+		var bmUpdate atomextez.BigMapUpdate
+		if err := json.Unmarshal(value, &bmUpdate.BigMap.Value); err != nil {
+			return err
+		}
+		bmUpdate.BigMap.Key = atomextez.KeyBigMap(key)
+		bmUpdate.BigMap.Ptr = &bm.Ptr
+		bmUpdate.Action = action
+		bmUpdate.Contract = bm.Contract.Address
+		bmUpdate.Level = level
+		return t.parseTezosContractUpdate(ctx, bmUpdate)
+	}
+
+	var bmUpdate atomexteztoken.BigMap0Update
+	if err := json.Unmarshal(value, &bmUpdate.BigMap0.Value); err != nil {
+		return err
+	}
+	bmUpdate.BigMap0.Key = atomexteztoken.Key0(key)
+	bmUpdate.BigMap0.Ptr = &bm.Ptr
+	bmUpdate.Action = action
+	bmUpdate.Contract = bm.Contract.Address
+	bmUpdate.Level = level
+	return t.parseTokenContractUpdate(ctx, bmUpdate)
+}
+
 func (t *Tezos) parseTezosContractUpdate(ctx context.Context, update atomextez.BigMapUpdate) error {
 	hashedSecret := chain.NewHexFromBytes(update.BigMap.Key)
 
@@ -636,20 +736,23 @@ func (t *Tezos) parseTokenContractUpdate(ctx context.Context, update atomextezto
 	return nil
 }
 
-func (t *Tezos) addToQueue(transaction node.Transaction, hashedSecret chain.Hex) {
+func (t *Tezos) addToQueue(queued queuedTransaction) {
+	// approvals are computed before sending by current allowance
+	queued.approvals = nil
+
 	t.transactionsMutex.Lock()
-	t.transactions[hashedSecret] = transaction
+	t.transactions[queued.hashedSecret] = queued
 	t.transactionsMutex.Unlock()
 }
 
 func (t *Tezos) send(ctx context.Context) error {
+	t.transactionsMutex.Lock()
+	defer t.transactionsMutex.Unlock()
+
 	if len(t.transactions) == 0 {
 		return nil
 	}
 
-	t.transactionsMutex.Lock()
-	defer t.transactionsMutex.Unlock()
-
 	headerCtx, headerCancel := context.WithTimeout(ctx, 10*time.Second)
 	defer headerCancel()
 	header, err := t.rpc.Header(headerCtx, fmt.Sprintf("head~%s", t.ttl))
@@ -664,15 +767,54 @@ func (t *Tezos) send(ctx context.Context) error {
 	if counter == t.lastCounter {
 		return nil
 	}
-	t.lastCounter = counter
 
-	operations := make([]node.Operation, 0)
-	for _, tx := range t.transactions {
-		counter++
-		tx.Counter = fmt.Sprintf("%d", counter)
+	batch, err := t.withApprovals(ctx, header.ChainID, t.nextBatch())
+	if err != nil {
+		return errors.Wrap(err, "approvals")
+	}
+	estimated, err := t.estimate(ctx, header.Hash, header.ChainID, withCounters(batch, counter))
+	if errors.Is(err, ErrSimulationRejected) {
+		t.log.Warn().Err(err).Int("size", len(batch)).Msg("batch is rejected by simulation. looking for failing transactions...")
+
+		var rejected []queuedTransaction
+		batch, rejected, err = t.bisect(ctx, header.Hash, header.ChainID, counter, batch)
+		if err != nil {
+			return errors.Wrap(err, "bisect")
+		}
+		t.reject(rejected)
+		if len(batch) == 0 {
+			return nil
+		}
+		if batch, err = t.withApprovals(ctx, header.ChainID, batch); err != nil {
+			return errors.Wrap(err, "approvals")
+		}
+		estimated, err = t.estimate(ctx, header.Hash, header.ChainID, withCounters(batch, counter))
+	}
+	if err != nil {
+		return errors.Wrap(err, "estimate")
+	}
+
+	var exceeded bool
+	var offset int
+	for i := range batch {
+		// caps from tezos.yml are related to Atomex contracts only, so approvals aren't checked
+		offset += batch[i].size()
+		if err := checkCaps(estimated[offset-1], batch[i].transaction); err != nil {
+			t.log.Err(err).Str("hashed_secret", batch[i].hashedSecret.String()).Str("contract", batch[i].transaction.Destination).Msg("transaction is removed from queue")
+			t.dequeueRejected(batch[i])
+			exceeded = true
+		}
+	}
+	if exceeded {
+		// counters and fees of the rest transactions are changed, so they will be estimated again on next tick
+		return nil
+	}
+
+	operations := make([]node.Operation, 0, len(estimated))
+	for i := range estimated {
 		operations = append(operations, node.Operation{
 			Kind: node.KindTransaction,
-			Body: tx,
+			Body: estimated[i],
 		})
 	}
 
@@ -696,20 +838,50 @@ func (t *Tezos) send(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	t.lastCounter = counter
+
+	contents := make(map[uint64]queuedTransaction, len(batch))
+	for i := range batch {
+		counter += uint64(batch[i].size())
+		contents[counter] = batch[i]
+	}
+	t.trackOperation(hash, header.Level, t.lastCounter, contents)
 
-	for hashedSecret := range t.transactions {
+	for i := range batch {
 		t.operations <- chain.Operation{
 			Status:       chain.Pending,
 			Hash:         hash,
 			ChainType:    chain.ChainTypeTezos,
-			HashedSecret: hashedSecret,
+			HashedSecret: batch[i].hashedSecret,
 		}
-		delete(t.transactions, hashedSecret)
+		delete(t.transactions, batch[i].hashedSecret)
 	}
 
 	return nil
 }
 
+// checkCaps - checks estimated transaction against gas limit, storage limit and fee of queued transaction which are set from tezos.yml
+func checkCaps(estimated, caps node.Transaction) error {
+	for _, field := range []struct {
+		name  string
+		value string
+		cap   string
+	}{
+		{"gas_limit", estimated.GasLimit, caps.GasLimit},
+		{"storage_limit", estimated.StorageLimit, caps.StorageLimit},
+		{"fee", estimated.Fee, caps.Fee},
+	} {
+		exceeds, err := exceedsCap(field.value, field.cap)
+		if err != nil {
+			return errors.Wrap(err, field.name)
+		}
+		if exceeds {
+			return errors.Errorf("estimated %s %s exceeds cap %s", field.name, field.value, field.cap)
+		}
+	}
+	return nil
+}
+
 func (t *Tezos) counter(ctx context.Context) (uint64, error) {
 	counterCtx, counterCancel := context.WithTimeout(ctx, 10*time.Second)
 	defer counterCancel()
@@ -0,0 +1,352 @@
+package tezos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/dipdup-net/go-lib/tools/forge"
+	"github.com/pkg/errors"
+)
+
+// ErrSimulationRejected - operation group is rejected by `run_operation` because one of its transactions is failed by contract's script.
+// Other simulation errors, e.g. counter or balance ones, are transient, so the batch is kept in queue.
+var ErrSimulationRejected = errors.New("simulation rejected")
+
+// identifiers of protocol errors which mean that contract's script fails the transaction. Full identifiers are prefixed by protocol.
+var scriptErrors = []string{".script_rejected", ".runtime_error"}
+
+// minimal fee constants of default baker configuration
+const (
+	minimalFees              = 100
+	minimalNanotezPerByte    = 100
+	minimalNanotezPerGasUnit = 100
+)
+
+// safety margins which are added to simulated gas and storage
+const (
+	gasSafetyMargin     = 100
+	storageSafetyMargin = 20
+)
+
+const (
+	branchSize      = 32
+	signatureSize   = 64
+	originationSize = 257
+
+	// signature which is accepted by `run_operation`. It isn't checked by node.
+	zeroSignature = "sigUHx32f9wesZ1n2BWpixXz4AQaZggEtchaQNHYGRCoWNAXx45WGW2ua3apUUUAGMLPwAU41QoaFCzVSL61VaessLg4YbbP"
+)
+
+// default protocol limits. They're used if node's constants can't be received.
+const (
+	defaultHardGasLimitPerOperation     = 1040000
+	defaultHardGasLimitPerBlock         = 5200000
+	defaultHardStorageLimitPerOperation = 60000
+)
+
+type transactionContent struct {
+	Kind string `json:"kind"`
+	node.Transaction
+}
+
+type runOperationRequest struct {
+	Operation struct {
+		Branch    string               `json:"branch"`
+		Contents  []transactionContent `json:"contents"`
+		Signature string               `json:"signature"`
+	} `json:"operation"`
+	ChainID string `json:"chain_id"`
+}
+
+type runOperationResponse struct {
+	Contents []runOperationContent `json:"contents"`
+}
+
+type runOperationContent struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		OperationResult          simulationResult `json:"operation_result"`
+		InternalOperationResults []struct {
+			Result simulationResult `json:"result"`
+		} `json:"internal_operation_results"`
+	} `json:"metadata"`
+}
+
+type simulationResult struct {
+	Status                       string          `json:"status"`
+	ConsumedGas                  string          `json:"consumed_gas"`
+	ConsumedMilligas             string          `json:"consumed_milligas"`
+	PaidStorageSizeDiff          string          `json:"paid_storage_size_diff"`
+	AllocatedDestinationContract bool            `json:"allocated_destination_contract"`
+	OriginatedContracts          []string        `json:"originated_contracts"`
+	Errors                       json.RawMessage `json:"errors"`
+}
+
+func (r simulationResult) gas() (int64, error) {
+	if r.ConsumedMilligas != "" {
+		milligas, err := strconv.ParseInt(r.ConsumedMilligas, 10, 64)
+		if err != nil {
+			return 0, errors.Wrap(err, "consumed_milligas")
+		}
+		return ceilDiv(milligas, 1000), nil
+	}
+	if r.ConsumedGas != "" {
+		gas, err := strconv.ParseInt(r.ConsumedGas, 10, 64)
+		if err != nil {
+			return 0, errors.Wrap(err, "consumed_gas")
+		}
+		return gas, nil
+	}
+	return 0, nil
+}
+
+func (r simulationResult) storage() (int64, error) {
+	var size int64
+	if r.PaidStorageSizeDiff != "" {
+		paid, err := strconv.ParseInt(r.PaidStorageSizeDiff, 10, 64)
+		if err != nil {
+			return 0, errors.Wrap(err, "paid_storage_size_diff")
+		}
+		size += paid
+	}
+	if r.AllocatedDestinationContract {
+		size += originationSize
+	}
+	size += int64(len(r.OriginatedContracts)) * originationSize
+	return size, nil
+}
+
+// limits - returns gas and storage consumed by operation content including its internal operations
+func (c runOperationContent) limits() (gas int64, storage int64, err error) {
+	results := []simulationResult{c.Metadata.OperationResult}
+	for i := range c.Metadata.InternalOperationResults {
+		results = append(results, c.Metadata.InternalOperationResults[i].Result)
+	}
+
+	for i := range results {
+		switch results[i].Status {
+		case "applied":
+		case "failed", "backtracked":
+			return 0, 0, errors.Wrapf(ErrSimulationRejected, "simulated operation is %s: %s", results[i].Status, string(results[i].Errors))
+		default:
+			return 0, 0, errors.Errorf("simulated operation is %s: %s", results[i].Status, string(results[i].Errors))
+		}
+		consumed, err := results[i].gas()
+		if err != nil {
+			return 0, 0, err
+		}
+		paid, err := results[i].storage()
+		if err != nil {
+			return 0, 0, err
+		}
+		gas += consumed
+		storage += paid
+	}
+	return gas, storage, nil
+}
+
+func (t *Tezos) runOperation(ctx context.Context, branch, chainID string, transactions []node.Transaction) ([]runOperationContent, error) {
+	var request runOperationRequest
+	request.ChainID = chainID
+	request.Operation.Branch = branch
+	request.Operation.Signature = zeroSignature
+	request.Operation.Contents = make([]transactionContent, len(transactions))
+	for i := range transactions {
+		request.Operation.Contents[i] = transactionContent{
+			Kind:        node.KindTransaction,
+			Transaction: transactions[i],
+		}
+	}
+
+	var response runOperationResponse
+	link := fmt.Sprintf("%s/chains/main/blocks/head/helpers/scripts/run_operation", strings.TrimSuffix(t.cfg.Node, "/"))
+	if err := t.requestJSON(ctx, link, request, &response); err != nil {
+		var reqErr requestError
+		// node responds with protocol errors if operation can't be applied. Only script errors reject the batch: balance or counter errors are transient.
+		if errors.As(err, &reqErr) && reqErr.code == http.StatusInternalServerError && isScriptError(reqErr.body) {
+			return nil, errors.Wrapf(ErrSimulationRejected, "run_operation: %s", string(reqErr.body))
+		}
+		return nil, errors.Wrap(err, "run_operation")
+	}
+	if len(response.Contents) != len(transactions) {
+		return nil, errors.Errorf("run_operation: invalid contents count: expected %d got %d", len(transactions), len(response.Contents))
+	}
+	return response.Contents, nil
+}
+
+// isScriptError - checks if protocol errors of node's response contain error of contract's script
+func isScriptError(body []byte) bool {
+	var protocolErrors []struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(body, &protocolErrors); err != nil {
+		return false
+	}
+	for i := range protocolErrors {
+		for _, suffix := range scriptErrors {
+			if strings.HasSuffix(protocolErrors[i].ID, suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type requestError struct {
+	code int
+	body []byte
+}
+
+func (e requestError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.code, http.StatusText(e.code), string(e.body))
+}
+
+// requestJSON - sends POST request with JSON `request` or GET request if `request` is nil and decodes JSON response
+func (t *Tezos) requestJSON(ctx context.Context, link string, request, response interface{}) error {
+	method := http.MethodGet
+	var body io.Reader
+	if request != nil {
+		data, err := json.Marshal(request)
+		if err != nil {
+			return err
+		}
+		method = http.MethodPost
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, link, body)
+	if err != nil {
+		return err
+	}
+	if request != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return requestError{code: resp.StatusCode, body: data}
+	}
+
+	return json.NewDecoder(resp.Body).Decode(response)
+}
+
+// estimate - simulates batch and returns transactions with gas limit, storage limit and fee which are enough for the batch inclusion
+func (t *Tezos) estimate(ctx context.Context, branch, chainID string, transactions []node.Transaction) ([]node.Transaction, error) {
+	gasLimit := t.hardGasLimitPerOperation
+	if perBlock := t.hardGasLimitPerBlock / int64(len(transactions)); perBlock < gasLimit {
+		gasLimit = perBlock
+	}
+
+	simulated := make([]node.Transaction, len(transactions))
+	for i := range transactions {
+		simulated[i] = transactions[i]
+		simulated[i].Fee = "0"
+		simulated[i].GasLimit = strconv.FormatInt(gasLimit, 10)
+		simulated[i].StorageLimit = strconv.FormatInt(t.hardStorageLimitPerOperation, 10)
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	contents, err := t.runOperation(runCtx, branch, chainID, simulated)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range contents {
+		gas, storage, err := contents[i].limits()
+		if err != nil {
+			return nil, errors.Wrapf(err, "content %d", i)
+		}
+
+		gas += gasSafetyMargin
+		if gas > t.hardGasLimitPerOperation {
+			gas = t.hardGasLimitPerOperation
+		}
+		if storage > 0 {
+			storage += storageSafetyMargin
+			if storage > t.hardStorageLimitPerOperation {
+				storage = t.hardStorageLimitPerOperation
+			}
+		}
+
+		simulated[i].GasLimit = strconv.FormatInt(gas, 10)
+		simulated[i].StorageLimit = strconv.FormatInt(storage, 10)
+
+		fee, err := minimalFee(simulated[i], i == 0)
+		if err != nil {
+			return nil, err
+		}
+		simulated[i].Fee = strconv.FormatInt(fee, 10)
+	}
+
+	return simulated, nil
+}
+
+// minimalFee - computes minimal fee of the transaction by its forged size and gas limit. The first transaction of batch pays for branch and signature too.
+func minimalFee(tx node.Transaction, first bool) (int64, error) {
+	gasLimit, err := strconv.ParseInt(tx.GasLimit, 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "gas_limit")
+	}
+
+	var fee int64
+	tx.Fee = "0"
+	// fee is a part of forged transaction, so the size depends on fee
+	for {
+		forged, err := forge.Transaction(tx)
+		if err != nil {
+			return 0, err
+		}
+
+		size := int64(len(forged))
+		if first {
+			size += branchSize + signatureSize
+		}
+
+		required := ceilDiv(size*minimalNanotezPerByte+gasLimit*minimalNanotezPerGasUnit, 1000)
+		if first {
+			required += minimalFees
+		}
+
+		if required <= fee {
+			return fee, nil
+		}
+		fee = required
+		tx.Fee = strconv.FormatInt(fee, 10)
+	}
+}
+
+// exceedsCap - checks estimated value against optional cap from tezos.yml
+func exceedsCap(value, limit string) (bool, error) {
+	if limit == "" {
+		return false, nil
+	}
+	capValue, err := strconv.ParseInt(limit, 10, 64)
+	if err != nil {
+		return false, err
+	}
+	estimated, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return false, err
+	}
+	return estimated > capValue, nil
+}
+
+func ceilDiv(x, y int64) int64 {
+	return (x + y - 1) / y
+}
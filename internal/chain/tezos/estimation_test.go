@@ -0,0 +1,107 @@
+package tezos
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunOperationContent_limits(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        string
+		wantGas     int64
+		wantStorage int64
+		wantErr     bool
+	}{
+		{
+			name:        "milligas with internal operation",
+			data:        `{"kind":"transaction","metadata":{"operation_result":{"status":"applied","consumed_milligas":"2500100","paid_storage_size_diff":"67"},"internal_operation_results":[{"result":{"status":"applied","consumed_milligas":"1000000","allocated_destination_contract":true}}]}}`,
+			wantGas:     3501,
+			wantStorage: 67 + originationSize,
+		}, {
+			name:    "gas",
+			data:    `{"kind":"transaction","metadata":{"operation_result":{"status":"applied","consumed_gas":"1520"}}}`,
+			wantGas: 1520,
+		}, {
+			name:    "failed",
+			data:    `{"kind":"transaction","metadata":{"operation_result":{"status":"failed","errors":[{"id":"proto.script_rejected"}]}}}`,
+			wantErr: true,
+		}, {
+			name:    "backtracked internal",
+			data:    `{"kind":"transaction","metadata":{"operation_result":{"status":"applied","consumed_gas":"1520"},"internal_operation_results":[{"result":{"status":"backtracked"}}]}}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var content runOperationContent
+			require.NoError(t, json.Unmarshal([]byte(tt.data), &content))
+
+			gas, storage, err := content.limits()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantGas, gas)
+			assert.Equal(t, tt.wantStorage, storage)
+		})
+	}
+}
+
+func TestIsScriptError(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{
+			name: "script rejected",
+			body: `[{"kind":"temporary","id":"proto.013-PtJakart.michelson_v1.runtime_error","contract_handle":"KT1"},{"kind":"temporary","id":"proto.013-PtJakart.michelson_v1.script_rejected"}]`,
+			want: true,
+		}, {
+			name: "counter in the past",
+			body: `[{"kind":"temporary","id":"proto.013-PtJakart.contract.counter_in_the_past","contract":"tz1","expected":"11","found":"10"}]`,
+		}, {
+			name: "balance too low",
+			body: `[{"kind":"temporary","id":"proto.013-PtJakart.contract.balance_too_low"}]`,
+		}, {
+			name: "not json",
+			body: `Internal Server Error`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isScriptError([]byte(tt.body)))
+		})
+	}
+}
+
+func TestMinimalFee(t *testing.T) {
+	tx := node.Transaction{
+		Source:       "tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK6",
+		Counter:      "1000",
+		GasLimit:     "3600",
+		StorageLimit: "0",
+		Amount:       "0",
+		Destination:  "KT1VG2WtYdSWz5E7chTeAdDPZNy2MpP8pTfL",
+	}
+
+	first, err := minimalFee(tx, true)
+	require.NoError(t, err)
+	next, err := minimalFee(tx, false)
+	require.NoError(t, err)
+
+	assert.InDelta(t, minimalFees+(branchSize+signatureSize)*minimalNanotezPerByte/1000, first-next, 1, "first transaction pays for branch and signature")
+
+	tx.Fee = strconv.FormatInt(next, 10)
+	required, err := minimalFee(tx, false)
+	require.NoError(t, err)
+	assert.Equal(t, next, required, "fee is stable after it's set")
+	assert.GreaterOrEqual(t, next, ceilDiv(3600*minimalNanotezPerGasUnit, 1000))
+}
@@ -0,0 +1,184 @@
+package tezos
+
+import (
+	"context"
+	"time"
+
+	"github.com/atomex-protocol/watch_tower/internal/chain"
+	"github.com/shopspring/decimal"
+)
+
+// default max operations TTL of Tezos protocol. It's used if node's block metadata can't be received.
+const defaultMaxOperationsTTL = 120
+
+// injectedOperation - operation group which was injected by watch tower and is waiting for inclusion. Its contents are mapped by counters.
+// `counter` is the account counter which the group was injected at.
+type injectedOperation struct {
+	hash        string
+	branchLevel uint64
+	counter     uint64
+	contents    map[uint64]queuedTransaction
+}
+
+func (t *Tezos) trackOperation(hash string, branchLevel, counter uint64, contents map[uint64]queuedTransaction) {
+	t.injectedMutex.Lock()
+	t.injected[hash] = injectedOperation{
+		hash:        hash,
+		branchLevel: branchLevel,
+		counter:     counter,
+		contents:    contents,
+	}
+	t.injectedMutex.Unlock()
+}
+
+// releaseCounter - sending is blocked while account counter is equal to counter of the last injected operation. Expired operation doesn't change the counter,
+// so it's released when the operation is finished. Counter of newer operation isn't released.
+func (t *Tezos) releaseCounter(operation injectedOperation) {
+	t.transactionsMutex.Lock()
+	if t.lastCounter == operation.counter {
+		t.lastCounter = 0
+	}
+	t.transactionsMutex.Unlock()
+}
+
+func (t *Tezos) isTracked(hash string) bool {
+	t.injectedMutex.RLock()
+	_, ok := t.injected[hash]
+	t.injectedMutex.RUnlock()
+	return ok
+}
+
+// contractOperation - handles operation status which is received from contract subscription. Statuses of injected operations are sent by `checkOperations` after confirmations.
+func (t *Tezos) contractOperation(hash, status string) {
+	if t.isTracked(hash) {
+		return
+	}
+	t.operations <- chain.Operation{
+		Hash:      hash,
+		ChainType: chain.ChainTypeTezos,
+		Status:    toOperationStatus(status),
+	}
+}
+
+func (t *Tezos) listenOperations(ctx context.Context) {
+	defer t.wg.Done()
+
+	ticker := time.NewTicker(time.Second * 15)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := t.checkOperations(ctx); err != nil {
+				t.log.Err(err).Msg("checkOperations")
+			}
+		}
+	}
+}
+
+// checkOperations - receives statuses of injected operations. Operation is applied when it has enough confirmations and it's failed if one of its contents is failed, backtracked or skipped or if its branch is expired.
+// Failed contents aren't sent again by the chain: consumers of failed operations decide whether to retry.
+func (t *Tezos) checkOperations(ctx context.Context) error {
+	t.injectedMutex.RLock()
+	injected := make([]injectedOperation, 0, len(t.injected))
+	for _, operation := range t.injected {
+		injected = append(injected, operation)
+	}
+	t.injectedMutex.RUnlock()
+
+	if len(injected) == 0 {
+		return nil
+	}
+
+	headCtx, headCancel := context.WithTimeout(ctx, 10*time.Second)
+	defer headCancel()
+
+	head, err := t.api.GetHead(headCtx)
+	if err != nil {
+		return err
+	}
+
+	for _, operation := range injected {
+		statuses, finished, err := t.contentStatuses(ctx, operation, head.Level)
+		if err != nil {
+			return err
+		}
+		if !finished {
+			continue
+		}
+
+		t.injectedMutex.Lock()
+		delete(t.injected, operation.hash)
+		t.injectedMutex.Unlock()
+		t.releaseCounter(operation)
+
+		for counter, content := range operation.contents {
+			result, ok := statuses[counter]
+			if !ok {
+				result.status = "failed"
+			}
+			status := result.status
+			t.log.Info().Str("hash", operation.hash).Str("hashed_secret", content.hashedSecret.String()).Str("status", status).Msg("operation is finished")
+
+			t.operations <- chain.Operation{
+				Status:       toOperationStatus(status),
+				Hash:         operation.hash,
+				ChainType:    chain.ChainTypeTezos,
+				HashedSecret: content.hashedSecret,
+				Fee:          decimal.NewFromInt(int64(result.fee)),
+			}
+		}
+	}
+	return nil
+}
+
+// contentStatus - status of operation content and fees paid by it in mutez
+type contentStatus struct {
+	status string
+	fee    uint64
+}
+
+// contentStatuses - returns statuses of operation contents by counters. Operation is finished when all its contents are applied and have enough confirmations, when it's failed or when its branch is expired.
+func (t *Tezos) contentStatuses(ctx context.Context, operation injectedOperation, head uint64) (map[uint64]contentStatus, bool, error) {
+	requestCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	transactions, err := t.api.GetTransactionsByHash(requestCtx, operation.hash, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	statuses := make(map[uint64]contentStatus)
+	if len(transactions) == 0 {
+		if head > operation.branchLevel+t.maxOperationsTTL {
+			t.log.Warn().Str("hash", operation.hash).Msg("operation is expired")
+			for counter := range operation.contents {
+				statuses[counter] = contentStatus{status: "failed"}
+			}
+			return statuses, true, nil
+		}
+		return nil, false, nil
+	}
+
+	applied := true
+	for i := range transactions {
+		// internal operations have the same counter as their content
+		if transactions[i].Nonce != nil {
+			continue
+		}
+		statuses[transactions[i].Counter] = contentStatus{
+			status: transactions[i].Status,
+			fee:    transactions[i].BakerFee + transactions[i].StorageFee + transactions[i].AllocationFee,
+		}
+		if transactions[i].Status != "applied" {
+			applied = false
+		}
+	}
+
+	if applied && head < transactions[0].Level+t.cfg.Confirmations {
+		return nil, false, nil
+	}
+	return statuses, true, nil
+}
@@ -0,0 +1,182 @@
+package tezos
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/atomex-protocol/watch_tower/internal/chain"
+	"github.com/dipdup-net/go-lib/tzkt/api"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testOperationHash = "opDqhqYmqgmXTxcEcDXbJMWBThZkaQCovwV8BC3gwthEWYdPCWD"
+
+// newTzKTServer - emulates TzKT: head is at `head` level and transactions of `testOperationHash` are `transactions`
+func newTzKTServer(head, transactions string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/head":
+			_, _ = w.Write([]byte(`{"level":` + head + `}`))
+		case "/v1/operations/transactions/" + testOperationHash:
+			_, _ = w.Write([]byte(transactions))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func newTestTezos(server *httptest.Server) *Tezos {
+	return &Tezos{
+		cfg:              Config{Confirmations: 2},
+		api:              api.New(server.URL),
+		log:              zerolog.Nop(),
+		transactions:     make(map[chain.Hex]queuedTransaction),
+		injected:         make(map[string]injectedOperation),
+		operations:       make(chan chain.Operation, 16),
+		maxOperationsTTL: 120,
+	}
+}
+
+func TestTezos_contentStatuses(t *testing.T) {
+	tests := []struct {
+		name         string
+		head         uint64
+		transactions string
+		want         map[uint64]contentStatus
+		wantFinished bool
+	}{
+		{
+			name:         "operation isn't included yet",
+			head:         150,
+			transactions: `[]`,
+		}, {
+			name:         "operation is expired",
+			head:         300,
+			transactions: `[]`,
+			want: map[uint64]contentStatus{
+				101: {status: "failed"},
+				102: {status: "failed"},
+			},
+			wantFinished: true,
+		}, {
+			name:         "applied operation hasn't enough confirmations",
+			head:         151,
+			transactions: `[{"level":150,"counter":101,"status":"applied"},{"level":150,"counter":102,"status":"applied"}]`,
+		}, {
+			name:         "applied operation is confirmed",
+			head:         152,
+			transactions: `[{"level":150,"counter":101,"status":"applied","bakerFee":10,"storageFee":5,"allocationFee":1},{"level":150,"counter":101,"nonce":1,"status":"applied"},{"level":150,"counter":102,"status":"applied"}]`,
+			want: map[uint64]contentStatus{
+				101: {status: "applied", fee: 16},
+				102: {status: "applied"},
+			},
+			wantFinished: true,
+		}, {
+			name:         "failed operation is finished without confirmations",
+			head:         150,
+			transactions: `[{"level":150,"counter":101,"status":"backtracked","bakerFee":10},{"level":150,"counter":102,"status":"failed","bakerFee":20}]`,
+			want: map[uint64]contentStatus{
+				101: {status: "backtracked", fee: 10},
+				102: {status: "failed", fee: 20},
+			},
+			wantFinished: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTzKTServer("0", tt.transactions)
+			defer server.Close()
+
+			operation := injectedOperation{
+				hash:        testOperationHash,
+				branchLevel: 100,
+				counter:     100,
+				contents: map[uint64]queuedTransaction{
+					101: {hashedSecret: "01"},
+					102: {hashedSecret: "02"},
+				},
+			}
+
+			statuses, finished, err := newTestTezos(server).contentStatuses(context.Background(), operation, tt.head)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantFinished, finished)
+			assert.Equal(t, tt.want, statuses)
+		})
+	}
+}
+
+func TestTezos_checkOperations(t *testing.T) {
+	tests := []struct {
+		name          string
+		head          string
+		transactions  string
+		lastCounter   uint64
+		wantCounter   uint64
+		wantStatuses  []chain.OperationStatus
+		wantQueued    []chain.Hex
+		wantsInjected bool
+	}{
+		{
+			name:          "pending operation blocks its counter",
+			head:          "150",
+			transactions:  `[]`,
+			lastCounter:   100,
+			wantCounter:   100,
+			wantsInjected: true,
+		}, {
+			name:         "expired operation releases its counter",
+			head:         "300",
+			transactions: `[]`,
+			lastCounter:  100,
+			wantCounter:  0,
+			wantStatuses: []chain.OperationStatus{chain.Failed, chain.Failed},
+		}, {
+			name:         "counter of newer operation isn't released",
+			head:         "300",
+			transactions: `[]`,
+			lastCounter:  105,
+			wantCounter:  105,
+			wantStatuses: []chain.OperationStatus{chain.Failed, chain.Failed},
+		}, {
+			name:         "backtracked content is failed and isn't queued again",
+			head:         "150",
+			transactions: `[{"level":150,"counter":101,"status":"backtracked"},{"level":150,"counter":102,"status":"failed"}]`,
+			lastCounter:  100,
+			wantCounter:  0,
+			wantStatuses: []chain.OperationStatus{chain.Failed, chain.Failed},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTzKTServer(tt.head, tt.transactions)
+			defer server.Close()
+
+			tz := newTestTezos(server)
+			tz.lastCounter = tt.lastCounter
+			tz.trackOperation(testOperationHash, 100, 100, map[uint64]queuedTransaction{
+				101: {hashedSecret: "01"},
+				102: {hashedSecret: "02"},
+			})
+
+			require.NoError(t, tz.checkOperations(context.Background()))
+			assert.Equal(t, tt.wantCounter, tz.lastCounter)
+			assert.Equal(t, tt.wantsInjected, tz.isTracked(testOperationHash))
+
+			require.Len(t, tz.operations, len(tt.wantStatuses))
+			for i := range tt.wantStatuses {
+				operation := <-tz.operations
+				assert.Equal(t, tt.wantStatuses[i], operation.Status)
+				assert.Equal(t, testOperationHash, operation.Hash)
+			}
+
+			require.Len(t, tz.transactions, len(tt.wantQueued))
+			for _, hashedSecret := range tt.wantQueued {
+				assert.Contains(t, tz.transactions, hashedSecret)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package tezos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// token standards
+const (
+	TokenStandardFA12 = "fa1.2"
+	TokenStandardFA2  = "fa2"
+)
+
+// tokenAllowance - amount of tokens which `spender` has to be allowed to transfer from watch tower's address
+type tokenAllowance struct {
+	token   string
+	tokenID uint64
+	spender string
+	amount  *big.Int
+}
+
+type allowanceKey struct {
+	token   string
+	tokenID uint64
+	spender string
+}
+
+// withApprovals - returns copy of the batch where approvals are prepended to the first transaction of each token. Allowance must cover all token transactions of the batch.
+func (t *Tezos) withApprovals(ctx context.Context, chainID string, batch []queuedTransaction) ([]queuedTransaction, error) {
+	result := make([]queuedTransaction, len(batch))
+	required := make(map[allowanceKey]*big.Int)
+	first := make(map[allowanceKey]int)
+	for i := range batch {
+		result[i] = batch[i]
+		result[i].approvals = nil
+
+		if batch[i].allowance == nil {
+			continue
+		}
+		key := allowanceKey{
+			token:   batch[i].allowance.token,
+			tokenID: batch[i].allowance.tokenID,
+			spender: batch[i].allowance.spender,
+		}
+		if amount, ok := required[key]; ok {
+			amount.Add(amount, batch[i].allowance.amount)
+		} else {
+			required[key] = new(big.Int).Set(batch[i].allowance.amount)
+			first[key] = i
+		}
+	}
+
+	for key, amount := range required {
+		approvals, err := t.approvals(ctx, chainID, key, amount)
+		if err != nil {
+			return nil, errors.Wrap(err, key.token)
+		}
+		result[first[key]].approvals = approvals
+	}
+	return result, nil
+}
+
+// tokenStandard - detects token standard by contract entrypoints
+func (t *Tezos) tokenStandard(ctx context.Context, token string) (string, error) {
+	t.tokenStandardsMutex.Lock()
+	defer t.tokenStandardsMutex.Unlock()
+
+	if standard, ok := t.tokenStandards[token]; ok {
+		return standard, nil
+	}
+
+	requestCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	entrypoints, err := t.rpc.ContractEntrypoints(requestCtx, "head", token)
+	if err != nil {
+		return "", err
+	}
+
+	var standard string
+	if _, ok := entrypoints.Entrypoints["update_operators"]; ok {
+		standard = TokenStandardFA2
+	} else if _, ok := entrypoints.Entrypoints["approve"]; ok {
+		standard = TokenStandardFA12
+	} else {
+		return "", errors.Errorf("unknown token standard of %s", token)
+	}
+
+	t.tokenStandards[token] = standard
+	return standard, nil
+}
+
+// approvals - returns transactions which have to be sent before initiation, so `spender` can transfer `amount` of tokens of watch tower's address
+func (t *Tezos) approvals(ctx context.Context, chainID string, key allowanceKey, amount *big.Int) ([]node.Transaction, error) {
+	token, tokenID, spender := key.token, key.tokenID, key.spender
+	standard, err := t.tokenStandard(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	owner := t.key.PubKey.GetAddress()
+
+	switch standard {
+	case TokenStandardFA12:
+		allowance, err := t.allowance(ctx, chainID, token, owner, spender)
+		if err != nil {
+			return nil, errors.Wrap(err, "allowance")
+		}
+		if allowance.Cmp(amount) >= 0 {
+			return nil, nil
+		}
+
+		t.log.Info().Str("token", token).Str("allowance", allowance.String()).Str("amount", amount.String()).Msg("approving tokens...")
+
+		approvals := make([]node.Transaction, 0, 2)
+		if allowance.Sign() > 0 {
+			// FA1.2 forbids change of non-zero allowance to another non-zero value
+			approvals = append(approvals, t.tokenTransaction(token, "approve", fa12Approve(spender, big.NewInt(0))))
+		}
+		return append(approvals, t.tokenTransaction(token, "approve", fa12Approve(spender, amount))), nil
+
+	case TokenStandardFA2:
+		isOperator, err := t.isOperator(ctx, token, tokenID, owner, spender)
+		if err != nil {
+			return nil, errors.Wrap(err, "isOperator")
+		}
+		if isOperator {
+			return nil, nil
+		}
+
+		t.log.Info().Str("token", token).Uint64("token_id", tokenID).Msg("adding operator...")
+		return []node.Transaction{
+			t.tokenTransaction(token, "update_operators", fa2AddOperator(owner, spender, tokenID)),
+		}, nil
+
+	default:
+		return nil, errors.Errorf("unknown token standard: %s", standard)
+	}
+}
+
+func (t *Tezos) tokenTransaction(token, entrypoint string, value json.RawMessage) node.Transaction {
+	return node.Transaction{
+		Source:      t.key.PubKey.GetAddress(),
+		Destination: token,
+		Amount:      "0",
+		Parameters: &node.Parameters{
+			Entrypoint: entrypoint,
+			Value:      &value,
+		},
+	}
+}
+
+type runViewRequest struct {
+	Contract      string          `json:"contract"`
+	Entrypoint    string          `json:"entrypoint"`
+	Input         json.RawMessage `json:"input"`
+	ChainID       string          `json:"chain_id"`
+	UnparsingMode string          `json:"unparsing_mode"`
+}
+
+type runViewResponse struct {
+	Data struct {
+		Int string `json:"int"`
+	} `json:"data"`
+}
+
+// allowance - receives allowance of FA1.2 token by `getAllowance` view
+func (t *Tezos) allowance(ctx context.Context, chainID, token, owner, spender string) (*big.Int, error) {
+	requestCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var response runViewResponse
+	link := fmt.Sprintf("%s/chains/main/blocks/head/helpers/scripts/run_view", strings.TrimSuffix(t.cfg.Node, "/"))
+	if err := t.requestJSON(requestCtx, link, runViewRequest{
+		Contract:      token,
+		Entrypoint:    "getAllowance",
+		Input:         pair(michelineString(owner), michelineString(spender)),
+		ChainID:       chainID,
+		UnparsingMode: "Readable",
+	}, &response); err != nil {
+		return nil, err
+	}
+
+	allowance, ok := new(big.Int).SetString(response.Data.Int, 10)
+	if !ok {
+		return nil, errors.Errorf("invalid allowance: %s", response.Data.Int)
+	}
+	return allowance, nil
+}
+
+// isOperator - checks FA2 `operators` big map of token via TzKT
+func (t *Tezos) isOperator(ctx context.Context, token string, tokenID uint64, owner, operator string) (bool, error) {
+	bigMapsCtx, bigMapsCancel := context.WithTimeout(ctx, 10*time.Second)
+	defer bigMapsCancel()
+
+	bigMaps, err := t.api.GetBigmaps(bigMapsCtx, map[string]string{
+		"contract": token,
+		"path.as":  "*operators",
+	})
+	if err != nil {
+		return false, err
+	}
+	if len(bigMaps) == 0 {
+		return false, errors.Errorf("can't find operators big map of %s", token)
+	}
+
+	keysCtx, keysCancel := context.WithTimeout(ctx, 10*time.Second)
+	defer keysCancel()
+
+	var keys []json.RawMessage
+	link := fmt.Sprintf("%s/v1/bigmaps/%d/keys?active=true&limit=1&key.owner=%s&key.operator=%s&key.token_id=%d", strings.TrimSuffix(t.cfg.TzKT, "/"), bigMaps[0].Ptr, owner, operator, tokenID)
+	if err := t.requestJSON(keysCtx, link, nil, &keys); err != nil {
+		return false, err
+	}
+	return len(keys) > 0, nil
+}
+
+// tokenBalance - receives balance of watch tower's address from TzKT token balances
+func (t *Tezos) tokenBalance(ctx context.Context, token string, tokenID uint64) (decimal.Decimal, error) {
+	var balances []string
+	link := fmt.Sprintf("%s/v1/tokens/balances?account=%s&token.contract=%s&token.tokenId=%d&select=balance", strings.TrimSuffix(t.cfg.TzKT, "/"), t.key.PubKey.GetAddress(), token, tokenID)
+	if err := t.requestJSON(ctx, link, nil, &balances); err != nil {
+		return decimal.Zero, err
+	}
+	if len(balances) == 0 {
+		return decimal.Zero, nil
+	}
+	return decimal.NewFromString(balances[0])
+}
+
+func michelineString(value string) json.RawMessage {
+	data, _ := json.Marshal(map[string]string{"string": value})
+	return data
+}
+
+func michelineInt(value *big.Int) json.RawMessage {
+	data, _ := json.Marshal(map[string]string{"int": value.String()})
+	return data
+}
+
+func pair(left, right json.RawMessage) json.RawMessage {
+	data, _ := json.Marshal(map[string]interface{}{
+		"prim": "Pair",
+		"args": []json.RawMessage{left, right},
+	})
+	return data
+}
+
+// fa12Approve - parameter of FA1.2 `approve`: (pair (address :spender) (nat :value))
+func fa12Approve(spender string, amount *big.Int) json.RawMessage {
+	return pair(michelineString(spender), michelineInt(amount))
+}
+
+// fa2AddOperator - parameter of FA2 `update_operators` with single `add_operator`: (list (or (pair owner (pair operator token_id)) ...))
+func fa2AddOperator(owner, operator string, tokenID uint64) json.RawMessage {
+	data, _ := json.Marshal([]map[string]interface{}{
+		{
+			"prim": "Left",
+			"args": []json.RawMessage{
+				pair(michelineString(owner), pair(michelineString(operator), michelineInt(new(big.Int).SetUint64(tokenID)))),
+			},
+		},
+	})
+	return data
+}
@@ -0,0 +1,115 @@
+package tezos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/atomex-protocol/watch_tower/internal/chain"
+	"github.com/dipdup-net/go-lib/node"
+	"github.com/goat-systems/go-tezos/v4/keys"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTezos_withApprovals(t *testing.T) {
+	const (
+		token   = "KT1DM4k79uSx5diQnwqDiF4XeA86aCBxBD35"
+		spender = "KT1Jj1jzDQbDRHt4u7M73DUrBDV1napRbNFr"
+	)
+
+	tests := []struct {
+		name      string
+		allowance string
+		amounts   []string
+	}{
+		{
+			name:      "allowance is enough",
+			allowance: "30",
+		}, {
+			name:      "zero allowance",
+			allowance: "0",
+			amounts:   []string{"30"},
+		}, {
+			name:      "non-zero allowance is reset before approve",
+			allowance: "29",
+			amounts:   []string{"0", "30"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/chains/main/blocks/head/context/contracts/" + token + "/entrypoints":
+					_, _ = w.Write([]byte(`{"entrypoints":{"approve":{},"transfer":{},"getAllowance":{}}}`))
+				case "/chains/main/blocks/head/helpers/scripts/run_view":
+					var request runViewRequest
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+					assert.Equal(t, "getAllowance", request.Entrypoint)
+					assert.Equal(t, token, request.Contract)
+					_, _ = w.Write([]byte(`{"data":{"int":"` + tt.allowance + `"}}`))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer server.Close()
+
+			key, err := keys.Generate(keys.Ed25519)
+			require.NoError(t, err)
+
+			tz := &Tezos{
+				cfg:            Config{Node: server.URL},
+				rpc:            node.NewMainRPC(server.URL),
+				client:         server.Client(),
+				key:            key,
+				log:            zerolog.Nop(),
+				tokenStandards: make(map[string]string),
+			}
+
+			batch := []queuedTransaction{
+				{
+					hashedSecret: "00",
+					transaction:  node.Transaction{Destination: spender},
+					allowance:    &tokenAllowance{token: token, spender: spender, amount: big.NewInt(10)},
+				}, {
+					hashedSecret: "01",
+					transaction:  node.Transaction{Destination: "KT1SJMtHZFSPva5AzQEx5btBuQ8BjvXqort3"},
+				}, {
+					hashedSecret: "02",
+					transaction:  node.Transaction{Destination: spender},
+					allowance:    &tokenAllowance{token: token, spender: spender, amount: big.NewInt(20)},
+				},
+			}
+
+			prepared, err := tz.withApprovals(context.Background(), "NetXdQprcVkpaWU", batch)
+			require.NoError(t, err)
+			require.Len(t, prepared, len(batch))
+			assert.Empty(t, prepared[1].approvals)
+			assert.Empty(t, prepared[2].approvals)
+
+			require.Len(t, prepared[0].approvals, len(tt.amounts))
+			for i := range tt.amounts {
+				approval := prepared[0].approvals[i]
+				assert.Equal(t, token, approval.Destination)
+				assert.Equal(t, "approve", approval.Parameters.Entrypoint)
+				assert.JSONEq(t, `{"prim":"Pair","args":[{"string":"`+spender+`"},{"int":"`+tt.amounts[i]+`"}]}`, string(*approval.Parameters.Value))
+			}
+
+			transactions := withCounters(prepared, 100)
+			require.Len(t, transactions, len(batch)+len(tt.amounts))
+			assert.Equal(t, spender, transactions[len(tt.amounts)].Destination)
+			assert.Equal(t, fmt.Sprintf("%d", 100+len(transactions)), transactions[len(transactions)-1].Counter)
+			assert.Equal(t, chain.Hex("00"), prepared[0].hashedSecret)
+		})
+	}
+}
+
+func TestFa2AddOperator(t *testing.T) {
+	value := fa2AddOperator("tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK6", "KT1Jj1jzDQbDRHt4u7M73DUrBDV1napRbNFr", 3)
+	assert.JSONEq(t, `[{"prim":"Left","args":[{"prim":"Pair","args":[{"string":"tz1aSkwEot3L2kmUvcoxzjMomb9mvBNuzFK6"},{"prim":"Pair","args":[{"string":"KT1Jj1jzDQbDRHt4u7M73DUrBDV1napRbNFr"},{"int":"3"}]}]}]}]`, string(value))
+}
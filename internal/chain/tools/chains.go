@@ -0,0 +1,109 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/atomex-protocol/watch_tower/internal/chain"
+	"github.com/atomex-protocol/watch_tower/internal/chain/bitcoin"
+	"github.com/atomex-protocol/watch_tower/internal/chain/ethereum"
+	"github.com/atomex-protocol/watch_tower/internal/chain/tezos"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// NewChains - creates chains which are set in config
+func NewChains(cfg Config) (map[chain.ChainType]chain.Chain, error) {
+	chains := make(map[chain.ChainType]chain.Chain)
+
+	if cfg.Tezos != nil {
+		tezosChain, err := tezos.New(tezos.Config{
+			Node:            cfg.Tezos.Node,
+			TzKT:            cfg.Tezos.TzKT,
+			Contract:        cfg.Tezos.Contract,
+			Tokens:          cfg.Tezos.Tokens,
+			MinPayOff:       cfg.Tezos.MinPayOff,
+			TTL:             cfg.Tezos.TTL,
+			Confirmations:   cfg.Tezos.Confirmations,
+			BatchSize:       cfg.Tezos.BatchSize,
+			OperaitonParams: cfg.Tezos.OperaitonParams,
+			LogLevel:        zerolog.InfoLevel,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "tezos.New")
+		}
+		chains[chain.ChainTypeTezos] = tezosChain
+	}
+
+	if cfg.Ethereum != nil {
+		eth, err := newEVM(chain.ChainTypeEthereum.String(), *cfg.Ethereum)
+		if err != nil {
+			return nil, err
+		}
+		chains[chain.ChainTypeEthereum] = eth
+	}
+
+	for name, network := range cfg.EVM {
+		chainType := chain.RegisterChainType(name)
+		if _, ok := chains[chainType]; ok {
+			return nil, errors.Errorf("duplicate chain: %s", name)
+		}
+		evm, err := newEVM(name, *network)
+		if err != nil {
+			return nil, err
+		}
+		chains[chainType] = evm
+	}
+
+	if cfg.Bitcoin != nil {
+		btc, err := bitcoin.New(bitcoin.Config{
+			Node:          cfg.Bitcoin.Node,
+			User:          cfg.Bitcoin.User,
+			Password:      cfg.Bitcoin.Password,
+			Network:       cfg.Bitcoin.Network,
+			FeeRate:       cfg.Bitcoin.FeeRate,
+			StartLevel:    cfg.Bitcoin.StartLevel,
+			PollInterval:  time.Duration(cfg.Bitcoin.PollInterval) * time.Second,
+			Confirmations: cfg.Bitcoin.Confirmations,
+			LogLevel:      zerolog.InfoLevel,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "bitcoin.New")
+		}
+		chains[chain.ChainTypeBitcoin] = btc
+	}
+
+	if len(chains) == 0 {
+		return nil, errors.New("there are no chains in config")
+	}
+
+	return chains, nil
+}
+
+func newEVM(name string, cfg Ethereum) (*ethereum.Ethereum, error) {
+	secret := cfg.Secret
+	if secret == "" {
+		secret = fmt.Sprintf("%s_PRIVATE", strings.ToUpper(name))
+	}
+	evm, err := ethereum.New(ethereum.Config{
+		Name:           name,
+		ChainType:      chain.RegisterChainType(name),
+		ChainID:        cfg.ChainID,
+		Confirmations:  cfg.Confirmations,
+		SecretName:     secret,
+		EthContract:    cfg.EthAddress,
+		Erc20Contracts: cfg.Erc20Addresses,
+		NodeURL:        cfg.Node,
+		WssURL:         cfg.Wss,
+		MinPayOff:      cfg.MinPayOff,
+		FeePolicy:      cfg.FeePolicy,
+		ApprovePolicy:  cfg.ApprovePolicy,
+		ReplaceTimeout: time.Duration(cfg.ReplaceTimeout) * time.Second,
+		LogLevel:       zerolog.InfoLevel,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s: ethereum.New", name)
+	}
+	return evm, nil
+}
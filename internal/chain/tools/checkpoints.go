@@ -0,0 +1,9 @@
+package tools
+
+import "github.com/atomex-protocol/watch_tower/internal/chain"
+
+// Checkpoints - storage of the last fully processed level of each chain
+type Checkpoints interface {
+	Checkpoint(chainType chain.ChainType) (uint64, error)
+	SaveCheckpoint(chainType chain.ChainType, level uint64) error
+}
@@ -1,28 +1,28 @@
 package tools
 
 import (
-	"errors"
-
+	"github.com/atomex-protocol/watch_tower/internal/chain/ethereum"
 	"github.com/atomex-protocol/watch_tower/internal/chain/tezos"
 	"github.com/atomex-protocol/watch_tower/internal/types"
+	"github.com/pkg/errors"
 )
 
-const (
-	chainsCount = 2
-)
-
-// Config -
+// Config - settings of chains. Chain is disabled if its section is absent.
 type Config struct {
-	Tezos    Tezos    `yaml:"tezos" validate:"required"`
-	Ethereum Ethereum `yaml:"ethereum" validate:"required"`
+	Tezos    *Tezos               `yaml:"tezos" validate:"required_without_all=Ethereum EVM Bitcoin"`
+	Ethereum *Ethereum            `yaml:"ethereum" validate:"required_without_all=Tezos EVM Bitcoin"`
+	EVM      map[string]*Ethereum `yaml:"evm" validate:"required_without_all=Tezos Ethereum Bitcoin,omitempty,dive"`
+	Bitcoin  *Bitcoin             `yaml:"bitcoin" validate:"required_without_all=Tezos Ethereum EVM"`
 }
 
 // Tezos -
 type Tezos struct {
-	MinPayOff string `yaml:"min_payoff" validate:"numeric"`
-	Node      string `yaml:"node" validate:"required,uri"`
-	TzKT      string `yaml:"tzkt" validate:"required,uri"`
-	TTL       int64  `yaml:"ttl" validate:"gt=0"`
+	MinPayOff     string `yaml:"min_payoff" validate:"numeric"`
+	Node          string `yaml:"node" validate:"required,uri"`
+	TzKT          string `yaml:"tzkt" validate:"required,uri"`
+	TTL           int64  `yaml:"ttl" validate:"gt=0"`
+	Confirmations uint64 `yaml:"confirmations"`
+	BatchSize     int    `yaml:"batch_size" validate:"omitempty,gt=0"`
 
 	Tokens          []string                         `yaml:"-" validate:"-"`
 	Contract        string                           `yaml:"-" validate:"-"`
@@ -53,32 +53,59 @@ func (t *Tezos) FillContractAddresses(assets map[string]types.Asset) error {
 	return nil
 }
 
-// Ethereum -
+// Ethereum - settings of Ethereum or another EVM network which uses Atomex contracts
 type Ethereum struct {
-	MinPayOff    string `yaml:"min_payoff"`
-	Node         string `yaml:"node" validate:"required,uri"`
-	Wss          string `yaml:"wss" validate:"required,uri"`
-	EthAddress   string `yaml:"-" validate:"-"`
-	Erc20Address string `yaml:"-" validate:"-"`
+	MinPayOff      string   `yaml:"min_payoff"`
+	Node           string   `yaml:"node" validate:"required,uri"`
+	Wss            string   `yaml:"wss" validate:"required,uri"`
+	ChainID        int64    `yaml:"chain_id" validate:"omitempty,gt=0"`
+	Confirmations  uint64   `yaml:"confirmations"`
+	ReplaceTimeout uint64   `yaml:"replace_timeout"`
+	NativeAsset    string   `yaml:"native_asset"`
+	Secret         string   `yaml:"secret"`
+	EthAddress     string   `yaml:"-" validate:"-"`
+	Erc20Addresses []string `yaml:"-" validate:"-"`
+
+	FeePolicy     ethereum.FeePolicy `yaml:"fee_policy"`
+	ApprovePolicy string             `yaml:"approve_policy" validate:"omitempty,oneof=unlimited exact"`
 }
 
-// FillContractAddresses -
-func (e *Ethereum) FillContractAddresses(assets map[string]types.Asset) error {
-	xtz, ok := assets["ETH"]
+// FillContractAddresses - `name` is the chain name which is used in `chain` field of assets.
+func (e *Ethereum) FillContractAddresses(name string, assets map[string]types.Asset) error {
+	if e.NativeAsset == "" {
+		e.NativeAsset = "ETH"
+	}
+	native, ok := assets[e.NativeAsset]
 	if !ok {
-		return errors.New("assets.yml does not contains ETH asset")
+		return errors.Errorf("assets.yml does not contains %s asset", e.NativeAsset)
 	}
-	e.EthAddress = xtz.AtomexContract
+	e.EthAddress = native.AtomexContract
 
-	for name, asset := range assets {
-		if asset.Chain != "ethereum" || name == "ETH" {
+	contracts := make(map[string]struct{})
+	for assetName, asset := range assets {
+		if asset.Chain != name || assetName == e.NativeAsset || asset.AtomexContract == "" {
 			continue
 		}
 
-		if asset.AtomexContract != "" {
-			e.Erc20Address = asset.AtomexContract
-		}
+		contracts[asset.AtomexContract] = struct{}{}
+	}
+
+	e.Erc20Addresses = make([]string, 0)
+	for address := range contracts {
+		e.Erc20Addresses = append(e.Erc20Addresses, address)
 	}
 
 	return nil
 }
+
+// Bitcoin -
+type Bitcoin struct {
+	Node          string `yaml:"node" validate:"required,uri"`
+	User          string `yaml:"user"`
+	Password      string `yaml:"password"`
+	Network       string `yaml:"network" validate:"omitempty,oneof=mainnet testnet regtest"`
+	FeeRate       int64  `yaml:"fee_rate" validate:"gte=0"`
+	StartLevel    uint64 `yaml:"start_level"`
+	PollInterval  int64  `yaml:"poll_interval" validate:"gte=0"`
+	Confirmations uint64 `yaml:"confirmations"`
+}
@@ -64,6 +64,9 @@ func (swap *Swap) fromInitEvent(event chain.InitEvent) {
 		}
 		swap.Status = StatusInitiatedOnce
 	case StatusInitiatedOnce:
+		if swap.Initiator.is(event.Chain, event.ContractAddress) { // event was already received. For example, on restore from checkpoint.
+			return
+		}
 		swap.Acceptor.ChainType = event.Chain
 		swap.Acceptor.Contract = event.ContractAddress
 		swap.Acceptor.Status = StatusInitiated
@@ -78,6 +81,9 @@ func (swap *Swap) fromRedeemEvent(event chain.RedeemEvent) {
 	if swap.Secret == "" {
 		swap.Secret = event.Secret
 	}
+	if swap.isLegFinished(event.Chain, event.ContractAddress) {
+		return
+	}
 
 	switch swap.Status {
 	case StatusEmpty, StatusInitiatedOnce, StatusInitiated:
@@ -98,6 +104,9 @@ func (swap *Swap) fromRefundEvent(event chain.RefundEvent) {
 	if swap.HashedSecret != event.HashedSecret() {
 		return
 	}
+	if swap.isLegFinished(event.Chain, event.ContractAddress) {
+		return
+	}
 	switch swap.Status {
 	case StatusEmpty, StatusInitiatedOnce, StatusInitiated:
 		swap.Status = StatusRefundedOnce
@@ -113,6 +122,16 @@ func (swap *Swap) fromRefundEvent(event chain.RefundEvent) {
 	}
 }
 
+func (swap *Swap) isLegFinished(chainType chain.ChainType, contract string) bool {
+	switch {
+	case swap.Acceptor.is(chainType, contract):
+		return swap.Acceptor.IsFinished()
+	case swap.Initiator.is(chainType, contract):
+		return swap.Initiator.IsFinished()
+	}
+	return false
+}
+
 // Leg -
 type Leg struct {
 	ChainType chain.ChainType
@@ -126,6 +145,10 @@ func (leg Leg) IsFinished() bool {
 	return leg.Status == StatusRedeemed || leg.Status == StatusRefunded
 }
 
+func (leg Leg) is(chainType chain.ChainType, contract string) bool {
+	return leg.ChainType == chainType && leg.Contract == contract
+}
+
 // Merge -
 func (leg *Leg) Merge(another Leg) {
 	if leg.ChainType != another.ChainType {
@@ -18,3 +18,27 @@ func WithRestore() TrackerOption {
 		t.needRestore = true
 	}
 }
+
+// WithCheckpoints - sets storage of the last processed levels. Restore starts from saved level of each chain.
+func WithCheckpoints(checkpoints Checkpoints) TrackerOption {
+	return func(t *Tracker) {
+		t.checkpoints = checkpoints
+	}
+}
+
+// WithSwaps - sets swaps which are known before start. It's needed for restoring from checkpoints: events of these swaps before checkpoint are not received again.
+func WithSwaps(swaps ...Swap) TrackerOption {
+	return func(t *Tracker) {
+		for i := range swaps {
+			swap := swaps[i]
+			t.swaps[swap.HashedSecret] = &swap
+		}
+	}
+}
+
+// WithWatches - sets storage of watched HTLCs. They are registered again on start and deleted when they are redeemed or refunded.
+func WithWatches(watches Watches) TrackerOption {
+	return func(t *Tracker) {
+		t.watches = watches
+	}
+}
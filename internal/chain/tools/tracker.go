@@ -7,24 +7,26 @@ import (
 	"time"
 
 	"github.com/atomex-protocol/watch_tower/internal/chain"
-	"github.com/atomex-protocol/watch_tower/internal/chain/ethereum"
-	"github.com/atomex-protocol/watch_tower/internal/chain/tezos"
 	"github.com/atomex-protocol/watch_tower/internal/logger"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
 )
 
 // tracker -
 type Tracker struct {
-	tezos *tezos.Tezos
-	eth   *ethereum.Ethereum
+	chains map[chain.ChainType]chain.Chain
 
 	logger zerolog.Logger
 
 	restoreCounter int32
 	needRestore    bool
+	checkpoints    Checkpoints
+	levels         map[chain.ChainType]uint64
+	watches        Watches
 
 	swaps         map[chain.Hex]*Swap
+	events        chan chain.Event
 	statusChanged chan Swap
 	operations    chan chain.Operation
 	restored      chan struct{}
@@ -34,39 +36,19 @@ type Tracker struct {
 
 // NewTracker -
 func NewTracker(cfg Config, opts ...TrackerOption) (*Tracker, error) {
-	tezosChain, err := tezos.New(tezos.Config{
-		Node:            cfg.Tezos.Node,
-		TzKT:            cfg.Tezos.TzKT,
-		Contract:        cfg.Tezos.Contract,
-		Tokens:          cfg.Tezos.Tokens,
-		MinPayOff:       cfg.Tezos.MinPayOff,
-		TTL:             cfg.Tezos.TTL,
-		OperaitonParams: cfg.Tezos.OperaitonParams,
-		LogLevel:        zerolog.InfoLevel,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	eth, err := ethereum.New(ethereum.Config{
-		EthContract:   cfg.Ethereum.EthAddress,
-		Erc20Contract: cfg.Ethereum.Erc20Address,
-		NodeURL:       cfg.Ethereum.Node,
-		WssURL:        cfg.Ethereum.Wss,
-		MinPayOff:     cfg.Ethereum.MinPayOff,
-		LogLevel:      zerolog.InfoLevel,
-	})
+	chains, err := NewChains(cfg)
 	if err != nil {
 		return nil, err
 	}
 
 	t := &Tracker{
-		tezos: tezosChain,
-		eth:   eth,
+		chains: chains,
 
 		logger: logger.New(logger.WithModuleName("tracker")),
 
 		swaps:         make(map[chain.Hex]*Swap),
+		levels:        make(map[chain.ChainType]uint64),
+		events:        make(chan chain.Event, 1024),
 		operations:    make(chan chain.Operation, 1024),
 		statusChanged: make(chan Swap, 1024),
 		restored:      make(chan struct{}, 1),
@@ -96,13 +78,13 @@ func (t *Tracker) Restored() <-chan struct{} {
 func (t *Tracker) Close() error {
 	t.wg.Wait()
 
-	if err := t.eth.Close(); err != nil {
-		return err
-	}
-	if err := t.tezos.Close(); err != nil {
-		return err
+	for chainType, c := range t.chains {
+		if err := c.Close(); err != nil {
+			return errors.Wrap(err, chainType.String())
+		}
 	}
 
+	close(t.events)
 	close(t.operations)
 	close(t.statusChanged)
 	close(t.restored)
@@ -111,14 +93,27 @@ func (t *Tracker) Close() error {
 
 // Start -
 func (t *Tracker) Start(ctx context.Context) error {
-	if err := t.tezos.Init(ctx); err != nil {
-		return err
+	for chainType, c := range t.chains {
+		if err := c.Init(ctx); err != nil {
+			return errors.Wrapf(err, "init %s", chainType)
+		}
 	}
 
-	if err := t.eth.Init(ctx); err != nil {
+	if err := t.loadWatches(); err != nil {
 		return err
 	}
 
+	if !t.needRestore {
+		if err := t.initLevels(); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range t.chains {
+		t.wg.Add(1)
+		go t.listenChain(ctx, c)
+	}
+
 	t.wg.Add(1)
 	go t.listen(ctx)
 
@@ -127,33 +122,128 @@ func (t *Tracker) Start(ctx context.Context) error {
 			return err
 		}
 	} else {
-		t.restoreCounter = chainsCount
+		atomic.StoreInt32(&t.restoreCounter, int32(len(t.chains)))
 		t.restored <- struct{}{}
 	}
 
-	if err := t.tezos.Run(ctx); err != nil {
-		return err
+	for chainType, c := range t.chains {
+		if err := c.Run(ctx); err != nil {
+			return errors.Wrapf(err, "run %s", chainType)
+		}
 	}
 
-	if err := t.eth.Run(ctx); err != nil {
-		return err
+	return nil
+}
+
+func (t *Tracker) restore(ctx context.Context) error {
+	for chainType, c := range t.chains {
+		level, err := t.checkpoint(chainType)
+		if err != nil {
+			return err
+		}
+		if err := c.Restore(ctx, level); err != nil {
+			return errors.Wrapf(err, "restore %s", chainType)
+		}
 	}
+	return nil
+}
 
+// loadWatches - registers saved HTLCs in chains before restore, so their events are found again
+func (t *Tracker) loadWatches() error {
+	if t.watches == nil {
+		return nil
+	}
+	for chainType, c := range t.chains {
+		watcher, ok := c.(chain.Watcher)
+		if !ok {
+			continue
+		}
+		watches, err := t.watches.Watches(chainType)
+		if err != nil {
+			return errors.Wrapf(err, "watches of %s", chainType)
+		}
+		for i := range watches {
+			if err := watcher.Watch(watches[i]); err != nil {
+				return errors.Wrapf(err, "watch %s in %s", watches[i].HashedSecret, chainType)
+			}
+		}
+	}
 	return nil
 }
 
-func (t *Tracker) restore(ctx context.Context) error {
-	if err := t.tezos.Restore(ctx); err != nil {
-		return err
+func (t *Tracker) saveWatch(chainType chain.ChainType, args chain.WatchArgs) {
+	if t.watches == nil {
+		return
+	}
+	if err := t.watches.SaveWatch(chainType, args); err != nil {
+		t.logger.Err(err).Str("blockchain", chainType.String()).Str("hashed_secret", args.HashedSecret.String()).Msg("save watch")
 	}
+}
 
-	if err := t.eth.Restore(); err != nil {
-		return err
+// deleteWatch - HTLC isn't needed to be watched after it's redeemed or refunded
+func (t *Tracker) deleteWatch(event chain.Event) {
+	if t.watches == nil {
+		return
 	}
+	if _, ok := t.chains[event.ChainType()].(chain.Watcher); !ok {
+		return
+	}
+	if err := t.watches.DeleteWatch(event.ChainType(), event.HashedSecret()); err != nil {
+		t.logger.Err(err).Str("blockchain", event.ChainType().String()).Str("hashed_secret", event.HashedSecret().String()).Msg("delete watch")
+	}
+}
 
+// initLevels - levels are set by restored events if restore is enabled. Otherwise checkpoints are updated from saved levels.
+func (t *Tracker) initLevels() error {
+	for chainType := range t.chains {
+		level, err := t.checkpoint(chainType)
+		if err != nil {
+			return err
+		}
+		t.levels[chainType] = level
+	}
 	return nil
 }
 
+func (t *Tracker) isRestored() bool {
+	return atomic.LoadInt32(&t.restoreCounter) == int32(len(t.chains))
+}
+
+func (t *Tracker) checkpoint(chainType chain.ChainType) (uint64, error) {
+	if t.checkpoints == nil {
+		return 0, nil
+	}
+	level, err := t.checkpoints.Checkpoint(chainType)
+	if err != nil {
+		return 0, errors.Wrapf(err, "checkpoint of %s", chainType)
+	}
+	return level, nil
+}
+
+func (t *Tracker) saveCheckpoint(chainType chain.ChainType, level uint64) {
+	if t.checkpoints == nil {
+		return
+	}
+	if err := t.checkpoints.SaveCheckpoint(chainType, level); err != nil {
+		t.logger.Err(err).Str("blockchain", chainType.String()).Msg("save checkpoint")
+	}
+}
+
+// updateCheckpoint - events of each chain are received in level order after restore, so all levels before the event's level are fully processed.
+func (t *Tracker) updateCheckpoint(event chain.Event) {
+	level, ok := t.levels[event.ChainType()]
+	if !ok || event.Level() == 0 {
+		return
+	}
+
+	if checkpoint := event.Level() - 1; checkpoint > level {
+		t.levels[event.ChainType()] = checkpoint
+		if t.isRestored() {
+			t.saveCheckpoint(event.ChainType(), checkpoint)
+		}
+	}
+}
+
 func (t *Tracker) listen(ctx context.Context) {
 	defer t.wg.Done()
 
@@ -161,17 +251,22 @@ func (t *Tracker) listen(ctx context.Context) {
 		select {
 		case <-ctx.Done():
 			return
-
-		// Tezos
-		case event := <-t.tezos.Events():
+		case event := <-t.events:
 			t.onEvent(event)
-		case operation := <-t.tezos.Operations():
-			t.operations <- operation
+		}
+	}
+}
 
-		// Ethereum
-		case event := <-t.eth.Events():
-			t.onEvent(event)
-		case operation := <-t.eth.Operations():
+func (t *Tracker) listenChain(ctx context.Context, c chain.Chain) {
+	defer t.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-c.Events():
+			t.events <- event
+		case operation := <-c.Operations():
 			t.operations <- operation
 		}
 	}
@@ -186,14 +281,18 @@ func (t *Tracker) onEvent(event chain.Event) {
 	case chain.RefundEvent:
 		t.onRefund(e)
 	case chain.RestoredEvent:
-		t.logger.Info().Str("blockchain", e.Chain.String()).Msg("restored")
+		t.logger.Info().Str("blockchain", e.Chain.String()).Uint64("level", e.BlockNumber).Msg("restored")
+		t.levels[e.Chain] = e.BlockNumber
 		atomic.AddInt32(&t.restoreCounter, 1)
 		time.Sleep(2 * time.Second)
 
-		if t.restoreCounter == chainsCount {
+		if t.isRestored() {
 			for id := range t.swaps {
 				t.statusChanged <- *t.swaps[id]
 			}
+			for chainType, level := range t.levels {
+				t.saveCheckpoint(chainType, level)
+			}
 			time.Sleep(10 * time.Second)
 			t.restored <- struct{}{}
 		}
@@ -203,25 +302,30 @@ func (t *Tracker) onEvent(event chain.Event) {
 func (t *Tracker) onInit(event chain.InitEvent) {
 	swap := t.getSwap(event)
 	swap.fromInitEvent(event)
-	if t.restoreCounter == chainsCount {
+	if t.isRestored() {
 		t.statusChanged <- *swap
 	}
+	t.updateCheckpoint(event)
 }
 
 func (t *Tracker) onRedeem(event chain.RedeemEvent) {
 	swap := t.getSwap(event)
 	swap.fromRedeemEvent(event)
-	if t.restoreCounter == chainsCount {
+	if t.isRestored() {
 		t.statusChanged <- *swap
 	}
+	t.deleteWatch(event)
+	t.updateCheckpoint(event)
 }
 
 func (t *Tracker) onRefund(event chain.RefundEvent) {
 	swap := t.getSwap(event)
 	swap.fromRefundEvent(event)
-	if t.restoreCounter == chainsCount {
+	if t.isRestored() {
 		t.statusChanged <- *swap
 	}
+	t.deleteWatch(event)
+	t.updateCheckpoint(event)
 }
 
 func (t *Tracker) getSwap(event chain.Event) *Swap {
@@ -235,62 +339,74 @@ func (t *Tracker) getSwap(event chain.Event) *Swap {
 
 // Redeem -
 func (t *Tracker) Redeem(ctx context.Context, swap Swap, leg Leg) error {
-	switch leg.ChainType {
-	case chain.ChainTypeEthereum:
-		if err := t.eth.Redeem(ctx, swap.HashedSecret, swap.Secret, leg.Contract); err != nil {
-			return err
-		}
-	case chain.ChainTypeTezos:
-		if err := t.tezos.Redeem(ctx, swap.HashedSecret, swap.Secret, leg.Contract); err != nil {
-			return err
-		}
-	default:
+	c, ok := t.chains[leg.ChainType]
+	if !ok {
 		return errors.Wrapf(ErrUnknownChainType, "Redeem %v", leg.ChainType)
 	}
-	return nil
+	return c.Redeem(ctx, swap.HashedSecret, swap.Secret, leg.Contract)
 }
 
-// Redeem -
+// Refund -
 func (t *Tracker) Refund(ctx context.Context, swap Swap, leg Leg) error {
-	switch leg.ChainType {
-	case chain.ChainTypeEthereum:
-		if err := t.eth.Refund(ctx, swap.HashedSecret, leg.Contract); err != nil {
-			return err
-		}
-	case chain.ChainTypeTezos:
-		if err := t.tezos.Refund(ctx, swap.HashedSecret, leg.Contract); err != nil {
-			return err
-		}
-	default:
+	c, ok := t.chains[leg.ChainType]
+	if !ok {
 		return errors.Wrapf(ErrUnknownChainType, "Refund %v", leg.ChainType)
 	}
-	return nil
+	return c.Refund(ctx, swap.HashedSecret, leg.Contract)
 }
 
 // Initiate -
 func (t *Tracker) Initiate(ctx context.Context, args chain.InitiateArgs, chainType chain.ChainType) error {
-	switch chainType {
-	case chain.ChainTypeEthereum:
-		if err := t.eth.Initiate(ctx, args); err != nil {
-			return err
-		}
-	case chain.ChainTypeTezos:
-		if err := t.tezos.Initiate(ctx, args); err != nil {
-			return err
-		}
-	default:
+	c, ok := t.chains[chainType]
+	if !ok {
 		return errors.Wrapf(ErrUnknownChainType, "Initiate %v", chainType)
 	}
+	if err := c.Initiate(ctx, args); err != nil {
+		return err
+	}
+	// watcher registers own HTLC during initiation, it's saved to be found after restart
+	if _, ok := c.(chain.Watcher); ok {
+		t.saveWatch(chainType, chain.WatchArgs{
+			HashedSecret: args.HashedSecret,
+			Initiator:    c.Wallet().Address,
+			Participant:  args.Participant,
+			RefundTime:   args.RefundTime,
+		})
+	}
 	return nil
 }
 
 // Wallet -
 func (t *Tracker) Wallet(typ chain.ChainType) (chain.Wallet, error) {
-	switch typ {
-	case chain.ChainTypeEthereum:
-		return t.eth.Wallet(), nil
-	case chain.ChainTypeTezos:
-		return t.tezos.Wallet(), nil
+	c, ok := t.chains[typ]
+	if !ok {
+		return chain.Wallet{}, errors.Wrapf(ErrUnknownChainType, typ.String())
 	}
-	return chain.Wallet{}, errors.Wrapf(ErrUnknownChainType, typ.String())
+	return c.Wallet(), nil
+}
+
+// Balance - returns balance of watch tower's wallet in the chain in minimal units
+func (t *Tracker) Balance(ctx context.Context, typ chain.ChainType, args chain.BalanceArgs) (decimal.Decimal, error) {
+	c, ok := t.chains[typ]
+	if !ok {
+		return decimal.Zero, errors.Wrapf(ErrUnknownChainType, "Balance %v", typ)
+	}
+	return c.Balance(ctx, args)
+}
+
+// Watch - passes swap parameters to chain which can't find swaps by itself and saves them if watches storage is set. Other chains ignore it.
+func (t *Tracker) Watch(chainType chain.ChainType, args chain.WatchArgs) error {
+	c, ok := t.chains[chainType]
+	if !ok {
+		return errors.Wrapf(ErrUnknownChainType, "Watch %v", chainType)
+	}
+	watcher, ok := c.(chain.Watcher)
+	if !ok {
+		return nil
+	}
+	if err := watcher.Watch(args); err != nil {
+		return err
+	}
+	t.saveWatch(chainType, args)
+	return nil
 }
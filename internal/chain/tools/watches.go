@@ -0,0 +1,10 @@
+package tools
+
+import "github.com/atomex-protocol/watch_tower/internal/chain"
+
+// Watches - storage of HTLC parameters registered by `Watch` in chains which can't find swaps by themselves. Registered HTLCs are lost on restart without it.
+type Watches interface {
+	Watches(chainType chain.ChainType) ([]chain.WatchArgs, error)
+	SaveWatch(chainType chain.ChainType, args chain.WatchArgs) error
+	DeleteWatch(chainType chain.ChainType, hashedSecret chain.Hex) error
+}
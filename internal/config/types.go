@@ -2,9 +2,11 @@ package config
 
 import (
 	"context"
+	"os"
 	"path"
 	"time"
 
+	"github.com/atomex-protocol/watch_tower/internal/chain"
 	"github.com/atomex-protocol/watch_tower/internal/chain/tools"
 	"github.com/atomex-protocol/watch_tower/internal/types"
 	"github.com/pkg/errors"
@@ -57,11 +59,21 @@ func LoadGeneralConfig(ctx context.Context, configDir string) (general General,
 	}
 	general.Chains = chains
 
-	if err := general.Chains.Ethereum.FillContractAddresses(general.Assets); err != nil {
-		return general, errors.Wrap(err, "Ethereum.FillContractAddresses")
+	if general.Chains.Ethereum != nil {
+		if err := general.Chains.Ethereum.FillContractAddresses(chain.ChainTypeEthereum.String(), general.Assets); err != nil {
+			return general, errors.Wrap(err, "Ethereum.FillContractAddresses")
+		}
+	}
+	for name, network := range general.Chains.EVM {
+		chain.RegisterChainType(name)
+		if err := network.FillContractAddresses(name, general.Assets); err != nil {
+			return general, errors.Wrapf(err, "%s.FillContractAddresses", name)
+		}
 	}
-	if err := general.Chains.Tezos.FillContractAddresses(general.Assets); err != nil {
-		return general, errors.Wrap(err, "Tezos.FillContractAddresses")
+	if general.Chains.Tezos != nil {
+		if err := general.Chains.Tezos.FillContractAddresses(general.Assets); err != nil {
+			return general, errors.Wrap(err, "Tezos.FillContractAddresses")
+		}
 	}
 
 	atomexConfig, err := loadAtomex(ctx, configDir)
@@ -104,10 +116,17 @@ func loadChains(ctx context.Context, configDir string) (tools.Config, error) {
 		return chains, err
 	}
 
+	if chains.Tezos == nil {
+		return chains, nil
+	}
+
+	// tezos.yml is optional: gas, storage and fees are estimated by simulation and its values are caps
 	tezosCtx, cancelTezos := context.WithTimeout(ctx, time.Second)
 	defer cancelTezos()
-	err := Load(tezosCtx, path.Join(configDir, "tezos.yml"), &chains.Tezos.OperaitonParams)
-	return chains, err
+	if err := Load(tezosCtx, path.Join(configDir, "tezos.yml"), &chains.Tezos.OperaitonParams); err != nil && !os.IsNotExist(err) {
+		return chains, err
+	}
+	return chains, nil
 }
 
 func loadAtomex(ctx context.Context, configDir string) (Atomex, error) {
@@ -83,7 +83,36 @@ func (b *Binance) OHLC(symbol string) ([]exchange.OHLC, error) {
 	if err != nil {
 		return nil, err
 	}
+	return toOHLC(data), nil
+}
+
+// Klines - returns candles of `interval` which are opened between `start` and `end`. Zero `start` isn't sent, zero `end` means now.
+func (b *Binance) Klines(symbol string, interval Interval, start, end time.Time) ([]exchange.OHLC, error) {
+	var endTime uint64
+	if !end.IsZero() {
+		endTime = uint64(end.UnixMilli())
+	}
+
+	var startTime uint64
+	if !start.IsZero() {
+		startTime = uint64(start.UnixMilli())
+	}
+
+	result := make([]exchange.OHLC, 0)
+	for {
+		data, err := b.api.OHLC(symbol, interval, startTime, endTime, maxKlinesLimit)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, toOHLC(data)...)
+		if len(data) < maxKlinesLimit {
+			return result, nil
+		}
+		startTime = uint64(data[len(data)-1].OpenTime) + 1
+	}
+}
 
+func toOHLC(data []OHLC) []exchange.OHLC {
 	ohlc := make([]exchange.OHLC, len(data))
 	for i := range data {
 		ohlc[i] = exchange.OHLC{
@@ -95,7 +124,7 @@ func (b *Binance) OHLC(symbol string) ([]exchange.OHLC, error) {
 			Volume: data[i].Volume,
 		}
 	}
-	return ohlc, nil
+	return ohlc
 }
 
 func (b *Binance) listen() {
@@ -178,6 +178,46 @@ const (
 	IntervalMonth    = "1M"
 )
 
+// maximum count of klines in one request
+const maxKlinesLimit = 1000
+
+// Duration - returns duration of interval. Month is 30 days. Zero is returned for unknown interval.
+func (i Interval) Duration() time.Duration {
+	switch i {
+	case IntervalMinute1:
+		return time.Minute
+	case IntervalMinute3:
+		return 3 * time.Minute
+	case IntervalMinute5:
+		return 5 * time.Minute
+	case IntervalMinute15:
+		return 15 * time.Minute
+	case IntervalMinute30:
+		return 30 * time.Minute
+	case IntervalHour1:
+		return time.Hour
+	case IntervalHour2:
+		return 2 * time.Hour
+	case IntervalHour4:
+		return 4 * time.Hour
+	case IntervalHour6:
+		return 6 * time.Hour
+	case IntervalHour8:
+		return 8 * time.Hour
+	case IntervalHour12:
+		return 12 * time.Hour
+	case IntervalDay1:
+		return 24 * time.Hour
+	case IntervalDay3:
+		return 72 * time.Hour
+	case IntervalWeek:
+		return 7 * 24 * time.Hour
+	case IntervalMonth:
+		return 30 * 24 * time.Hour
+	}
+	return 0
+}
+
 // OHLC -
 type OHLC struct {
 	OpenTime            int64
@@ -212,3 +252,58 @@ func (ohlc *OHLC) UnmarshalJSON(data []byte) error {
 	}
 	return json.Unmarshal(data, &response)
 }
+
+// OrderResponse -
+type OrderResponse struct {
+	Symbol              string          `json:"symbol"`
+	OrderID             int64           `json:"orderId"`
+	ClientOrderID       string          `json:"clientOrderId"`
+	Price               decimal.Decimal `json:"price"`
+	OrigQty             decimal.Decimal `json:"origQty"`
+	ExecutedQty         decimal.Decimal `json:"executedQty"`
+	CummulativeQuoteQty decimal.Decimal `json:"cummulativeQuoteQty"`
+	Status              string          `json:"status"`
+	TimeInForce         string          `json:"timeInForce"`
+	Type                string          `json:"type"`
+	Side                string          `json:"side"`
+	TransactTime        int64           `json:"transactTime,omitempty"`
+	Time                int64           `json:"time,omitempty"`
+	UpdateTime          int64           `json:"updateTime,omitempty"`
+	Fills               []Fill          `json:"fills,omitempty"`
+}
+
+// Fill -
+type Fill struct {
+	Price           decimal.Decimal `json:"price"`
+	Qty             decimal.Decimal `json:"qty"`
+	Commission      decimal.Decimal `json:"commission"`
+	CommissionAsset string          `json:"commissionAsset"`
+}
+
+// Account -
+type Account struct {
+	CanTrade   bool             `json:"canTrade"`
+	UpdateTime int64            `json:"updateTime"`
+	Balances   []AccountBalance `json:"balances"`
+}
+
+// AccountBalance -
+type AccountBalance struct {
+	Asset  string          `json:"asset"`
+	Free   decimal.Decimal `json:"free"`
+	Locked decimal.Decimal `json:"locked"`
+}
+
+// time in force values
+const (
+	TimeInForceGTC = "GTC"
+	TimeInForceIOC = "IOC"
+	TimeInForceFOK = "FOK"
+)
+
+// filter types
+const (
+	FilterTypeLotSize     = "LOT_SIZE"
+	FilterTypePrice       = "PRICE_FILTER"
+	FilterTypeMinNotional = "MIN_NOTIONAL"
+)
@@ -17,6 +17,7 @@ import (
 	"github.com/atomex-protocol/watch_tower/internal/secrets"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
 	"golang.org/x/time/rate"
 )
 
@@ -26,6 +27,8 @@ type Rest struct {
 	delta         int64
 	publicLimiter *rate.Limiter
 	log           zerolog.Logger
+
+	symbols map[string]Symbol
 }
 
 func newRest(url string, logger zerolog.Logger) *Rest {
@@ -33,6 +36,7 @@ func newRest(url string, logger zerolog.Logger) *Rest {
 		url:           url,
 		publicLimiter: rate.NewLimiter(rate.Every(time.Minute), 1200),
 		log:           logger,
+		symbols:       make(map[string]Symbol),
 	}
 }
 
@@ -54,6 +58,10 @@ func (rest *Rest) Init() error {
 			rest.publicLimiter = getLimitInterval(limit)
 		}
 	}
+
+	for _, symbol := range info.Symbols {
+		rest.symbols[symbol.Symbol] = symbol
+	}
 	return nil
 }
 
@@ -207,3 +215,72 @@ func (rest *Rest) OHLC(symbol string, interval Interval, start, end, limit uint6
 	err = rest.request(false, http.MethodGet, "api/v3/klines", args, url.Values{}, 1, &data)
 	return
 }
+
+// NewOrder -
+func (rest *Rest) NewOrder(symbol, side, orderType string, qty, price decimal.Decimal, clientOrderID string) (data OrderResponse, err error) {
+	args := url.Values{}
+	args.Add("symbol", symbol)
+	args.Add("side", side)
+	args.Add("type", orderType)
+	args.Add("quantity", qty.String())
+	if orderType == string(exchange.OrderTypeLimit) {
+		args.Add("price", price.String())
+		args.Add("timeInForce", TimeInForceGTC)
+	}
+	if clientOrderID != "" {
+		args.Add("newClientOrderId", clientOrderID)
+	}
+	args.Add("newOrderRespType", "FULL")
+
+	err = rest.request(true, http.MethodPost, "api/v3/order", args, url.Values{}, 1, &data)
+	return
+}
+
+// CancelOrder -
+func (rest *Rest) CancelOrder(symbol string, orderID int64) (data OrderResponse, err error) {
+	args := url.Values{}
+	args.Add("symbol", symbol)
+	args.Add("orderId", strconv.FormatInt(orderID, 10))
+
+	err = rest.request(true, http.MethodDelete, "api/v3/order", args, url.Values{}, 1, &data)
+	return
+}
+
+// QueryOrder -
+func (rest *Rest) QueryOrder(symbol string, orderID int64) (data OrderResponse, err error) {
+	args := url.Values{}
+	args.Add("symbol", symbol)
+	args.Add("orderId", strconv.FormatInt(orderID, 10))
+
+	err = rest.request(true, http.MethodGet, "api/v3/order", args, url.Values{}, 2, &data)
+	return
+}
+
+// OpenOrders -
+func (rest *Rest) OpenOrders(symbol string) (data []OrderResponse, err error) {
+	args := url.Values{}
+	args.Add("symbol", symbol)
+
+	err = rest.request(true, http.MethodGet, "api/v3/openOrders", args, url.Values{}, 3, &data)
+	return
+}
+
+// Account -
+func (rest *Rest) Account() (data Account, err error) {
+	err = rest.request(true, http.MethodGet, "api/v3/account", url.Values{}, url.Values{}, 10, &data)
+	return
+}
+
+// LotSize - returns step and minimal quantity of symbol's orders. Zero values mean the symbol is unknown or has no filter.
+func (rest *Rest) LotSize(symbol string) (step decimal.Decimal, min decimal.Decimal) {
+	info, ok := rest.symbols[symbol]
+	if !ok {
+		return
+	}
+	for _, filter := range info.Filters {
+		if filter.FilterType == FilterTypeLotSize {
+			return filter.StepSize, filter.MinQty
+		}
+	}
+	return
+}
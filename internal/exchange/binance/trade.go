@@ -0,0 +1,96 @@
+package binance
+
+import (
+	"github.com/atomex-protocol/watch_tower/internal/exchange"
+	"github.com/pkg/errors"
+)
+
+// PlaceOrder - places order. Quantity is truncated by symbol's lot size step.
+func (b *Binance) PlaceOrder(request exchange.OrderRequest) (exchange.Order, error) {
+	qty := request.Qty
+	step, min := b.api.LotSize(request.Symbol)
+	if step.IsPositive() {
+		qty = qty.Div(step).Floor().Mul(step)
+	}
+	if !qty.IsPositive() || qty.LessThan(min) {
+		return exchange.Order{}, errors.Wrapf(exchange.ErrInvalidQty, "%s %s (min %s, step %s)", request.Symbol, request.Qty, min, step)
+	}
+
+	response, err := b.api.NewOrder(request.Symbol, string(request.Side), string(request.Type), qty, request.Price, request.ClientOrderID)
+	if err != nil {
+		return exchange.Order{}, errors.Wrap(err, "NewOrder")
+	}
+	return toOrder(response), nil
+}
+
+// CancelOrder -
+func (b *Binance) CancelOrder(symbol string, id int64) error {
+	if _, err := b.api.CancelOrder(symbol, id); err != nil {
+		return errors.Wrap(err, "CancelOrder")
+	}
+	return nil
+}
+
+// Order -
+func (b *Binance) Order(symbol string, id int64) (exchange.Order, error) {
+	response, err := b.api.QueryOrder(symbol, id)
+	if err != nil {
+		return exchange.Order{}, errors.Wrap(err, "QueryOrder")
+	}
+	return toOrder(response), nil
+}
+
+// OpenOrders -
+func (b *Binance) OpenOrders(symbol string) ([]exchange.Order, error) {
+	response, err := b.api.OpenOrders(symbol)
+	if err != nil {
+		return nil, errors.Wrap(err, "OpenOrders")
+	}
+
+	orders := make([]exchange.Order, len(response))
+	for i := range response {
+		orders[i] = toOrder(response[i])
+	}
+	return orders, nil
+}
+
+// Balances -
+func (b *Binance) Balances() ([]exchange.Balance, error) {
+	account, err := b.api.Account()
+	if err != nil {
+		return nil, errors.Wrap(err, "Account")
+	}
+
+	balances := make([]exchange.Balance, 0, len(account.Balances))
+	for _, balance := range account.Balances {
+		if balance.Free.IsZero() && balance.Locked.IsZero() {
+			continue
+		}
+		balances = append(balances, exchange.Balance{
+			Asset:  balance.Asset,
+			Free:   balance.Free,
+			Locked: balance.Locked,
+		})
+	}
+	return balances, nil
+}
+
+func toOrder(response OrderResponse) exchange.Order {
+	order := exchange.Order{
+		ID:            response.OrderID,
+		ClientOrderID: response.ClientOrderID,
+		Symbol:        response.Symbol,
+		Side:          exchange.OrderSide(response.Side),
+		Type:          exchange.OrderType(response.Type),
+		Status:        exchange.OrderStatus(response.Status),
+		Price:         response.Price,
+		Qty:           response.OrigQty,
+		ExecutedQty:   response.ExecutedQty,
+		QuoteQty:      response.CummulativeQuoteQty,
+	}
+	for _, fill := range response.Fills {
+		order.Commission = order.Commission.Add(fill.Commission)
+		order.CommissionAsset = fill.CommissionAsset
+	}
+	return order
+}
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/atomex-protocol/watch_tower/internal/logger"
 	"github.com/gorilla/websocket"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
@@ -31,6 +32,15 @@ type Websocket struct {
 	wg sync.WaitGroup
 }
 
+// NewWebsocket - creates websocket of market streams, e.g. for recording of raw events. Call `Connect` and subscribe to streams after creation.
+func NewWebsocket(opts ...BinanceOption) *Websocket {
+	options := newOptions()
+	for i := range opts {
+		opts[i](&options)
+	}
+	return newWebsocket(options.BaseURLWs, logger.New(logger.WithLogLevel(options.Level), logger.WithModuleName("binance_ws")))
+}
+
 func newWebsocket(url string, logger zerolog.Logger) *Websocket {
 	return &Websocket{
 		url:              url,
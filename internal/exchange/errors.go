@@ -3,6 +3,8 @@ package exchange
 import (
 	"fmt"
 	"time"
+
+	"github.com/pkg/errors"
 )
 
 // ErrToManyRequests -
@@ -14,3 +16,8 @@ type ErrToManyRequests struct {
 func (e ErrToManyRequests) Error() string {
 	return fmt.Sprintf("too many requests. retry after: %s", e.RetryAfter.String())
 }
+
+// errors
+var (
+	ErrInvalidQty = errors.New("invalid order quantity")
+)
@@ -32,3 +32,84 @@ type Ticker struct {
 	Bid       decimal.Decimal
 	BidVolume decimal.Decimal
 }
+
+// Trader - order execution on exchange
+type Trader interface {
+	PlaceOrder(request OrderRequest) (Order, error)
+	CancelOrder(symbol string, id int64) error
+	Order(symbol string, id int64) (Order, error)
+	OpenOrders(symbol string) ([]Order, error)
+	Balances() ([]Balance, error)
+}
+
+// OrderSide -
+type OrderSide string
+
+// order sides
+const (
+	OrderSideBuy  OrderSide = "BUY"
+	OrderSideSell OrderSide = "SELL"
+)
+
+// Opposite - returns opposite side
+func (side OrderSide) Opposite() OrderSide {
+	if side == OrderSideBuy {
+		return OrderSideSell
+	}
+	return OrderSideBuy
+}
+
+// OrderType -
+type OrderType string
+
+// order types
+const (
+	OrderTypeMarket OrderType = "MARKET"
+	OrderTypeLimit  OrderType = "LIMIT"
+)
+
+// OrderStatus -
+type OrderStatus string
+
+// order statuses
+const (
+	OrderStatusNew             OrderStatus = "NEW"
+	OrderStatusPartiallyFilled OrderStatus = "PARTIALLY_FILLED"
+	OrderStatusFilled          OrderStatus = "FILLED"
+	OrderStatusCanceled        OrderStatus = "CANCELED"
+	OrderStatusRejected        OrderStatus = "REJECTED"
+	OrderStatusExpired         OrderStatus = "EXPIRED"
+)
+
+// OrderRequest - `Price` is ignored for market orders
+type OrderRequest struct {
+	Symbol        string
+	ClientOrderID string
+	Side          OrderSide
+	Type          OrderType
+	Qty           decimal.Decimal
+	Price         decimal.Decimal
+}
+
+// Order - `Commission` is paid for executed quantity in `CommissionAsset`
+type Order struct {
+	ID              int64
+	ClientOrderID   string
+	Symbol          string
+	Side            OrderSide
+	Type            OrderType
+	Status          OrderStatus
+	Price           decimal.Decimal
+	Qty             decimal.Decimal
+	ExecutedQty     decimal.Decimal
+	QuoteQty        decimal.Decimal
+	Commission      decimal.Decimal
+	CommissionAsset string
+}
+
+// Balance -
+type Balance struct {
+	Asset  string
+	Free   decimal.Decimal
+	Locked decimal.Decimal
+}
@@ -0,0 +1,129 @@
+package recorder
+
+import (
+	"sync"
+	"time"
+
+	"github.com/atomex-protocol/watch_tower/internal/exchange"
+	"github.com/atomex-protocol/watch_tower/internal/exchange/binance"
+	"github.com/rs/zerolog"
+)
+
+// Exchange - replays recorded Binance events as `exchange.Exchange`. Tickers and book tickers are sent to `Tickers`,
+// closed klines which are replayed already are returned by `OHLC`.
+type Exchange struct {
+	*player
+
+	log     zerolog.Logger
+	symbols map[string]struct{}
+	tickers chan exchange.Ticker
+
+	mx     sync.RWMutex
+	klines map[string][]exchange.OHLC
+}
+
+// NewExchange -
+func NewExchange(files []string, speed float64, log zerolog.Logger) *Exchange {
+	return &Exchange{
+		player:  newPlayer(files, SourceBinance, speed),
+		log:     log,
+		symbols: make(map[string]struct{}),
+		tickers: make(chan exchange.Ticker, 1024),
+		klines:  make(map[string][]exchange.OHLC),
+	}
+}
+
+// Start - starts replay of `symbols`. All recorded symbols are replayed if `symbols` are empty.
+func (e *Exchange) Start(symbols ...string) error {
+	for i := range symbols {
+		e.symbols[symbols[i]] = struct{}{}
+	}
+
+	e.start(e.handle, func(err error) {
+		if err != nil {
+			e.log.Err(err).Msg("replay of Binance")
+			return
+		}
+		e.log.Info().Msg("replay of Binance is finished")
+	})
+	return nil
+}
+
+// Close -
+func (e *Exchange) Close() error {
+	e.close()
+	close(e.tickers)
+	return nil
+}
+
+// Tickers -
+func (e *Exchange) Tickers() <-chan exchange.Ticker {
+	return e.tickers
+}
+
+// OHLC -
+func (e *Exchange) OHLC(symbol string) ([]exchange.OHLC, error) {
+	e.mx.RLock()
+	defer e.mx.RUnlock()
+
+	klines := e.klines[symbol]
+	result := make([]exchange.OHLC, len(klines))
+	copy(result, klines)
+	return result, nil
+}
+
+func (e *Exchange) has(symbol string) bool {
+	if len(e.symbols) == 0 {
+		return true
+	}
+	_, ok := e.symbols[symbol]
+	return ok
+}
+
+func (e *Exchange) handle(record Record, value interface{}) error {
+	switch typ := value.(type) {
+	case binance.WebsocketTicker:
+		if e.has(typ.Symbol) {
+			return e.send(exchange.Ticker{
+				Symbol:    typ.Symbol,
+				Ask:       typ.Ask,
+				AskVolume: typ.AskQuantity,
+				Bid:       typ.Bid,
+				BidVolume: typ.BidQuantity,
+			})
+		}
+	case binance.BookTicker:
+		if e.has(typ.Symbol) {
+			return e.send(exchange.Ticker{
+				Symbol:    typ.Symbol,
+				Ask:       typ.Ask,
+				AskVolume: typ.AskVolume,
+				Bid:       typ.Bid,
+				BidVolume: typ.BidVolume,
+			})
+		}
+	case binance.KLine:
+		if e.has(typ.Symbol) && typ.KLine.IsClosed {
+			e.mx.Lock()
+			e.klines[typ.Symbol] = append(e.klines[typ.Symbol], exchange.OHLC{
+				Time:   time.UnixMilli(typ.KLine.OpenTime).UTC(),
+				Open:   typ.KLine.Open,
+				High:   typ.KLine.High,
+				Low:    typ.KLine.Low,
+				Close:  typ.KLine.Close,
+				Volume: typ.KLine.BaseVolume,
+			})
+			e.mx.Unlock()
+		}
+	}
+	return nil
+}
+
+func (e *Exchange) send(ticker exchange.Ticker) error {
+	select {
+	case <-e.stop:
+		return errStopped
+	case e.tickers <- ticker:
+		return nil
+	}
+}
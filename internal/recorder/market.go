@@ -0,0 +1,175 @@
+package recorder
+
+import (
+	"sync"
+
+	"github.com/atomex-protocol/watch_tower/internal/atomex"
+	"github.com/pkg/errors"
+)
+
+// Market - replays recorded Atomex market data as `atomex.MarketStream`. Entries and snapshots are sent after subscription to order book,
+// top of book is sent after subscription to top of book. `GetTopOfBook` and `GetSnapshot` reply with the last replayed data.
+type Market struct {
+	*player
+
+	msgs chan atomex.Message
+	errs chan error
+
+	mx        sync.Mutex
+	orderBook bool
+	topOfBook bool
+	tops      map[string]atomex.TopOfBook
+	snapshots map[string]atomex.Snapshot
+}
+
+// NewMarket -
+func NewMarket(files []string, speed float64) *Market {
+	return &Market{
+		player:    newPlayer(files, SourceAtomex, speed),
+		msgs:      make(chan atomex.Message, 1024),
+		errs:      make(chan error, 1024),
+		tops:      make(map[string]atomex.TopOfBook),
+		snapshots: make(map[string]atomex.Snapshot),
+	}
+}
+
+// Connect - starts replay. Token is ignored.
+func (m *Market) Connect(token atomex.TokenResponse) error {
+	m.start(m.handle, func(err error) {
+		if err != nil {
+			m.sendError(errors.Wrap(err, "replay of Atomex"))
+		}
+	})
+	return nil
+}
+
+// Close -
+func (m *Market) Close() error {
+	m.close()
+	close(m.msgs)
+	close(m.errs)
+	return nil
+}
+
+// Listen -
+func (m *Market) Listen() <-chan atomex.Message {
+	return m.msgs
+}
+
+// Errors -
+func (m *Market) Errors() <-chan error {
+	return m.errs
+}
+
+// SubscribeToOrderBook -
+func (m *Market) SubscribeToOrderBook() error {
+	m.mx.Lock()
+	m.orderBook = true
+	m.mx.Unlock()
+	return nil
+}
+
+// UnsubscribeFromOrderBook -
+func (m *Market) UnsubscribeFromOrderBook() error {
+	m.mx.Lock()
+	m.orderBook = false
+	m.mx.Unlock()
+	return nil
+}
+
+// SubscribeToTopOfBook -
+func (m *Market) SubscribeToTopOfBook() error {
+	m.mx.Lock()
+	m.topOfBook = true
+	m.mx.Unlock()
+	return nil
+}
+
+// UnsubscribeFromTopOfBook -
+func (m *Market) UnsubscribeFromTopOfBook() error {
+	m.mx.Lock()
+	m.topOfBook = false
+	m.mx.Unlock()
+	return nil
+}
+
+// GetTopOfBook - replies with the last replayed top of book of `symbols`
+func (m *Market) GetTopOfBook(symbols ...string) error {
+	if len(symbols) == 0 {
+		return errors.Wrap(atomex.ErrInvalidArg, "array of symbols is empty in GetTopOfBook")
+	}
+
+	m.mx.Lock()
+	tops := make([]atomex.TopOfBook, 0, len(symbols))
+	for i := range symbols {
+		if top, ok := m.tops[symbols[i]]; ok {
+			tops = append(tops, top)
+		}
+	}
+	m.mx.Unlock()
+
+	return m.send(atomex.Message{Event: atomex.WebsocketMethodTopOfBookReply, Value: tops})
+}
+
+// GetSnapshot - replies with the last replayed snapshot of `symbol`
+func (m *Market) GetSnapshot(symbol string) error {
+	if len(symbol) == 0 {
+		return errors.Wrap(atomex.ErrInvalidArg, "symbol is empty in GetSnapshot")
+	}
+
+	m.mx.Lock()
+	snapshot, ok := m.snapshots[symbol]
+	m.mx.Unlock()
+	if !ok {
+		return errors.Errorf("unknown snapshot of %s", symbol)
+	}
+
+	return m.send(atomex.Message{Event: atomex.WebsocketMethodSnapshotReply, Value: snapshot})
+}
+
+func (m *Market) handle(record Record, value interface{}) error {
+	m.mx.Lock()
+	var subscribed bool
+	switch typ := value.(type) {
+	case []atomex.TopOfBook:
+		for i := range typ {
+			m.tops[typ[i].Symbol] = typ[i]
+		}
+		subscribed = m.topOfBook
+	case atomex.Snapshot:
+		if typ.MarketData != nil {
+			m.snapshots[typ.Symbol] = typ
+		}
+		subscribed = m.orderBook
+	case []atomex.Entry:
+		subscribed = m.orderBook
+	}
+	m.mx.Unlock()
+
+	if !subscribed {
+		return nil
+	}
+	return m.send(atomex.Message{Event: atomex.WebsocketMethod(record.Event), Value: value})
+}
+
+func (m *Market) send(msg atomex.Message) error {
+	select {
+	case <-m.stop:
+		return errStopped
+	default:
+	}
+
+	select {
+	case <-m.stop:
+		return errStopped
+	case m.msgs <- msg:
+		return nil
+	}
+}
+
+func (m *Market) sendError(err error) {
+	select {
+	case <-m.stop:
+	case m.errs <- err:
+	}
+}
@@ -0,0 +1,77 @@
+package recorder
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Files - returns recorded files with `prefix` in `dir` in chronological order
+func Files(dir, prefix string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, prefix+"-*.jsonl.gz"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Read - reads records of `files` in order and passes them to `handler`. Reading stops on the first error of `handler`.
+// A truncated end of file, e.g. after a crash of the recorder, is skipped.
+func Read(files []string, handler func(record Record) error) error {
+	for i := range files {
+		if err := readFile(files[i], handler); err != nil {
+			return errors.Wrap(err, files[i])
+		}
+	}
+	return nil
+}
+
+func readFile(name string, handler func(record Record) error) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return errors.Wrap(err, "gzip.NewReader")
+	}
+	defer gz.Close()
+
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		data := scanner.Bytes()
+		if len(data) == 0 {
+			continue
+		}
+
+		var record Record
+		if err := json.Unmarshal(data, &record); err != nil {
+			// the last line of truncated file
+			if errors.Is(scanner.Err(), io.ErrUnexpectedEOF) {
+				return nil
+			}
+			return err
+		}
+		if err := handler(record); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+	return nil
+}
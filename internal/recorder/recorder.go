@@ -0,0 +1,94 @@
+package recorder
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/atomex-protocol/watch_tower/internal/atomex"
+	"github.com/atomex-protocol/watch_tower/internal/exchange/binance"
+	"github.com/pkg/errors"
+)
+
+// Source - source of recorded message
+type Source string
+
+// sources
+const (
+	SourceBinance Source = "binance"
+	SourceAtomex  Source = "atomex"
+)
+
+// Binance events
+const (
+	EventTicker     = "24hrTicker"
+	EventBookTicker = "bookTicker"
+	EventKLine      = "kline"
+)
+
+// Record - line of recorded file. `Time` is the time when the message was received. `Event` is a Binance event type or an Atomex websocket method.
+type Record struct {
+	Time   time.Time       `json:"time"`
+	Source Source          `json:"source"`
+	Event  string          `json:"event"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// Value - decodes data of record. It returns `binance.WebsocketTicker`, `binance.BookTicker`, `binance.KLine`,
+// `[]atomex.Entry`, `atomex.Snapshot` or `[]atomex.TopOfBook`.
+func (r Record) Value() (interface{}, error) {
+	switch r.Source {
+	case SourceBinance:
+		switch r.Event {
+		case EventTicker:
+			var ticker binance.WebsocketTicker
+			err := json.Unmarshal(r.Data, &ticker)
+			return ticker, err
+		case EventBookTicker:
+			var ticker binance.BookTicker
+			err := json.Unmarshal(r.Data, &ticker)
+			return ticker, err
+		case EventKLine:
+			var kline binance.KLine
+			err := json.Unmarshal(r.Data, &kline)
+			return kline, err
+		}
+	case SourceAtomex:
+		switch atomex.WebsocketMethod(r.Event) {
+		case atomex.WebsocketMethodEntriesReply:
+			var entries []atomex.Entry
+			err := json.Unmarshal(r.Data, &entries)
+			return entries, err
+		case atomex.WebsocketMethodSnapshotReply:
+			var snapshot atomex.Snapshot
+			err := json.Unmarshal(r.Data, &snapshot)
+			return snapshot, err
+		case atomex.WebsocketMethodTopOfBookReply:
+			var tops []atomex.TopOfBook
+			err := json.Unmarshal(r.Data, &tops)
+			return tops, err
+		}
+	}
+	return nil, errors.Errorf("unknown record: %s %s", r.Source, r.Event)
+}
+
+// binanceEvent - returns recorded name of Binance event. Book tickers have no event type.
+func binanceEvent(event binance.WebsocketEvent) (string, bool) {
+	switch event.Body.(type) {
+	case binance.WebsocketTicker:
+		return EventTicker, true
+	case binance.BookTicker:
+		return EventBookTicker, true
+	case binance.KLine:
+		return EventKLine, true
+	}
+	return "", false
+}
+
+// atomexEvent - only market data of Atomex is recorded
+func atomexEvent(msg atomex.Message) (string, bool) {
+	switch msg.Event {
+	case atomex.WebsocketMethodEntriesReply, atomex.WebsocketMethodSnapshotReply, atomex.WebsocketMethodTopOfBookReply:
+		return string(msg.Event), true
+	}
+	return "", false
+}
@@ -0,0 +1,128 @@
+package recorder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/atomex-protocol/watch_tower/internal/atomex"
+	"github.com/atomex-protocol/watch_tower/internal/exchange/binance"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeRecords(t *testing.T, dir string) {
+	d := decimal.RequireFromString
+
+	writer, err := NewWriter(dir, "market", time.Minute, 0)
+	require.NoError(t, err)
+
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	writer.now = func() time.Time { return now }
+
+	kline := binance.KLine{Symbol: "XTZUSDT"}
+	kline.KLine.OpenTime = now.Add(-time.Minute).UnixMilli()
+	kline.KLine.Open = d("1.5")
+	kline.KLine.Close = d("1.6")
+	kline.KLine.IsClosed = true
+
+	require.NoError(t, writer.Binance(binance.WebsocketEvent{Type: "24hrTicker", Body: binance.WebsocketTicker{Symbol: "XTZUSDT", Bid: d("1.5"), Ask: d("1.6")}}))
+	require.NoError(t, writer.Binance(binance.WebsocketEvent{Type: "kline", Body: kline}))
+	require.NoError(t, writer.Atomex(atomex.Message{Event: atomex.WebsocketMethodTopOfBookReply, Value: []atomex.TopOfBook{{Symbol: "XTZ/USDT", Bid: d("1.4"), Ask: d("1.7")}}}))
+	require.NoError(t, writer.Atomex(atomex.Message{Event: atomex.WebsocketMethodOrderReply, Value: atomex.OrderWebsocket{}}))
+
+	now = now.Add(time.Minute)
+	require.NoError(t, writer.Binance(binance.WebsocketEvent{Body: binance.BookTicker{Symbol: "ETHUSDT", Bid: d("3000"), Ask: d("3001")}}))
+	require.NoError(t, writer.Close())
+}
+
+func TestWriter(t *testing.T) {
+	dir := t.TempDir()
+	writeRecords(t, dir)
+
+	files, err := Files(dir, "market")
+	require.NoError(t, err)
+	require.Len(t, files, 2, "file is rotated each minute")
+
+	records := make([]Record, 0)
+	require.NoError(t, Read(files, func(record Record) error {
+		records = append(records, record)
+		return nil
+	}))
+	require.Len(t, records, 4, "order isn't market data")
+
+	assert.Equal(t, SourceBinance, records[0].Source)
+	assert.Equal(t, EventTicker, records[0].Event)
+	assert.Equal(t, EventKLine, records[1].Event)
+	assert.Equal(t, SourceAtomex, records[2].Source)
+	assert.Equal(t, EventBookTicker, records[3].Event)
+
+	value, err := records[2].Value()
+	require.NoError(t, err)
+	tops := value.([]atomex.TopOfBook)
+	require.Len(t, tops, 1)
+	assert.Equal(t, "1.7", tops[0].Ask.String())
+}
+
+func TestExchange(t *testing.T) {
+	dir := t.TempDir()
+	writeRecords(t, dir)
+
+	files, err := Files(dir, "market")
+	require.NoError(t, err)
+
+	ex := NewExchange(files, 0, zerolog.Nop())
+	require.NoError(t, ex.Start("XTZUSDT"))
+
+	select {
+	case ticker := <-ex.Tickers():
+		assert.Equal(t, "XTZUSDT", ticker.Symbol)
+		assert.Equal(t, "1.5", ticker.Bid.String())
+	case <-time.After(time.Second):
+		require.FailNow(t, "ticker timeout")
+	}
+
+	ex.wg.Wait()
+	klines, err := ex.OHLC("XTZUSDT")
+	require.NoError(t, err)
+	require.Len(t, klines, 1)
+	assert.Equal(t, "1.6", klines[0].Close.String())
+
+	select {
+	case ticker := <-ex.Tickers():
+		assert.Failf(t, "unexpected ticker", "%s", ticker.Symbol)
+	default:
+	}
+	require.NoError(t, ex.Close())
+}
+
+func TestMarket(t *testing.T) {
+	dir := t.TempDir()
+	writeRecords(t, dir)
+
+	files, err := Files(dir, "market")
+	require.NoError(t, err)
+
+	market := NewMarket(files, 0)
+	require.NoError(t, market.SubscribeToTopOfBook())
+	require.NoError(t, market.Connect(atomex.TokenResponse{}))
+
+	select {
+	case msg := <-market.Listen():
+		assert.Equal(t, atomex.WebsocketMethodTopOfBookReply, msg.Event)
+		assert.Len(t, msg.Value.([]atomex.TopOfBook), 1)
+	case <-time.After(time.Second):
+		require.FailNow(t, "message timeout")
+	}
+
+	market.wg.Wait()
+	require.NoError(t, market.GetTopOfBook("XTZ/USDT", "ETH/USDT"))
+	msg := <-market.Listen()
+	tops := msg.Value.([]atomex.TopOfBook)
+	require.Len(t, tops, 1)
+	assert.Equal(t, "XTZ/USDT", tops[0].Symbol)
+
+	assert.Error(t, market.GetSnapshot("XTZ/USDT"))
+	require.NoError(t, market.Close())
+}
@@ -0,0 +1,86 @@
+package recorder
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// errStopped - replay is stopped by `Close`
+var errStopped = errors.New("replay is stopped")
+
+// player - replays records of one source with the recorded pauses divided by `speed`. Zero `speed` replays records without pauses.
+type player struct {
+	files  []string
+	source Source
+	speed  float64
+
+	stop chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+func newPlayer(files []string, source Source, speed float64) *player {
+	return &player{
+		files:  files,
+		source: source,
+		speed:  speed,
+		stop:   make(chan struct{}),
+	}
+}
+
+// start - replays records in goroutine and calls `done` with the result of replay
+func (p *player) start(handler func(record Record, value interface{}) error, done func(err error)) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		err := p.play(handler)
+		if errors.Is(err, errStopped) {
+			err = nil
+		}
+		done(err)
+	}()
+}
+
+func (p *player) play(handler func(record Record, value interface{}) error) error {
+	var last time.Time
+	return Read(p.files, func(record Record) error {
+		if record.Source != p.source {
+			return nil
+		}
+
+		if p.speed > 0 && !last.IsZero() && record.Time.After(last) {
+			pause := time.Duration(float64(record.Time.Sub(last)) / p.speed)
+			timer := time.NewTimer(pause)
+			select {
+			case <-p.stop:
+				timer.Stop()
+				return errStopped
+			case <-timer.C:
+			}
+		}
+		last = record.Time
+
+		select {
+		case <-p.stop:
+			return errStopped
+		default:
+		}
+
+		value, err := record.Value()
+		if err != nil {
+			return err
+		}
+		return handler(record, value)
+	})
+}
+
+// close - stops replay and waits for its goroutine
+func (p *player) close() {
+	p.once.Do(func() {
+		close(p.stop)
+	})
+	p.wg.Wait()
+}
@@ -0,0 +1,154 @@
+package recorder
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/atomex-protocol/watch_tower/internal/atomex"
+	"github.com/atomex-protocol/watch_tower/internal/exchange/binance"
+	"github.com/pkg/errors"
+)
+
+// layout of time in file names. It's sorted lexicographically in chronological order.
+const fileTimeLayout = "20060102T150405.000000"
+
+// Writer - writes records to gzip compressed JSONL files `<dir>/<prefix>-<time>.jsonl.gz`.
+// New file is started each `interval` or when `maxSize` bytes of JSON are written to the current one. Zero values disable rotation.
+type Writer struct {
+	dir      string
+	prefix   string
+	interval time.Duration
+	maxSize  int64
+
+	mx     sync.Mutex
+	file   *os.File
+	gz     *gzip.Writer
+	opened time.Time
+	size   int64
+	now    func() time.Time
+}
+
+// NewWriter - creates `dir` if it doesn't exist
+func NewWriter(dir, prefix string, interval time.Duration, maxSize int64) (*Writer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrap(err, "MkdirAll")
+	}
+	return &Writer{
+		dir:      dir,
+		prefix:   prefix,
+		interval: interval,
+		maxSize:  maxSize,
+		now:      time.Now,
+	}, nil
+}
+
+// Binance - writes ticker, book ticker or kline event. Other events are skipped.
+func (w *Writer) Binance(event binance.WebsocketEvent) error {
+	name, ok := binanceEvent(event)
+	if !ok {
+		return nil
+	}
+	return w.Write(SourceBinance, name, event.Body)
+}
+
+// Atomex - writes entries, snapshot or top of book message. Other messages are skipped.
+func (w *Writer) Atomex(msg atomex.Message) error {
+	name, ok := atomexEvent(msg)
+	if !ok {
+		return nil
+	}
+	return w.Write(SourceAtomex, name, msg.Value)
+}
+
+// Write - writes record with current time
+func (w *Writer) Write(source Source, event string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	now := w.now().UTC()
+	line, err := json.Marshal(Record{
+		Time:   now,
+		Source: source,
+		Event:  event,
+		Data:   data,
+	})
+	if err != nil {
+		return err
+	}
+
+	if w.rotationNeeded(now) {
+		if err := w.rotate(now); err != nil {
+			return errors.Wrap(err, "rotate")
+		}
+	}
+
+	line = append(line, '\n')
+	if _, err := w.gz.Write(line); err != nil {
+		return err
+	}
+	w.size += int64(len(line))
+	return nil
+}
+
+func (w *Writer) rotationNeeded(now time.Time) bool {
+	switch {
+	case w.file == nil:
+		return true
+	case w.interval > 0 && now.Sub(w.opened) >= w.interval:
+		return true
+	case w.maxSize > 0 && w.size >= w.maxSize:
+		return true
+	}
+	return false
+}
+
+func (w *Writer) rotate(now time.Time) error {
+	if err := w.closeFile(); err != nil {
+		return err
+	}
+
+	name := filepath.Join(w.dir, fmt.Sprintf("%s-%s.jsonl.gz", w.prefix, now.Format(fileTimeLayout)))
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	w.file = file
+	w.gz = gzip.NewWriter(file)
+	w.opened = now
+	w.size = 0
+	return nil
+}
+
+func (w *Writer) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+	if err := w.gz.Close(); err != nil {
+		return err
+	}
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+	w.gz = nil
+	return nil
+}
+
+// Close - flushes and closes current file
+func (w *Writer) Close() error {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	return w.closeFile()
+}
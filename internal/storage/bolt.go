@@ -0,0 +1,221 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/atomex-protocol/watch_tower/internal/chain"
+	"github.com/atomex-protocol/watch_tower/internal/chain/tools"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// buckets
+var (
+	bucketSwaps       = []byte("swaps")
+	bucketOperations  = []byte("operations")
+	bucketCheckpoints = []byte("checkpoints")
+	bucketWatches     = []byte("watches")
+)
+
+// Bolt - storage which keeps state in embedded BoltDB file
+type Bolt struct {
+	db *bolt.DB
+}
+
+// NewBolt -
+func NewBolt(path string) (*Bolt, error) {
+	if path == "" {
+		return nil, errors.New("empty path to bolt database")
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, err
+		}
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, errors.Wrap(err, "bolt.Open")
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketSwaps, bucketOperations, bucketCheckpoints, bucketWatches} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return migrateOperationKeys(tx.Bucket(bucketOperations))
+	}); err != nil {
+		return nil, err
+	}
+
+	return &Bolt{db}, nil
+}
+
+// Swaps -
+func (b *Bolt) Swaps() ([]Swap, error) {
+	swaps := make([]Swap, 0)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketSwaps).ForEach(func(_, value []byte) error {
+			var swap Swap
+			if err := json.Unmarshal(value, &swap); err != nil {
+				return err
+			}
+			swaps = append(swaps, swap)
+			return nil
+		})
+	})
+	return swaps, err
+}
+
+// SaveSwap -
+func (b *Bolt) SaveSwap(swap Swap) error {
+	return b.put(bucketSwaps, []byte(swap.HashedSecret), swap)
+}
+
+// DeleteSwap -
+func (b *Bolt) DeleteSwap(hashedSecret chain.Hex) error {
+	return b.delete(bucketSwaps, []byte(hashedSecret))
+}
+
+// Operations -
+func (b *Bolt) Operations() ([]chain.Operation, error) {
+	operations := make([]chain.Operation, 0)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketOperations).ForEach(func(_, value []byte) error {
+			var operation chain.Operation
+			if err := json.Unmarshal(value, &operation); err != nil {
+				return err
+			}
+			operations = append(operations, operation)
+			return nil
+		})
+	})
+	return operations, err
+}
+
+// SaveOperation -
+func (b *Bolt) SaveOperation(operation chain.Operation) error {
+	return b.put(bucketOperations, operationKey(tools.OperationID{
+		Hash:  operation.Hash,
+		Chain: operation.ChainType,
+	}), operation)
+}
+
+// DeleteOperation -
+func (b *Bolt) DeleteOperation(id tools.OperationID) error {
+	return b.delete(bucketOperations, operationKey(id))
+}
+
+// Checkpoint - returns saved level of chain. If it was not saved returns 0.
+func (b *Bolt) Checkpoint(chainType chain.ChainType) (level uint64, err error) {
+	err = b.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(bucketCheckpoints).Get([]byte(chainType.String()))
+		if value == nil {
+			return nil
+		}
+		return json.Unmarshal(value, &level)
+	})
+	return
+}
+
+// SaveCheckpoint -
+func (b *Bolt) SaveCheckpoint(chainType chain.ChainType, level uint64) error {
+	return b.put(bucketCheckpoints, []byte(chainType.String()), level)
+}
+
+// Watches -
+func (b *Bolt) Watches(chainType chain.ChainType) ([]chain.WatchArgs, error) {
+	watches := make([]chain.WatchArgs, 0)
+	prefix := []byte(chainType.String() + ":")
+	err := b.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(bucketWatches).Cursor()
+		for key, value := cursor.Seek(prefix); key != nil && bytes.HasPrefix(key, prefix); key, value = cursor.Next() {
+			var args chain.WatchArgs
+			if err := json.Unmarshal(value, &args); err != nil {
+				return err
+			}
+			watches = append(watches, args)
+		}
+		return nil
+	})
+	return watches, err
+}
+
+// SaveWatch -
+func (b *Bolt) SaveWatch(chainType chain.ChainType, args chain.WatchArgs) error {
+	return b.put(bucketWatches, watchKey(chainType, args.HashedSecret), args)
+}
+
+// DeleteWatch -
+func (b *Bolt) DeleteWatch(chainType chain.ChainType, hashedSecret chain.Hex) error {
+	return b.delete(bucketWatches, watchKey(chainType, hashedSecret))
+}
+
+// Close -
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
+
+func (b *Bolt) put(bucket, key []byte, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put(key, data)
+	})
+}
+
+func (b *Bolt) delete(bucket, key []byte) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Delete(key)
+	})
+}
+
+// operationKey - chain is set by name because values of registered chain types depend on registration order
+func operationKey(id tools.OperationID) []byte {
+	return []byte(fmt.Sprintf("%s:%s", id.Chain, id.Hash))
+}
+
+// watchKey - chain is set by name as in `operationKey`
+func watchKey(chainType chain.ChainType, hashedSecret chain.Hex) []byte {
+	return []byte(fmt.Sprintf("%s:%s", chainType, hashedSecret))
+}
+
+// migrateOperationKeys - re-keys operations which were saved with numeric chain types
+func migrateOperationKeys(bucket *bolt.Bucket) error {
+	keys := make(map[string][]byte)
+	if err := bucket.ForEach(func(key, value []byte) error {
+		var operation chain.Operation
+		if err := json.Unmarshal(value, &operation); err != nil {
+			return err
+		}
+		newKey := operationKey(tools.OperationID{
+			Hash:  operation.Hash,
+			Chain: operation.ChainType,
+		})
+		if string(newKey) != string(key) {
+			keys[string(key)] = newKey
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for oldKey, newKey := range keys {
+		value := bucket.Get([]byte(oldKey))
+		if err := bucket.Put(newKey, value); err != nil {
+			return err
+		}
+		if err := bucket.Delete([]byte(oldKey)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"sync"
+
+	"github.com/atomex-protocol/watch_tower/internal/chain"
+	"github.com/atomex-protocol/watch_tower/internal/chain/tools"
+)
+
+// Memory - storage which keeps state in memory only. It's used for tests and when persistence is not needed.
+type Memory struct {
+	swaps       map[chain.Hex]Swap
+	operations  map[tools.OperationID]chain.Operation
+	checkpoints map[chain.ChainType]uint64
+	watches     map[chain.ChainType]map[chain.Hex]chain.WatchArgs
+
+	mx sync.RWMutex
+}
+
+// NewMemory -
+func NewMemory() *Memory {
+	return &Memory{
+		swaps:       make(map[chain.Hex]Swap),
+		operations:  make(map[tools.OperationID]chain.Operation),
+		checkpoints: make(map[chain.ChainType]uint64),
+		watches:     make(map[chain.ChainType]map[chain.Hex]chain.WatchArgs),
+	}
+}
+
+// Swaps -
+func (m *Memory) Swaps() ([]Swap, error) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	swaps := make([]Swap, 0, len(m.swaps))
+	for _, swap := range m.swaps {
+		swaps = append(swaps, swap)
+	}
+	return swaps, nil
+}
+
+// SaveSwap -
+func (m *Memory) SaveSwap(swap Swap) error {
+	m.mx.Lock()
+	m.swaps[swap.HashedSecret] = swap
+	m.mx.Unlock()
+	return nil
+}
+
+// DeleteSwap -
+func (m *Memory) DeleteSwap(hashedSecret chain.Hex) error {
+	m.mx.Lock()
+	delete(m.swaps, hashedSecret)
+	m.mx.Unlock()
+	return nil
+}
+
+// Operations -
+func (m *Memory) Operations() ([]chain.Operation, error) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	operations := make([]chain.Operation, 0, len(m.operations))
+	for _, operation := range m.operations {
+		operations = append(operations, operation)
+	}
+	return operations, nil
+}
+
+// SaveOperation -
+func (m *Memory) SaveOperation(operation chain.Operation) error {
+	m.mx.Lock()
+	m.operations[tools.OperationID{
+		Hash:  operation.Hash,
+		Chain: operation.ChainType,
+	}] = operation
+	m.mx.Unlock()
+	return nil
+}
+
+// DeleteOperation -
+func (m *Memory) DeleteOperation(id tools.OperationID) error {
+	m.mx.Lock()
+	delete(m.operations, id)
+	m.mx.Unlock()
+	return nil
+}
+
+// Checkpoint -
+func (m *Memory) Checkpoint(chainType chain.ChainType) (uint64, error) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+	return m.checkpoints[chainType], nil
+}
+
+// SaveCheckpoint -
+func (m *Memory) SaveCheckpoint(chainType chain.ChainType, level uint64) error {
+	m.mx.Lock()
+	m.checkpoints[chainType] = level
+	m.mx.Unlock()
+	return nil
+}
+
+// Watches -
+func (m *Memory) Watches(chainType chain.ChainType) ([]chain.WatchArgs, error) {
+	m.mx.RLock()
+	defer m.mx.RUnlock()
+
+	watches := make([]chain.WatchArgs, 0, len(m.watches[chainType]))
+	for _, args := range m.watches[chainType] {
+		watches = append(watches, args)
+	}
+	return watches, nil
+}
+
+// SaveWatch -
+func (m *Memory) SaveWatch(chainType chain.ChainType, args chain.WatchArgs) error {
+	m.mx.Lock()
+	if _, ok := m.watches[chainType]; !ok {
+		m.watches[chainType] = make(map[chain.Hex]chain.WatchArgs)
+	}
+	m.watches[chainType][args.HashedSecret] = args
+	m.mx.Unlock()
+	return nil
+}
+
+// DeleteWatch -
+func (m *Memory) DeleteWatch(chainType chain.ChainType, hashedSecret chain.Hex) error {
+	m.mx.Lock()
+	delete(m.watches[chainType], hashedSecret)
+	m.mx.Unlock()
+	return nil
+}
+
+// Close -
+func (m *Memory) Close() error {
+	return nil
+}
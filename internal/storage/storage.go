@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"io"
+
+	"github.com/atomex-protocol/watch_tower/internal/chain"
+	"github.com/atomex-protocol/watch_tower/internal/chain/tools"
+	"github.com/pkg/errors"
+)
+
+// errors
+var (
+	ErrUnknownKind = errors.New("unknown storage kind")
+)
+
+// Storage -
+type Storage interface {
+	io.Closer
+
+	Swaps() ([]Swap, error)
+	SaveSwap(swap Swap) error
+	DeleteSwap(hashedSecret chain.Hex) error
+
+	Operations() ([]chain.Operation, error)
+	SaveOperation(operation chain.Operation) error
+	DeleteOperation(id tools.OperationID) error
+
+	tools.Checkpoints
+	tools.Watches
+}
+
+// Swap -
+type Swap struct {
+	tools.Swap
+	RetryCount uint
+}
+
+// Kind -
+type Kind string
+
+// kinds
+const (
+	KindMemory Kind = "memory"
+	KindBolt   Kind = "bolt"
+)
+
+// Config -
+type Config struct {
+	Kind Kind   `yaml:"kind" validate:"omitempty,oneof=memory bolt"`
+	Path string `yaml:"path"`
+}
+
+// New -
+func New(cfg Config) (Storage, error) {
+	switch cfg.Kind {
+	case KindMemory, "":
+		return NewMemory(), nil
+	case KindBolt:
+		return NewBolt(cfg.Path)
+	default:
+		return nil, errors.Wrap(ErrUnknownKind, string(cfg.Kind))
+	}
+}
@@ -0,0 +1,159 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/atomex-protocol/watch_tower/internal/chain"
+	"github.com/atomex-protocol/watch_tower/internal/chain/tools"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{
+			name: "memory",
+			cfg:  Config{Kind: KindMemory},
+		}, {
+			name: "bolt",
+			cfg: Config{
+				Kind: KindBolt,
+				Path: filepath.Join(t.TempDir(), "state", "watch_tower.db"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := New(tt.cfg)
+			require.NoError(t, err)
+			defer store.Close()
+
+			swap := Swap{
+				Swap: tools.Swap{
+					HashedSecret:    "a1b2",
+					Secret:          "c3d4",
+					Status:          tools.StatusRedeemedOnce,
+					RefundTime:      time.Date(2022, 4, 1, 10, 0, 0, 0, time.UTC),
+					RewardForRedeem: decimal.RequireFromString("1000"),
+					Initiator: tools.Leg{
+						ChainType: chain.ChainTypeTezos,
+						Address:   "tz1",
+						Contract:  "KT1",
+						Status:    tools.StatusRedeemed,
+					},
+					Acceptor: tools.Leg{
+						ChainType: chain.ChainTypeEthereum,
+						Address:   "0x1",
+						Contract:  "0x2",
+						Status:    tools.StatusInitiated,
+					},
+				},
+				RetryCount: 2,
+			}
+			require.NoError(t, store.SaveSwap(swap))
+
+			operation := chain.Operation{
+				Hash:         "oo1",
+				ChainType:    chain.ChainTypeTezos,
+				Status:       chain.Pending,
+				HashedSecret: "a1b2",
+			}
+			require.NoError(t, store.SaveOperation(operation))
+
+			swaps, err := store.Swaps()
+			require.NoError(t, err)
+			require.Len(t, swaps, 1)
+			assert.Equal(t, swap.HashedSecret, swaps[0].HashedSecret)
+			assert.Equal(t, swap.Status, swaps[0].Status)
+			assert.Equal(t, swap.RetryCount, swaps[0].RetryCount)
+			assert.Equal(t, swap.Initiator, swaps[0].Initiator)
+			assert.Equal(t, swap.Acceptor, swaps[0].Acceptor)
+			assert.True(t, swap.RefundTime.Equal(swaps[0].RefundTime))
+			assert.True(t, swap.RewardForRedeem.Equal(swaps[0].RewardForRedeem))
+
+			operations, err := store.Operations()
+			require.NoError(t, err)
+			assert.Equal(t, []chain.Operation{operation}, operations)
+
+			level, err := store.Checkpoint(chain.ChainTypeTezos)
+			require.NoError(t, err)
+			assert.Zero(t, level)
+
+			require.NoError(t, store.SaveCheckpoint(chain.ChainTypeTezos, 2300000))
+			level, err = store.Checkpoint(chain.ChainTypeTezos)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(2300000), level)
+
+			watch := chain.WatchArgs{
+				HashedSecret: "a1b2",
+				Initiator:    "bcrt1qinitiator",
+				Participant:  "bcrt1qparticipant",
+				RefundTime:   time.Date(2022, 4, 1, 10, 0, 0, 0, time.UTC),
+			}
+			require.NoError(t, store.SaveWatch(chain.ChainTypeBitcoin, watch))
+
+			watches, err := store.Watches(chain.ChainTypeBitcoin)
+			require.NoError(t, err)
+			require.Len(t, watches, 1)
+			assert.Equal(t, watch.Initiator, watches[0].Initiator)
+			assert.Equal(t, watch.Participant, watches[0].Participant)
+			assert.True(t, watch.RefundTime.Equal(watches[0].RefundTime))
+
+			watches, err = store.Watches(chain.ChainTypeTezos)
+			require.NoError(t, err)
+			assert.Empty(t, watches)
+
+			require.NoError(t, store.DeleteSwap(swap.HashedSecret))
+			require.NoError(t, store.DeleteWatch(chain.ChainTypeBitcoin, watch.HashedSecret))
+			require.NoError(t, store.DeleteOperation(tools.OperationID{Hash: operation.Hash, Chain: operation.ChainType}))
+
+			swaps, err = store.Swaps()
+			require.NoError(t, err)
+			assert.Empty(t, swaps)
+
+			operations, err = store.Operations()
+			require.NoError(t, err)
+			assert.Empty(t, operations)
+
+			watches, err = store.Watches(chain.ChainTypeBitcoin)
+			require.NoError(t, err)
+			assert.Empty(t, watches)
+		})
+	}
+}
+
+func TestBolt_migrateOperationKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watch_tower.db")
+
+	store, err := NewBolt(path)
+	require.NoError(t, err)
+
+	// operation saved by key with numeric chain type
+	operation := chain.Operation{
+		Hash:      "0x1",
+		ChainType: chain.ChainTypeEthereum,
+		Status:    chain.Pending,
+	}
+	require.NoError(t, store.put(bucketOperations, []byte(fmt.Sprintf("%d:%s", operation.ChainType, operation.Hash)), operation))
+	require.NoError(t, store.Close())
+
+	store, err = NewBolt(path)
+	require.NoError(t, err)
+	defer store.Close()
+
+	operations, err := store.Operations()
+	require.NoError(t, err)
+	assert.Equal(t, []chain.Operation{operation}, operations)
+
+	require.NoError(t, store.DeleteOperation(tools.OperationID{Hash: operation.Hash, Chain: operation.ChainType}))
+	operations, err = store.Operations()
+	require.NoError(t, err)
+	assert.Empty(t, operations)
+}
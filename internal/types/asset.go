@@ -9,16 +9,10 @@ type Asset struct {
 	Contract       string `yaml:"contract"`
 	AtomexContract string `yaml:"atomex_contract" validate:"require"`
 	Decimals       int    `yaml:"decimals" validate:"require"`
+	TokenID        uint64 `yaml:"token_id"`
 }
 
 // ChainType -
 func (a Asset) ChainType() chain.ChainType {
-	switch a.Chain {
-	case "ethereum":
-		return chain.ChainTypeEthereum
-	case "tezos":
-		return chain.ChainTypeTezos
-	default:
-		return chain.ChainTypeUnknown
-	}
+	return chain.ChainTypeFromString(a.Chain)
 }